@@ -27,13 +27,19 @@ type Peerface interface {
 // calls below determines if we return the Val
 // on Get calls. Val must always be provided
 // on Set.
+//
+// Signature, when the file came with one, is
+// the signed manifest it was uploaded with, as
+// checked against the key of Signer.
 type KeyInv struct {
-	Key     []byte
-	Who     string
-	When    time.Time
-	Size    int64
-	Blake2b []byte
-	Val     []byte
+	Key       []byte
+	Who       string
+	When      time.Time
+	Size      int64
+	Blake2b   []byte
+	Val       []byte
+	Signer    string
+	Signature []byte
 }
 
 func (ki *KeyInv) String() string {
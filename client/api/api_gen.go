@@ -709,6 +709,16 @@ func (z *KeyInv) DecodeMsg(dc *msgp.Reader) (err error) {
 			if err != nil {
 				return
 			}
+		case "Signer":
+			z.Signer, err = dc.ReadString()
+			if err != nil {
+				return
+			}
+		case "Signature":
+			z.Signature, err = dc.ReadBytes(z.Signature)
+			if err != nil {
+				return
+			}
 		default:
 			err = dc.Skip()
 			if err != nil {
@@ -721,9 +731,9 @@ func (z *KeyInv) DecodeMsg(dc *msgp.Reader) (err error) {
 
 // EncodeMsg implements msgp.Encodable
 func (z *KeyInv) EncodeMsg(en *msgp.Writer) (err error) {
-	// map header, size 6
+	// map header, size 8
 	// write "Key"
-	err = en.Append(0x86, 0xa3, 0x4b, 0x65, 0x79)
+	err = en.Append(0x88, 0xa3, 0x4b, 0x65, 0x79)
 	if err != nil {
 		return err
 	}
@@ -776,15 +786,33 @@ func (z *KeyInv) EncodeMsg(en *msgp.Writer) (err error) {
 	if err != nil {
 		return
 	}
+	// write "Signer"
+	err = en.Append(0xa6, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72)
+	if err != nil {
+		return err
+	}
+	err = en.WriteString(z.Signer)
+	if err != nil {
+		return
+	}
+	// write "Signature"
+	err = en.Append(0xa9, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	if err != nil {
+		return err
+	}
+	err = en.WriteBytes(z.Signature)
+	if err != nil {
+		return
+	}
 	return
 }
 
 // MarshalMsg implements msgp.Marshaler
 func (z *KeyInv) MarshalMsg(b []byte) (o []byte, err error) {
 	o = msgp.Require(b, z.Msgsize())
-	// map header, size 6
+	// map header, size 8
 	// string "Key"
-	o = append(o, 0x86, 0xa3, 0x4b, 0x65, 0x79)
+	o = append(o, 0x88, 0xa3, 0x4b, 0x65, 0x79)
 	o = msgp.AppendBytes(o, z.Key)
 	// string "Who"
 	o = append(o, 0xa3, 0x57, 0x68, 0x6f)
@@ -801,6 +829,12 @@ func (z *KeyInv) MarshalMsg(b []byte) (o []byte, err error) {
 	// string "Val"
 	o = append(o, 0xa3, 0x56, 0x61, 0x6c)
 	o = msgp.AppendBytes(o, z.Val)
+	// string "Signer"
+	o = append(o, 0xa6, 0x53, 0x69, 0x67, 0x6e, 0x65, 0x72)
+	o = msgp.AppendString(o, z.Signer)
+	// string "Signature"
+	o = append(o, 0xa9, 0x53, 0x69, 0x67, 0x6e, 0x61, 0x74, 0x75, 0x72, 0x65)
+	o = msgp.AppendBytes(o, z.Signature)
 	return
 }
 
@@ -850,6 +884,16 @@ func (z *KeyInv) UnmarshalMsg(bts []byte) (o []byte, err error) {
 			if err != nil {
 				return
 			}
+		case "Signer":
+			z.Signer, bts, err = msgp.ReadStringBytes(bts)
+			if err != nil {
+				return
+			}
+		case "Signature":
+			z.Signature, bts, err = msgp.ReadBytesBytes(bts, z.Signature)
+			if err != nil {
+				return
+			}
 		default:
 			bts, err = msgp.Skip(bts)
 			if err != nil {
@@ -863,6 +907,6 @@ func (z *KeyInv) UnmarshalMsg(bts []byte) (o []byte, err error) {
 
 // Msgsize returns an upper bound estimate of the number of bytes occupied by the serialized message
 func (z *KeyInv) Msgsize() (s int) {
-	s = 1 + 4 + msgp.BytesPrefixSize + len(z.Key) + 4 + msgp.StringPrefixSize + len(z.Who) + 5 + msgp.TimeSize + 5 + msgp.Int64Size + 8 + msgp.BytesPrefixSize + len(z.Blake2b) + 4 + msgp.BytesPrefixSize + len(z.Val)
+	s = 1 + 4 + msgp.BytesPrefixSize + len(z.Key) + 4 + msgp.StringPrefixSize + len(z.Who) + 5 + msgp.TimeSize + 5 + msgp.Int64Size + 8 + msgp.BytesPrefixSize + len(z.Blake2b) + 4 + msgp.BytesPrefixSize + len(z.Val) + 7 + msgp.StringPrefixSize + len(z.Signer) + 10 + msgp.BytesPrefixSize + len(z.Signature)
 	return
 }
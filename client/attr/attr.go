@@ -0,0 +1,191 @@
+// Package attr reads and applies the file metadata
+// (mode, ownership, times, extended attributes) that
+// travels with a file as a pb.FileAttr.
+package attr
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"time"
+
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+)
+
+// Policy decides how much of a FileAttr gets applied
+// to a received file. Each level includes the ones before it.
+type Policy int
+
+const (
+	// None applies nothing; files get default permissions.
+	None Policy = iota
+
+	// Mode applies the permission bits and the mtime/atime.
+	Mode
+
+	// Owner also applies the owner and group, and the
+	// setuid/setgid bits. Changing owners usually needs root.
+	Owner
+
+	// Full also applies extended attributes, and with them ACLs.
+	Full
+)
+
+var policyNames = []string{"none", "mode", "owner", "full"}
+
+func (p Policy) String() string {
+	if p < None || p > Full {
+		return fmt.Sprintf("Policy(%d)", int(p))
+	}
+
+	return policyNames[p]
+}
+
+// ParsePolicy turns one of "none", "mode", "owner" or "full" into a Policy.
+func ParsePolicy(s string) (Policy, error) {
+	for i, name := range policyNames {
+		if s == name {
+			return Policy(i), nil
+		}
+	}
+
+	return None, fmt.Errorf("unknown attribute policy '%s'; must be one of %v", s, policyNames)
+}
+
+// POSIX mode bits beyond the permissions.
+const (
+	modeSetuid = 04000
+	modeSetgid = 02000
+	modeSticky = 01000
+)
+
+// FromFile reads the metadata of the file at path.
+// Extended attributes are only read if withXattrs is set.
+func FromFile(path string, withXattrs bool) (*pb.FileAttr, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &pb.FileAttr{
+		Mode:  modeToPosix(fi.Mode()),
+		Mtime: fi.ModTime().UnixNano(),
+	}
+	fillFromSys(a, fi)
+
+	if u, err := user.LookupId(strconv.Itoa(int(a.Uid))); err == nil {
+		a.User = u.Username
+	}
+	if g, err := user.LookupGroupId(strconv.Itoa(int(a.Gid))); err == nil {
+		a.Group = g.Name
+	}
+
+	if withXattrs {
+		a.Xattrs, err = getXattrs(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return a, nil
+}
+
+// Apply sets the metadata in a on the file at path, as far
+// as policy p allows. The times are set last, so that
+// nothing else we do disturbs them.
+func Apply(path string, a *pb.FileAttr, p Policy) error {
+	if a == nil || p == None {
+		return nil
+	}
+
+	if p >= Owner {
+		uid, gid := ownerIDs(a)
+		if err := os.Chown(path, uid, gid); err != nil {
+			return fmt.Errorf("attr: could not set owner of '%s': %v", path, err)
+		}
+	}
+
+	if p >= Full && len(a.Xattrs) > 0 {
+		if err := setXattrs(path, a.Xattrs); err != nil {
+			return err
+		}
+	}
+
+	mode := a.Mode & 0777
+	if p >= Owner {
+		mode = a.Mode & 07777
+	} else {
+		mode |= a.Mode & modeSticky
+	}
+	if err := os.Chmod(path, posixToMode(mode)); err != nil {
+		return fmt.Errorf("attr: could not set mode of '%s': %v", path, err)
+	}
+
+	if a.Mtime != 0 {
+		mtime := time.Unix(0, a.Mtime)
+		atime := mtime
+		if a.Atime != 0 {
+			atime = time.Unix(0, a.Atime)
+		}
+		if err := os.Chtimes(path, atime, mtime); err != nil {
+			return fmt.Errorf("attr: could not set times of '%s': %v", path, err)
+		}
+	}
+
+	return nil
+}
+
+// ownerIDs resolves the owner and group of a, preferring
+// the names, since ids need not agree between hosts.
+func ownerIDs(a *pb.FileAttr) (uid, gid int) {
+	uid, gid = int(a.Uid), int(a.Gid)
+
+	if a.User != "" {
+		if u, err := user.Lookup(a.User); err == nil {
+			if id, err := strconv.Atoi(u.Uid); err == nil {
+				uid = id
+			}
+		}
+	}
+
+	if a.Group != "" {
+		if g, err := user.LookupGroup(a.Group); err == nil {
+			if id, err := strconv.Atoi(g.Gid); err == nil {
+				gid = id
+			}
+		}
+	}
+
+	return uid, gid
+}
+
+func modeToPosix(m os.FileMode) uint32 {
+	mode := uint32(m.Perm())
+	if m&os.ModeSetuid != 0 {
+		mode |= modeSetuid
+	}
+	if m&os.ModeSetgid != 0 {
+		mode |= modeSetgid
+	}
+	if m&os.ModeSticky != 0 {
+		mode |= modeSticky
+	}
+
+	return mode
+}
+
+func posixToMode(mode uint32) os.FileMode {
+	m := os.FileMode(mode & 0777)
+	if mode&modeSetuid != 0 {
+		m |= os.ModeSetuid
+	}
+	if mode&modeSetgid != 0 {
+		m |= os.ModeSetgid
+	}
+	if mode&modeSticky != 0 {
+		m |= os.ModeSticky
+	}
+
+	return m
+}
@@ -0,0 +1,74 @@
+//go:build linux
+
+package attr
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+)
+
+func fillFromSys(a *pb.FileAttr, fi os.FileInfo) {
+	st, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return
+	}
+
+	a.Uid = st.Uid
+	a.Gid = st.Gid
+	a.Atime = st.Atim.Nano()
+}
+
+func getXattrs(path string) (map[string][]byte, error) {
+	sz, err := unix.Listxattr(path, nil)
+	if err != nil {
+		if err == unix.ENOTSUP {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("attr: listing xattrs of '%s': %v", path, err)
+	}
+	if sz == 0 {
+		return nil, nil
+	}
+
+	buf := make([]byte, sz)
+	sz, err = unix.Listxattr(path, buf)
+	if err != nil {
+		return nil, fmt.Errorf("attr: listing xattrs of '%s': %v", path, err)
+	}
+
+	xattrs := make(map[string][]byte)
+	for _, name := range bytes.Split(buf[:sz], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+
+		vsz, err := unix.Getxattr(path, string(name), nil)
+		if err != nil {
+			return nil, fmt.Errorf("attr: reading xattr '%s' of '%s': %v", name, path, err)
+		}
+		val := make([]byte, vsz)
+		vsz, err = unix.Getxattr(path, string(name), val)
+		if err != nil {
+			return nil, fmt.Errorf("attr: reading xattr '%s' of '%s': %v", name, path, err)
+		}
+		xattrs[string(name)] = val[:vsz]
+	}
+
+	return xattrs, nil
+}
+
+func setXattrs(path string, xattrs map[string][]byte) error {
+	for name, val := range xattrs {
+		if err := unix.Setxattr(path, name, val, 0); err != nil {
+			return fmt.Errorf("attr: setting xattr '%s' on '%s': %v", name, path, err)
+		}
+	}
+
+	return nil
+}
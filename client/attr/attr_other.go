@@ -0,0 +1,20 @@
+//go:build !linux
+
+package attr
+
+import (
+	"fmt"
+	"os"
+
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+)
+
+func fillFromSys(a *pb.FileAttr, fi os.FileInfo) {}
+
+func getXattrs(path string) (map[string][]byte, error) {
+	return nil, nil
+}
+
+func setXattrs(path string, xattrs map[string][]byte) error {
+	return fmt.Errorf("attr: extended attributes are not supported on this platform; cannot set them on '%s'", path)
+}
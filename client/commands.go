@@ -0,0 +1,307 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/devops-filetransfer/blake2b"
+
+	"github.com/devops-filetransfer/filetransfer/client/attr"
+	"github.com/devops-filetransfer/filetransfer/client/config"
+	"github.com/devops-filetransfer/filetransfer/client/e2e"
+	_grpc "github.com/devops-filetransfer/filetransfer/client/grpc"
+	"github.com/devops-filetransfer/filetransfer/client/manifest"
+	"github.com/devops-filetransfer/filetransfer/client/pki"
+	"github.com/devops-filetransfer/filetransfer/client/sshkey"
+)
+
+// runCommand carries out one of the client commands given after the flags:
+//
+//	put <local> [remote]   send a local file, with its metadata; end-to-end
+//	                       encrypted with -encrypt_to or -passphrase_file, and
+//	                       with a signed manifest with -sign_key
+//	get <remote> [local]   fetch a stored file, applying its metadata per -attr_policy,
+//	                       and decrypting it if it was end-to-end encrypted; its
+//	                       signed manifest, if any, is saved as <local>.sig
+//	rm <remote>            delete a stored file
+//
+// and, for administrators, the SSH key commands:
+//
+//	keys [user]                     list the keys SSH logins may use
+//	key-add <user> <key.pub>        let user log in with a public key
+//	key-revoke <user> <fingerprint> stop user logging in with a key
+func runCommand(conn *grpc.ClientConn, cfg *config.ClientConfig, args []string, myID string) error {
+	c := _grpc.NewClient(conn, cfg.MaxMsgSize)
+	c.EncryptTo(cfg.Recipients)
+	c.DecryptWith(cfg.Identities)
+	c.SignWith(cfg.SignKey)
+	c.RetryWith(retryPolicy(cfg))
+
+	switch args[0] {
+	case "put":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: %s put <local> [remote]", ProgramName)
+		}
+		local := args[1]
+		remote := filepath.ToSlash(filepath.Base(local))
+		if len(args) == 3 {
+			remote = args[2]
+		}
+		return c.RunPutFile(local, remote, &_grpc.PutOptions{
+			WithXattrs: cfg.WithXattrs,
+			Sparse:     cfg.Sparse,
+			ChunkSize:  cfg.ChunkSize,
+			Progress:   tenthsProgress(),
+		}, myID)
+
+	case "get":
+		if len(args) < 2 || len(args) > 3 {
+			return fmt.Errorf("usage: %s get <remote> [local]", ProgramName)
+		}
+		remote := args[1]
+		local := filepath.Base(filepath.FromSlash(remote))
+		if len(args) == 3 {
+			local = args[2]
+		}
+		policy, err := attr.ParsePolicy(cfg.AttrPolicy)
+		if err != nil {
+			return err
+		}
+		return c.RunGetFile(remote, local, policy, myID)
+
+	case "rm":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: %s rm <remote>", ProgramName)
+		}
+		return c.RunDeleteFile(args[1], myID)
+
+	case "keys":
+		if len(args) > 2 {
+			return fmt.Errorf("usage: %s keys [user]", ProgramName)
+		}
+		var user string
+		if len(args) == 2 {
+			user = args[1]
+		}
+		keys, err := c.RunListSSHKeys(user)
+		if err != nil {
+			return err
+		}
+		for _, k := range keys {
+			added := time.Unix(0, k.Added).UTC().Format(time.RFC3339)
+			fmt.Printf("%-16s %-12s %s  %s  %s\n", k.User, k.Type, k.Fingerprint, added, k.Comment)
+		}
+		return nil
+
+	case "key-add":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: %s key-add <user> <key.pub>", ProgramName)
+		}
+		line, err := os.ReadFile(args[2])
+		if err != nil {
+			return err
+		}
+		_, err = c.RunAddSSHKey(args[1], line, myID)
+		return err
+
+	case "key-revoke":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: %s key-revoke <user> <fingerprint>", ProgramName)
+		}
+		return c.RunRevokeSSHKey(args[1], args[2], myID)
+	}
+
+	return fmt.Errorf("unknown command '%s'; expected put, get, rm, keys, key-add or key-revoke", args[0])
+}
+
+// retryPolicy is how cfg says to retry transfers.
+func retryPolicy(cfg *config.ClientConfig) _grpc.RetryPolicy {
+	return _grpc.RetryPolicy{
+		Attempts:       cfg.Retries + 1,
+		InitialBackoff: cfg.RetryBackoff,
+		MaxBackoff:     cfg.RetryMaxBackoff,
+		Budget:         cfg.RetryBudget,
+		Deadline:       cfg.Deadline,
+	}
+}
+
+// runCertCommand carries out the certificate commands, which
+// need no server and are given in place of the usual flags:
+//
+//	cert request [-dir <dir>] [-name <name>] [-email <address>]
+func runCertCommand(args []string) error {
+	if len(args) < 1 || args[0] != "request" {
+		return fmt.Errorf("usage: %s cert request [-dir <dir>] [-name <name>] [-email <address>]", ProgramName)
+	}
+
+	fs := flag.NewFlagSet("cert request", flag.ContinueOnError)
+	dir := fs.String("dir", pki.DefaultDir(), "directory to keep our key and certificates in")
+	name := fs.String("name", os.Getenv("USER"), "who to ask to be known as; the certificate's common name")
+	email := fs.String("email", "", "email address to put in the certificate; it then names us in preference to -name")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+
+	csr, err := pki.Request(*dir, *name, *email)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote key %s and request %s\n", filepath.Join(*dir, pki.ClientKeyFile), csr)
+	fmt.Printf("on the server: 'server cert sign -csr %s'; then copy the signed %s,\n", pki.ClientCSRFile, pki.ClientCertFile)
+	fmt.Printf("along with the server's %s, into %s\n", pki.CAFile, *dir)
+
+	return nil
+}
+
+// runSSHCommand carries out the SSH key commands, which need no
+// server and are given in place of the usual flags:
+//
+//	ssh keygen [-out <file>] [-comment <comment>]
+func runSSHCommand(args []string) error {
+	if len(args) < 1 || args[0] != "keygen" {
+		return fmt.Errorf("usage: %s ssh keygen [-out <file>] [-comment <comment>]", ProgramName)
+	}
+
+	host, _ := os.Hostname()
+	fs := flag.NewFlagSet("ssh keygen", flag.ContinueOnError)
+	out := fs.String("out", sshkey.DefaultPath(), "file to write the new private key to; the public key goes beside it")
+	comment := fs.String("comment", os.Getenv("USER")+"@"+host, "comment to put on the public key")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	line, err := sshkey.Generate(*out, *comment)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote private key %s and public key %s%s:\n%s", *out, *out, sshkey.PubSuffix, line)
+	login := os.Getenv("USER")
+	if login == "" {
+		login = "<login>"
+	}
+	pub := filepath.Base(*out) + sshkey.PubSuffix
+	fmt.Printf("keep the private key here; give an administrator %s, to run\n", pub)
+	fmt.Printf("'server ssh key add -user %s %s', or '%s key-add %s %s'\n", login, pub, ProgramName, login, pub)
+
+	return nil
+}
+
+// runE2ECommand carries out the end-to-end encryption key
+// commands, which need no server and are given in place of the
+// usual flags:
+//
+//	e2e keygen [-out <file>]
+func runE2ECommand(args []string) error {
+	if len(args) < 1 || args[0] != "keygen" {
+		return fmt.Errorf("usage: %s e2e keygen [-out <file>]", ProgramName)
+	}
+
+	fs := flag.NewFlagSet("e2e keygen", flag.ContinueOnError)
+	out := fs.String("out", e2e.DefaultIdentityPath(), "file to write our new identity to; the public key goes beside it")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	id, err := e2e.Generate(*out)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote identity %s and public key %s%s:\n%s\n", *out, *out, e2e.PubSuffix, id.Recipient())
+	fmt.Printf("keep the identity secret; anyone may encrypt files to you with -encrypt_to and the public key\n")
+
+	return nil
+}
+
+// runConfigCommand checks, or prints, the configuration we would
+// run with, given the same flags:
+//
+//	config validate [flags]
+//	config print [flags]
+func runConfigCommand(args []string) error {
+	if len(args) < 1 || (args[0] != "validate" && args[0] != "print") {
+		return fmt.Errorf("usage: %s config validate|print [flags]", ProgramName)
+	}
+
+	cfg, _, eff, err := loadConfig(args[1:])
+	if err != nil {
+		return err
+	}
+	if args[0] == "print" {
+		return eff.Print(os.Stdout)
+	}
+
+	if err := cfg.ValidateConfig(); err != nil {
+		return err
+	}
+	fmt.Printf("valid, from %s, the environment and the flags given\n", eff.Source())
+
+	return nil
+}
+
+// runVerifyCommand checks a file against its signed manifest, as
+// saved by get, without any server:
+//
+//	verify -trusted <file> <file> [<signature>]
+func runVerifyCommand(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	trusted := fs.String("trusted", "", "authorized_keys file of the Ed25519 keys whose signatures we accept")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *trusted == "" || fs.NArg() < 1 || fs.NArg() > 2 {
+		return fmt.Errorf("usage: %s verify -trusted <keys> <file> [<signature>]", ProgramName)
+	}
+
+	path := fs.Arg(0)
+	sigPath := path + manifest.SigSuffix
+	if fs.NArg() == 2 {
+		sigPath = fs.Arg(1)
+	}
+
+	tr, err := manifest.LoadTrusted(*trusted)
+	if err != nil {
+		return err
+	}
+	signed, err := os.ReadFile(sigPath)
+	if err != nil {
+		return err
+	}
+	m, err := tr.Verify(signed)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h, err := blake2b.New(nil)
+	if err != nil {
+		return err
+	}
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return err
+	}
+	if err := m.Check(size, h.Sum(nil)); err != nil {
+		return err
+	}
+
+	fmt.Printf("'%s' is '%s' as signed by %s (%s) at %v\n", path, m.Path, m.Signer, m.Fingerprint, m.Time.Format(time.RFC3339))
+
+	return nil
+}
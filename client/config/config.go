@@ -1,17 +1,34 @@
 package config
 
 import (
+	"context"
+	"crypto/ed25519"
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/devops-filetransfer/filetransfer/client/attr"
+	"github.com/devops-filetransfer/filetransfer/client/e2e"
 	"github.com/devops-filetransfer/filetransfer/client/exists"
+	"github.com/devops-filetransfer/filetransfer/client/hostkey"
+	"github.com/devops-filetransfer/filetransfer/client/logging"
+	"github.com/devops-filetransfer/filetransfer/client/manifest"
+	"github.com/devops-filetransfer/filetransfer/client/metrics"
+	"github.com/devops-filetransfer/filetransfer/client/pki"
 	"github.com/devops-filetransfer/filetransfer/client/ssh"
+	"github.com/devops-filetransfer/filetransfer/client/sshkey"
+	"github.com/devops-filetransfer/filetransfer/client/tracing"
 )
 
 type ClientConfig struct {
@@ -20,69 +37,312 @@ type ClientConfig struct {
 	// For when your VPN already provides encryption.
 	SkipEncryption bool // turn off both SSH and TLS.
 
-	AllowNewServer          bool // only give once to prevent MITM.
-	TestAllowOneshotConnect bool
-	CertPath                string
-	KeyPath                 string
-	ServerHost              string // ip address
-	ServerPort              int
-	ServerInternalHost      string // ip address
-	ServerInternalPort      int
-	ServerHostOverride      string
+	CertPath           string
+	KeyPath            string
+	ServerHost         string // ip address
+	ServerPort         int
+	ServerInternalHost string // ip address
+	ServerInternalPort int
+	ServerHostOverride string
+
+	// ClientCertPath and ClientKeyPath are the certificate we
+	// present to a server that wants mutual TLS.
+	ClientCertPath string
+	ClientKeyPath  string
+
+	// Token is a bearer token to present on every call, given
+	// directly or read from TokenPath. Tokens travel in the clear
+	// under -skip-encryption, so we refuse to send them like that
+	// unless TokenInsecure says otherwise.
+	Token         string
+	TokenPath     string
+	TokenInsecure bool
 
 	Username             string
 	PrivateKeyPath       string
 	ClientKnownHostsPath string
 
+	// HostKeyPolicy says which SSH host keys we trust: the one
+	// pinned by -host_key, or those in ClientKnownHostsPath,
+	// adding an unknown host's with -new and taking a rotated
+	// one with -rotate_host_key.
+	HostKeyPolicy hostkey.Policy
+
 	CpuProfilePath string
 
 	PayloadSizeMegaBytes int
+
+	// MaxMsgSize bounds the gRPC messages we send and receive;
+	// the chunk size actually used is also bounded by the
+	// server's limit, learned through Negotiate().
+	MaxMsgSize int
+
+	// ChunkSize is the initial chunk size; it adapts
+	// to the observed throughput from there.
+	ChunkSize int
+
+	// SessionFiles is how many small files the demo sends over one Session.
+	SessionFiles int
+
+	// WithXattrs sends extended attributes (and so ACLs) on put;
+	// AttrPolicy says which metadata to apply on get.
+	WithXattrs bool
+	AttrPolicy string
+
+	// Sparse sends the holes in sparse files as holes.
+	Sparse bool
+
+	// EncryptTo lists the public keys, or files of them, that we
+	// end-to-end encrypt the files we send to; PassphrasePath names
+	// a file holding a passphrase to encrypt them with as well.
+	// IdentityPath is our own key, for decrypting the files we get.
+	// From these, ValidateConfig sets Recipients and Identities.
+	EncryptTo      string
+	PassphrasePath string
+	IdentityPath   string
+	Recipients     []e2e.Recipient
+	Identities     []e2e.Identity
+
+	// SignKeyPath names the Ed25519 key we sign the manifests of
+	// the files we send with; ValidateConfig loads it into SignKey.
+	SignKeyPath string
+	SignKey     ed25519.PrivateKey
+
+	// MetricsAddr, when set, is where we serve our metrics, at
+	// /metrics, for Prometheus to scrape while we run.
+	MetricsAddr string
+
+	// LogLevel and LogFormat say what we log, and how.
+	LogLevel  string
+	LogFormat string
+
+	// Trace, when set, is where the spans tracing each call
+	// go, as tracing.Setup takes it.
+	Trace string
+
+	// Retries is how many times a transfer that fails in a way
+	// that may not last is tried again, waiting RetryBackoff at
+	// first, and doubling that each time, up to RetryMaxBackoff.
+	// RetryBudget, if not 0, caps the retries of all transfers
+	// together; Deadline, if not 0, bounds each transfer.
+	Retries         int
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	RetryBudget     int
+	Deadline        time.Duration
 }
 
+// DefaultMaxMsgSize is our default limit, in bytes, on gRPC
+// messages in either direction.
+const DefaultMaxMsgSize = 16 << 20
+
 func (c *ClientConfig) DefineFlags(fs *flag.FlagSet) {
-	fs.BoolVar(&c.AllowNewServer, "new", false, "allow new server host key to be recognized and stored in known-hosts")
+	fs.BoolVar(&c.HostKeyPolicy.TOFU, "new", false, "trust an SSH server we have no host key for, the first time we meet it, and record its key in known-hosts and the audit log")
+	fs.StringVar(&c.HostKeyPolicy.Pin, "host_key", "", "SHA256 fingerprint of the SSH server's host key, as the server logs it; only that key will do, whatever known-hosts says")
+	fs.StringVar(&c.HostKeyPolicy.Rotate, "rotate_host_key", "", "SHA256 fingerprint of the SSH server's new host key, to take in place of the one in known-hosts after the server's key was rotated")
 	fs.BoolVar(&c.UseTLS, "tls", false, "Use TLS for security (default is SSH)")
 	fs.BoolVar(&c.SkipEncryption, "skip-encryption", false, "Skip both TLS and SSH; for running on an already encrypted VPN.")
-	fs.StringVar(&c.CertPath, "cert_file", "testdata/server1.pem", "The TLS cert file")
-	fs.StringVar(&c.KeyPath, "key_file", "testdata/server1.key", "The TLS key file")
+	fs.StringVar(&c.CertPath, "cert_file", filepath.Join(pki.DefaultDir(), pki.CAFile), "The CA cert to check the server's TLS cert against; a copy of the server's "+pki.CAFile)
+	fs.StringVar(&c.KeyPath, "key_file", "", "unused; see -client_key_file")
+	fs.StringVar(&c.ClientCertPath, "client_cert_file", "", "our TLS client certificate, for servers that require one (mutual TLS); default: "+pki.ClientCertFile+" from 'client cert request', if signed")
+	fs.StringVar(&c.ClientKeyPath, "client_key_file", "", "the key for -client_cert_file")
 	fs.StringVar(&c.ServerHost, "host", "127.0.0.1", "host IP address or name to connect to")
 	fs.IntVar(&c.ServerPort, "port", 10000, "The exteral server port")
 	fs.StringVar(&c.ServerInternalHost, "ihost", "127.0.0.1", "internal host IP address or name to connect to")
 	fs.IntVar(&c.ServerInternalPort, "iport", 10001, "The internal server port")
-	fs.StringVar(&c.ServerHostOverride, "server_host_override", "x.test.youtube.com", "The server name use to verify the hostname returned by TLS handshake")
+	fs.StringVar(&c.ServerHostOverride, "server_host_override", "", "The server name use to verify the hostname returned by TLS handshake (default: -host)")
+
+	fs.StringVar(&c.Token, "token", "", "bearer token to authenticate with")
+	fs.StringVar(&c.TokenPath, "token_file", "", "file holding the bearer token to authenticate with")
+	fs.BoolVar(&c.TokenInsecure, "token_insecure", false, "send the bearer token even without TLS or SSH, where anyone watching can steal it")
 
 	user := os.Getenv("USER")
 	fs.StringVar(&c.Username, "user", user, "username for sshd login (default is $USER)")
 
-	home := os.Getenv("HOME")
-	fs.StringVar(&c.PrivateKeyPath, "key", home+"/.ssh/.sshego.sshd.db/users/"+user+"/id_rsa", "private key for sshd login")
-	fs.StringVar(&c.ClientKnownHostsPath, "known-hosts", home+"/.ssh/.sshego.cli.known.hosts", "path to our own known-hosts file, for sshd login")
+	fs.StringVar(&c.PrivateKeyPath, "key", sshkey.DefaultPath(), "private key for sshd login, as made by 'client ssh keygen'; Ed25519 or RSA")
+	fs.StringVar(&c.ClientKnownHostsPath, "known-hosts", filepath.Join(sshkey.DefaultDir(), hostkey.KnownHostsFile), "path to our own known-hosts file, for sshd login, in OpenSSH's format")
 
 	fs.StringVar(&c.CpuProfilePath, "cpuprofile", "", "write cpu profile to file")
 
 	fs.IntVar(&c.PayloadSizeMegaBytes, "payload", 128, "transfer payload size in MB (megabytes)")
+
+	fs.IntVar(&c.MaxMsgSize, "max_msg_size", DefaultMaxMsgSize, "max gRPC message size in bytes, for both send and receive")
+	fs.IntVar(&c.ChunkSize, "chunk", 1<<20, "initial chunk size in bytes; adapts to throughput from there")
+	fs.IntVar(&c.SessionFiles, "session", 100, "number of small files to send over a single Session stream")
+
+	fs.BoolVar(&c.WithXattrs, "xattrs", false, "on put, also send extended attributes and ACLs")
+	fs.StringVar(&c.AttrPolicy, "attr_policy", "mode", "on get, file metadata to apply: none, mode, owner or full")
+	fs.BoolVar(&c.Sparse, "sparse", true, "on put, detect holes in sparse files and send them as holes rather than zeros")
+
+	fs.StringVar(&c.EncryptTo, "encrypt_to", "", "comma separated public keys, or files of them such as "+e2e.IdentityFile+e2e.PubSuffix+", to end-to-end encrypt the files we send to")
+	fs.StringVar(&c.PassphrasePath, "passphrase_file", "", "file whose first line is a passphrase to end-to-end encrypt the files we send with, and to decrypt the files we get")
+	fs.StringVar(&c.SignKeyPath, "sign_key", "", "Ed25519 private key, such as one from 'client ssh keygen', to sign a manifest of each file we send with")
+	fs.StringVar(&c.MetricsAddr, "metrics_addr", "", "host:port to serve Prometheus metrics on, at /metrics, while we run (default: none)")
+	fs.StringVar(&c.LogLevel, "log_level", "info", "least severe level to log: debug, info, warn or error")
+	fs.StringVar(&c.LogFormat, "log_format", logging.Text, "log as "+logging.Text+" or "+logging.JSON)
+	fs.StringVar(&c.Trace, "trace", "", "send OpenTelemetry spans to stdout, file:<path> or otlp[:<host:port>] (default: none)")
+	fs.IntVar(&c.Retries, "retries", 5, "times to retry a transfer cut short by the connection, or failing its checksums; 0 to not retry")
+	fs.DurationVar(&c.RetryBackoff, "retry_backoff", 500*time.Millisecond, "wait before the first retry; doubled for each after, with jitter")
+	fs.DurationVar(&c.RetryMaxBackoff, "retry_max_backoff", 30*time.Second, "longest wait between retries")
+	fs.IntVar(&c.RetryBudget, "retry_budget", 0, "most retries of all transfers together (default: no cap)")
+	fs.DurationVar(&c.Deadline, "deadline", 0, "give up on a transfer taking longer than this, retries included (default: none)")
+	fs.StringVar(&c.IdentityPath, "identity", "", "our key from 'client e2e keygen', to decrypt end-to-end encrypted files with (default: "+e2e.DefaultIdentityPath()+", if there)")
 }
 
 func (c *ClientConfig) ValidateConfig() error {
-	if c.UseTLS {
-		if c.KeyPath == "" {
-			return fmt.Errorf("must provide -key_file under TLS")
-		}
-		if !exists.FileExists(c.KeyPath) {
-			return fmt.Errorf("-key_path '%s' does not exist", c.KeyPath)
+	if c.MaxMsgSize <= 0 {
+		return fmt.Errorf("-max_msg_size must be positive")
+	}
+
+	if _, err := attr.ParsePolicy(c.AttrPolicy); err != nil {
+		return err
+	}
+
+	if _, err := logging.New(io.Discard, c.LogLevel, c.LogFormat); err != nil {
+		return err
+	}
+
+	if err := tracing.Check(c.Trace); err != nil {
+		return err
+	}
+
+	if c.Retries < 0 || c.RetryBudget < 0 || c.Deadline < 0 {
+		return fmt.Errorf("-retries, -retry_budget and -deadline cannot be negative")
+	}
+	if c.Retries > 0 && (c.RetryBackoff <= 0 || c.RetryMaxBackoff < c.RetryBackoff) {
+		return fmt.Errorf("-retry_backoff must be positive, and no more than -retry_max_backoff")
+	}
+
+	if c.Token != "" && c.TokenPath != "" {
+		return fmt.Errorf("give only one of -token and -token_file")
+	}
+	if c.TokenPath != "" {
+		tok, err := os.ReadFile(c.TokenPath)
+		if err != nil {
+			return err
 		}
+		c.Token = strings.TrimSpace(string(tok))
+	}
+	if c.Token != "" && !c.UseTLS && c.SkipEncryption && !c.TokenInsecure {
+		return fmt.Errorf("refusing to send a bearer token without encryption; use -tls, or give -token_insecure if you really mean it")
+	}
 
+	if c.UseTLS {
 		if c.CertPath == "" {
-			return fmt.Errorf("must provide -key_file under TLS")
+			return fmt.Errorf("must provide -cert_file under TLS")
 		}
 		if !exists.FileExists(c.CertPath) {
-			return fmt.Errorf("-cert_path '%s' does not exist", c.CertPath)
+			return fmt.Errorf("-cert_path '%s' does not exist; copy the server's %s there", c.CertPath, pki.CAFile)
+		}
+
+		if c.ClientCertPath == "" && c.ClientKeyPath == "" {
+			// use the certificate 'client cert request' asked for, once signed.
+			dir := pki.DefaultDir()
+			cert, key := filepath.Join(dir, pki.ClientCertFile), filepath.Join(dir, pki.ClientKeyFile)
+			if exists.FileExists(cert) && exists.FileExists(key) {
+				c.ClientCertPath, c.ClientKeyPath = cert, key
+			}
+		}
+
+		if (c.ClientCertPath == "") != (c.ClientKeyPath == "") {
+			return fmt.Errorf("-client_cert_file and -client_key_file go together")
+		}
+		if c.ClientCertPath != "" && !exists.FileExists(c.ClientCertPath) {
+			return fmt.Errorf("-client_cert_file '%s' does not exist", c.ClientCertPath)
+		}
+		if c.ClientKeyPath != "" && !exists.FileExists(c.ClientKeyPath) {
+			return fmt.Errorf("-client_key_file '%s' does not exist", c.ClientKeyPath)
+		}
+	}
+
+	for _, fp := range []string{c.HostKeyPolicy.Pin, c.HostKeyPolicy.Rotate} {
+		if fp != "" && !strings.HasPrefix(fp, "SHA256:") {
+			return fmt.Errorf("host key fingerprint '%s' should look like 'SHA256:...'", fp)
+		}
+	}
+	if c.HostKeyPolicy.Pin != "" && (c.HostKeyPolicy.TOFU || c.HostKeyPolicy.Rotate != "") {
+		return fmt.Errorf("-host_key pins the one key to trust; it does not go with -new or -rotate_host_key")
+	}
+	c.HostKeyPolicy.KnownHostsPath = c.ClientKnownHostsPath
+
+	if c.SignKeyPath != "" {
+		key, err := manifest.LoadSigner(c.SignKeyPath)
+		if err != nil {
+			return fmt.Errorf("-sign_key: %v", err)
+		}
+		c.SignKey = key
+	}
+
+	return c.loadE2E()
+}
+
+// loadE2E sets Recipients and Identities from the e2e flags.
+func (c *ClientConfig) loadE2E() error {
+	rs, err := e2e.ParseRecipients(c.EncryptTo)
+	if err != nil {
+		return err
+	}
+	c.Recipients = rs
+
+	if c.PassphrasePath != "" {
+		p, err := e2e.LoadPassphrase(c.PassphrasePath)
+		if err != nil {
+			return err
+		}
+		c.Recipients = append(c.Recipients, p)
+		c.Identities = append(c.Identities, p)
+	}
+
+	path := c.IdentityPath
+	if path == "" && exists.FileExists(e2e.DefaultIdentityPath()) {
+		path = e2e.DefaultIdentityPath()
+	}
+	if path != "" {
+		id, err := e2e.LoadIdentity(path)
+		if err != nil {
+			return err
 		}
+		c.Identities = append(c.Identities, id)
 	}
 
 	return nil
 }
 
+// SetupMetrics adds the dial options that count our calls, and
+// serves the counts if we have somewhere to serve them.
+func (c *ClientConfig) SetupMetrics(opts *[]grpc.DialOption) {
+	*opts = append(*opts,
+		grpc.WithChainUnaryInterceptor(metrics.UnaryInterceptor()),
+		grpc.WithChainStreamInterceptor(metrics.StreamInterceptor()),
+	)
+
+	if c.MetricsAddr == "" {
+		return
+	}
+	addr, err := metrics.Serve(c.MetricsAddr)
+	if err != nil {
+		log.Fatalf("Failed to serve metrics %v", err)
+	}
+	log.Printf("serving Prometheus metrics at http://%v/metrics", addr)
+}
+
+// SetupTracing adds the dial options that trace our calls, and send
+// the trace along to the server, if we are tracing.
+func (c *ClientConfig) SetupTracing(opts *[]grpc.DialOption) {
+	if c.Trace == "" {
+		return
+	}
+	*opts = append(*opts, tracing.DialOptions()...)
+}
+
+// SetupMsgSize adds the dial options that enforce our message size limits.
+func (c *ClientConfig) SetupMsgSize(opts *[]grpc.DialOption) {
+	*opts = append(*opts, grpc.WithDefaultCallOptions(
+		grpc.MaxCallRecvMsgSize(c.MaxMsgSize),
+		grpc.MaxCallSendMsgSize(c.MaxMsgSize),
+	))
+}
+
 func (c *ClientConfig) SetupTLS(opts *[]grpc.DialOption) {
 	var sn string
 
@@ -92,7 +352,13 @@ func (c *ClientConfig) SetupTLS(opts *[]grpc.DialOption) {
 
 	var creds credentials.TransportCredentials
 
-	if c.CertPath != "" {
+	if c.ClientCertPath != "" {
+		var err error
+		creds, err = c.mutualTLS(sn)
+		if err != nil {
+			log.Fatalf("Failed to create mutual TLS credentials %v", err)
+		}
+	} else if c.CertPath != "" {
 		var err error
 		creds, err = credentials.NewClientTLSFromFile(c.CertPath, sn)
 		if err != nil {
@@ -102,13 +368,68 @@ func (c *ClientConfig) SetupTLS(opts *[]grpc.DialOption) {
 		creds = credentials.NewClientTLSFromCert(nil, sn)
 	}
 
-	*opts = append(*opts, grpc.WithTransportCredentials(creds))
+	*opts = append(*opts, grpc.WithTransportCredentials(metrics.Handshakes(creds, metrics.TLS)))
+}
+
+// mutualTLS returns credentials that verify the server against
+// CertPath, as for plain TLS, and also present our own certificate.
+func (c *ClientConfig) mutualTLS(serverName string) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(c.ClientCertPath, c.ClientKeyPath)
+	if err != nil {
+		return nil, err
+	}
+
+	tc := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if c.CertPath != "" {
+		pem, err := os.ReadFile(c.CertPath)
+		if err != nil {
+			return nil, err
+		}
+		tc.RootCAs = x509.NewCertPool()
+		if !tc.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in -cert_file '%s'", c.CertPath)
+		}
+	}
+
+	return credentials.NewTLS(tc), nil
+}
+
+// SetupToken adds the dial option that presents our bearer
+// token, if we have one.
+func (c *ClientConfig) SetupToken(opts *[]grpc.DialOption) {
+	if c.Token == "" {
+		return
+	}
+
+	// gRPC only knows to trust TLS; the SSH tunnel is invisible to it.
+	insecure := !c.UseTLS
+
+	*opts = append(*opts, grpc.WithPerRPCCredentials(&bearer{token: c.Token, insecure: insecure}))
+}
+
+// bearer presents a token in the "authorization" metadata.
+type bearer struct {
+	token    string
+	insecure bool
+}
+
+func (b *bearer) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + b.token}, nil
+}
+
+func (b *bearer) RequireTransportSecurity() bool {
+	return !b.insecure
 }
 
 func (c *ClientConfig) SetupSSH(opts *[]grpc.DialOption) {
 	destAddr := fmt.Sprintf("%v:%v", c.ServerInternalHost, c.ServerInternalPort)
 
-	dialer, err := ssh.ClientSshMain(c.AllowNewServer, c.TestAllowOneshotConnect, c.PrivateKeyPath, c.ClientKnownHostsPath, c.Username, c.ServerHost, destAddr, int64(c.ServerPort))
+	dialer, err := ssh.ClientSshMain(&c.HostKeyPolicy, c.PrivateKeyPath, c.Username, c.ServerHost, destAddr, int64(c.ServerPort))
 	if err != nil {
 		log.Fatalf("Failed to invoke clientSshMain %v", err)
 	}
@@ -0,0 +1,449 @@
+// Package e2e encrypts files on the client before they are sent,
+// so the server only ever holds, and checksums, ciphertext.
+//
+// The format follows age (age-encryption.org/v1). A random file key
+// is wrapped once per recipient, into a text header:
+//
+//	filetransfer-e2e/v1
+//	-> X25519 <ephemeral share>
+//	<wrapped file key>
+//	-> scrypt <salt> <log2 N>
+//	<wrapped file key>
+//	--- <HMAC-SHA256 of the header so far>
+//
+// After it come the sealed info block, which holds the size and
+// Blake2b checksum of the plain file, then a 16 byte nonce and the
+// payload: the plain file, sealed with ChaCha20-Poly1305 in chunks
+// of ChunkSize bytes, each chunk's nonce its number and whether it
+// is the last, so chunks can be neither reordered nor dropped.
+package e2e
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+
+	"github.com/devops-filetransfer/blake2b"
+)
+
+// Magic is the first line of every encrypted file.
+const Magic = "filetransfer-e2e/v1\n"
+
+// ChunkSize is the most plain bytes one payload chunk holds.
+const ChunkSize = 64 << 10
+
+// maxHeader bounds the header we will buffer, looking for its end.
+const maxHeader = 64 << 10
+
+const (
+	fileKeySize = 16
+	nonceSize   = 16
+	overhead    = chacha20poly1305.Overhead
+	sumSize     = 64
+	infoSize    = 8 + sumSize + overhead
+)
+
+// IsEncrypted says whether a file starting with prefix is one of ours.
+func IsEncrypted(prefix []byte) bool {
+	return bytes.HasPrefix(prefix, []byte(Magic))
+}
+
+// SealedSize is the size of a file of size plain bytes once
+// encrypted with header.
+func SealedSize(header int, plain int64) int64 {
+	chunks := (plain + ChunkSize - 1) / ChunkSize
+	if chunks == 0 {
+		chunks = 1
+	}
+
+	return int64(header) + infoSize + nonceSize + plain + chunks*overhead
+}
+
+func derive(fileKey, salt []byte, label string, n int) []byte {
+	key := make([]byte, n)
+	_, _ = io.ReadFull(hkdf.New(sha256.New, fileKey, salt, []byte(label)), key)
+
+	return key
+}
+
+// headerMAC is the MAC over the header up to and including "---".
+func headerMAC(fileKey, header []byte) []byte {
+	h := hmac.New(sha256.New, derive(fileKey, nil, "header", 32))
+	h.Write(header)
+
+	return h.Sum(nil)
+}
+
+// chunkNonce is the nonce of payload chunk i.
+func chunkNonce(i uint64, last bool) []byte {
+	nonce := make([]byte, chacha20poly1305.NonceSize)
+	binary.BigEndian.PutUint64(nonce[3:11], i)
+	if last {
+		nonce[11] = 1
+	}
+
+	return nonce
+}
+
+var b64 = base64.RawStdEncoding
+
+// Encrypter reads a plain file from src and gives it out encrypted.
+type Encrypter struct {
+	src     io.Reader
+	payload cipher.AEAD
+	hasher  hash.Hash
+	sum     []byte
+
+	pending   []byte
+	remaining int64
+	chunk     uint64
+	done      bool
+	size      int64
+}
+
+// NewEncrypter encrypts the size bytes of src, whose Blake2b
+// checksum is sum, to recipients. The checksum goes in the sealed
+// info block, for the reader to check against once decrypted; if
+// src does not match it, Read fails at the end.
+func NewEncrypter(src io.Reader, size int64, sum []byte, recipients []Recipient) (*Encrypter, error) {
+	if len(recipients) == 0 {
+		return nil, fmt.Errorf("e2e: no recipients")
+	}
+	if len(sum) != sumSize {
+		return nil, fmt.Errorf("e2e: checksum is %v bytes, want %v", len(sum), sumSize)
+	}
+
+	fileKey := make([]byte, fileKeySize)
+	if _, err := rand.Read(fileKey); err != nil {
+		return nil, err
+	}
+
+	var hdr bytes.Buffer
+	hdr.WriteString(Magic)
+	for _, r := range recipients {
+		s, err := r.Wrap(fileKey)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&hdr, "-> %s\n%s\n", strings.Join(append([]string{s.Type}, s.Args...), " "), b64.EncodeToString(s.Body))
+	}
+	hdr.WriteString("---")
+	fmt.Fprintf(&hdr, " %s\n", b64.EncodeToString(headerMAC(fileKey, hdr.Bytes())))
+	headerLen := hdr.Len()
+
+	info, err := aeadSeal(derive(fileKey, nil, "info", chacha20poly1305.KeySize), append(binary.BigEndian.AppendUint64(nil, uint64(size)), sum...))
+	if err != nil {
+		return nil, err
+	}
+	hdr.Write(info)
+
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	hdr.Write(nonce)
+
+	payload, err := chacha20poly1305.New(derive(fileKey, nonce, "payload", chacha20poly1305.KeySize))
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := blake2b.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Encrypter{
+		src:       src,
+		payload:   payload,
+		hasher:    h,
+		sum:       sum,
+		pending:   hdr.Bytes(),
+		remaining: size,
+		size:      SealedSize(headerLen, size),
+	}, nil
+}
+
+// Size is the size of the encrypted file.
+func (e *Encrypter) Size() int64 {
+	return e.size
+}
+
+// Read implements io.Reader.
+func (e *Encrypter) Read(p []byte) (int, error) {
+	for len(e.pending) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		if err := e.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, e.pending)
+	e.pending = e.pending[n:]
+
+	return n, nil
+}
+
+// next seals the next chunk of the payload.
+func (e *Encrypter) next() error {
+	n := e.remaining
+	if n > ChunkSize {
+		n = ChunkSize
+	}
+
+	plain := make([]byte, n, n+overhead)
+	if _, err := io.ReadFull(e.src, plain); err != nil {
+		return fmt.Errorf("e2e: reading the plain file: %v", err)
+	}
+	e.hasher.Write(plain)
+	e.remaining -= n
+
+	last := e.remaining == 0
+	if last {
+		if !bytes.Equal(e.hasher.Sum(nil), e.sum) {
+			return fmt.Errorf("e2e: the plain file changed while it was being encrypted")
+		}
+		e.done = true
+	}
+
+	e.pending = e.payload.Seal(plain[:0], chunkNonce(e.chunk, last), plain, nil)
+	e.chunk++
+
+	return nil
+}
+
+// Decrypter takes an encrypted file, as it is written to it, and
+// writes the plain file to dst. Nothing reaches dst before it has
+// been authenticated; Close checks that the whole file was there,
+// and that it matches the size and checksum it was sealed with.
+type Decrypter struct {
+	dst        io.Writer
+	identities []Identity
+
+	buf     []byte
+	payload cipher.AEAD
+	chunk   uint64
+	hasher  hash.Hash
+
+	// from the info block.
+	wantSize int64
+	wantSum  []byte
+
+	size int64
+}
+
+// NewDecrypter returns a Decrypter writing to dst, opening the file
+// with whichever of identities it was encrypted to.
+func NewDecrypter(dst io.Writer, identities []Identity) *Decrypter {
+	return &Decrypter{dst: dst, identities: identities}
+}
+
+// Write implements io.Writer.
+func (d *Decrypter) Write(p []byte) (int, error) {
+	d.buf = append(d.buf, p...)
+
+	if d.payload == nil {
+		ok, err := d.header()
+		if err != nil || !ok {
+			return len(p), err
+		}
+	}
+
+	// a full chunk is only known not to be the last once
+	// more follows it.
+	for len(d.buf) > ChunkSize+overhead {
+		if err := d.open(d.buf[:ChunkSize+overhead], false); err != nil {
+			return len(p), err
+		}
+		d.buf = d.buf[ChunkSize+overhead:]
+	}
+
+	return len(p), nil
+}
+
+// Close opens the last chunk, and checks the plain file against
+// the info block. It does not close dst.
+func (d *Decrypter) Close() error {
+	if d.payload == nil {
+		return fmt.Errorf("e2e: file ends in its header")
+	}
+	if len(d.buf) < overhead {
+		return fmt.Errorf("e2e: file was cut short after %v plain bytes", d.size)
+	}
+	if err := d.open(d.buf, true); err != nil {
+		return err
+	}
+	d.buf = nil
+
+	if d.size != d.wantSize {
+		return fmt.Errorf("e2e: plain file is %v bytes, but was sealed as %v", d.size, d.wantSize)
+	}
+	if got := d.hasher.Sum(nil); !bytes.Equal(got, d.wantSum) {
+		return fmt.Errorf("e2e: plain file checksum '%x' differs from the '%x' it was sealed with", got, d.wantSum)
+	}
+
+	return nil
+}
+
+// Size is the number of plain bytes written so far.
+func (d *Decrypter) Size() int64 {
+	return d.size
+}
+
+// Sum is the Blake2b checksum of the plain file, from the sealed
+// info block; it is only set once the header has been read.
+func (d *Decrypter) Sum() []byte {
+	return d.wantSum
+}
+
+func (d *Decrypter) open(sealed []byte, last bool) error {
+	plain, err := d.payload.Open(nil, chunkNonce(d.chunk, last), sealed, nil)
+	if err != nil {
+		if last {
+			return fmt.Errorf("e2e: last chunk does not check out; the file was altered or cut short")
+		}
+		return fmt.Errorf("e2e: chunk %v does not check out; the file was altered", d.chunk)
+	}
+	d.chunk++
+
+	if _, err := d.dst.Write(plain); err != nil {
+		return err
+	}
+	d.hasher.Write(plain)
+	d.size += int64(len(plain))
+
+	return nil
+}
+
+// header parses the header, info block and nonce once they are
+// all in buf, and says whether they were.
+func (d *Decrypter) header() (bool, error) {
+	if len(d.buf) < len(Magic) {
+		return false, nil
+	}
+	if !IsEncrypted(d.buf) {
+		return false, fmt.Errorf("e2e: not an encrypted file")
+	}
+
+	end := bytes.Index(d.buf, []byte("\n--- "))
+	if end < 0 {
+		if len(d.buf) > maxHeader {
+			return false, fmt.Errorf("e2e: header is over %v bytes", maxHeader)
+		}
+		return false, nil
+	}
+	macEnd := bytes.IndexByte(d.buf[end+1:], '\n')
+	if macEnd < 0 {
+		return false, nil
+	}
+	macEnd += end + 1
+	if len(d.buf) < macEnd+1+infoSize+nonceSize {
+		return false, nil
+	}
+
+	stanzas, err := parseStanzas(string(d.buf[len(Magic) : end+1]))
+	if err != nil {
+		return false, err
+	}
+
+	fileKey, err := d.unwrap(stanzas)
+	if err != nil {
+		return false, err
+	}
+
+	mac, err := b64.DecodeString(string(d.buf[end+5 : macEnd]))
+	if err != nil {
+		return false, fmt.Errorf("e2e: bad header MAC")
+	}
+	if subtle.ConstantTimeCompare(mac, headerMAC(fileKey, d.buf[:end+4])) != 1 {
+		return false, fmt.Errorf("e2e: header does not check out; the file was altered")
+	}
+
+	rest := d.buf[macEnd+1:]
+	info, err := aeadOpen(derive(fileKey, nil, "info", chacha20poly1305.KeySize), rest[:infoSize])
+	if err != nil {
+		return false, fmt.Errorf("e2e: info block does not check out; the file was altered")
+	}
+	d.wantSize = int64(binary.BigEndian.Uint64(info[:8]))
+	d.wantSum = info[8:]
+
+	nonce := rest[infoSize : infoSize+nonceSize]
+	d.payload, err = chacha20poly1305.New(derive(fileKey, nonce, "payload", chacha20poly1305.KeySize))
+	if err != nil {
+		return false, err
+	}
+	d.hasher, err = blake2b.New(nil)
+	if err != nil {
+		return false, err
+	}
+
+	d.buf = append([]byte(nil), rest[infoSize+nonceSize:]...)
+
+	return true, nil
+}
+
+// parseStanzas parses the "-> ..." lines of a header, and the
+// body line after each.
+func parseStanzas(s string) ([]*Stanza, error) {
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if len(lines)%2 != 0 || len(lines) == 0 {
+		return nil, fmt.Errorf("e2e: malformed header")
+	}
+
+	var stanzas []*Stanza
+	for i := 0; i < len(lines); i += 2 {
+		fields := strings.Fields(lines[i])
+		if len(fields) < 2 || fields[0] != "->" {
+			return nil, fmt.Errorf("e2e: malformed header line '%s'", lines[i])
+		}
+		body, err := b64.DecodeString(lines[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("e2e: malformed stanza body: %v", err)
+		}
+		stanzas = append(stanzas, &Stanza{Type: fields[1], Args: fields[2:], Body: body})
+	}
+
+	return stanzas, nil
+}
+
+// unwrap finds a stanza one of our identities can open.
+func (d *Decrypter) unwrap(stanzas []*Stanza) ([]byte, error) {
+	if len(d.identities) == 0 {
+		return nil, fmt.Errorf("e2e: file is encrypted, and we have no identity or passphrase to open it with")
+	}
+
+	var types []string
+	for _, s := range stanzas {
+		types = append(types, s.Type)
+		for _, id := range d.identities {
+			key, err := id.Unwrap(s)
+			if errors.Is(err, ErrIncorrectIdentity) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			if len(key) != fileKeySize {
+				return nil, fmt.Errorf("e2e: file key is %v bytes", len(key))
+			}
+			return key, nil
+		}
+	}
+
+	return nil, fmt.Errorf("e2e: file is encrypted to %s, and none of those is us", strings.Join(types, ", "))
+}
@@ -0,0 +1,124 @@
+package e2e
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/devops-filetransfer/blake2b"
+)
+
+func sum(t *testing.T, data []byte) []byte {
+	h, err := blake2b.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Write(data)
+
+	return h.Sum(nil)
+}
+
+func encrypt(t *testing.T, data []byte, rs ...Recipient) []byte {
+	e, err := NewEncrypter(bytes.NewReader(data), int64(len(data)), sum(t, data), rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := io.ReadAll(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if int64(len(sealed)) != e.Size() {
+		t.Fatalf("encrypted to %v bytes, but Size said %v", len(sealed), e.Size())
+	}
+
+	return sealed
+}
+
+// decrypt feeds sealed to a Decrypter in pieces of n bytes.
+func decrypt(sealed []byte, n int, ids ...Identity) ([]byte, error) {
+	var out bytes.Buffer
+	d := NewDecrypter(&out, ids)
+	for len(sealed) > 0 {
+		k := n
+		if k > len(sealed) {
+			k = len(sealed)
+		}
+		if _, err := d.Write(sealed[:k]); err != nil {
+			return nil, err
+		}
+		sealed = sealed[k:]
+	}
+
+	if err := d.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+func TestRoundTrip(t *testing.T) {
+	alice, _ := GenerateIdentity()
+	bob, _ := GenerateIdentity()
+	pass := NewPassphrase([]byte("correct horse battery staple"))
+
+	r, err := ParseRecipient(bob.Recipient().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, size := range []int{0, 1, ChunkSize, 3*ChunkSize + 7} {
+		data := make([]byte, size)
+		rand.New(rand.NewSource(int64(size))).Read(data)
+
+		sealed := encrypt(t, data, alice.Recipient(), r, pass)
+		if size > 64 && bytes.Contains(sealed, data[:64]) {
+			t.Fatal("plain text in the encrypted file")
+		}
+
+		for _, id := range []Identity{alice, bob, pass} {
+			got, err := decrypt(sealed, 1000, id)
+			if err != nil {
+				t.Fatalf("%v bytes: %v", size, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("%v bytes: decrypted file differs", size)
+			}
+		}
+	}
+}
+
+func TestRefusals(t *testing.T) {
+	alice, _ := GenerateIdentity()
+	mallory, _ := GenerateIdentity()
+	data := make([]byte, 2*ChunkSize+100)
+	sealed := encrypt(t, data, alice.Recipient())
+
+	if _, err := decrypt(sealed, len(sealed), mallory); err == nil || !strings.Contains(err.Error(), "none of those is us") {
+		t.Fatalf("opened by the wrong identity: %v", err)
+	}
+	if _, err := decrypt(sealed, len(sealed), NewPassphrase([]byte("guess"))); err == nil {
+		t.Fatal("opened by a passphrase it was not encrypted to")
+	}
+
+	// drop the last chunk, which is 100 bytes of data.
+	if _, err := decrypt(sealed[:len(sealed)-100-overhead], 4096, alice); err == nil {
+		t.Fatal("opened a truncated file")
+	}
+
+	flipped := append([]byte(nil), sealed...)
+	flipped[len(flipped)-ChunkSize] ^= 1
+	if _, err := decrypt(flipped, 4096, alice); err == nil {
+		t.Fatal("opened an altered file")
+	}
+
+	// the source changing under the encrypter is caught.
+	e, err := NewEncrypter(bytes.NewReader(data), int64(len(data)), sum(t, []byte("something else")), []Recipient{alice.Recipient()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.ReadAll(e); err == nil {
+		t.Fatal("encrypted a file that does not match its checksum")
+	}
+}
@@ -0,0 +1,401 @@
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/crypto/scrypt"
+)
+
+// Stanza is one recipient's wrapped copy of a file's key, as it
+// appears in the header: "-> Type Args...", then the Body.
+type Stanza struct {
+	Type string
+	Args []string
+	Body []byte
+}
+
+// Recipient is someone a file is encrypted to.
+type Recipient interface {
+	Wrap(fileKey []byte) (*Stanza, error)
+}
+
+// Identity can open the stanzas wrapped for it. Unwrap returns
+// ErrIncorrectIdentity for a stanza that is not for it.
+type Identity interface {
+	Unwrap(s *Stanza) ([]byte, error)
+}
+
+// ErrIncorrectIdentity is returned by Identity.Unwrap for a
+// stanza meant for someone else.
+var ErrIncorrectIdentity = fmt.Errorf("e2e: stanza is not for this identity")
+
+// publicPrefix starts the text form of an X25519 public key.
+const publicPrefix = "ft-x25519:"
+
+// identityBlock is the PEM block type of an identity file.
+const identityBlock = "E2E IDENTITY"
+
+// IdentityFile is our identity under DefaultDir; our public key
+// is beside it, with PubSuffix.
+const (
+	IdentityFile = "identity"
+	PubSuffix    = ".pub"
+)
+
+// DefaultDir is where we keep our identity unless told otherwise.
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	return filepath.Join(home, ".filetransfer", "e2e")
+}
+
+// DefaultIdentityPath is the identity we decrypt with unless told
+// otherwise.
+func DefaultIdentityPath() string {
+	return filepath.Join(DefaultDir(), IdentityFile)
+}
+
+// X25519Recipient is a public key files can be encrypted to.
+type X25519Recipient struct {
+	pub []byte
+}
+
+// X25519Identity is the private key that opens files encrypted to
+// its X25519Recipient.
+type X25519Identity struct {
+	priv, pub []byte
+}
+
+// GenerateIdentity returns a new X25519 identity.
+func GenerateIdentity() (*X25519Identity, error) {
+	priv := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(priv); err != nil {
+		return nil, err
+	}
+
+	return newIdentity(priv)
+}
+
+func newIdentity(priv []byte) (*X25519Identity, error) {
+	pub, err := curve25519.X25519(priv, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+
+	return &X25519Identity{priv: priv, pub: pub}, nil
+}
+
+// Recipient is the public half of id.
+func (id *X25519Identity) Recipient() *X25519Recipient {
+	return &X25519Recipient{pub: id.pub}
+}
+
+// Marshal returns id as a PEM identity file, with its public key
+// in the clear in a header.
+func (id *X25519Identity) Marshal() []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type:    identityBlock,
+		Headers: map[string]string{"Public-Key": id.Recipient().String()},
+		Bytes:   id.priv,
+	})
+}
+
+// Generate writes a new identity to path, and its public key to
+// path+PubSuffix, refusing to replace an identity already there.
+func Generate(path string) (*X25519Identity, error) {
+	id, err := GenerateIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := writeNew(path, id.Marshal(), 0600); err != nil {
+		return nil, err
+	}
+	if err := writeNew(path+PubSuffix, []byte(id.Recipient().String()+"\n"), 0644); err != nil {
+		return nil, err
+	}
+
+	return id, nil
+}
+
+// writeNew writes data to a new file at path, refusing to
+// overwrite one that is already there.
+func writeNew(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if os.IsExist(err) {
+		return fmt.Errorf("'%s' already exists; not replacing it", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// LoadIdentity reads the identity file at path.
+func LoadIdentity(path string) (*X25519Identity, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b, _ := pem.Decode(data)
+	if b == nil || b.Type != identityBlock || len(b.Bytes) != curve25519.ScalarSize {
+		return nil, fmt.Errorf("'%s' is not an e2e identity file, as made by 'client e2e keygen'", path)
+	}
+
+	return newIdentity(b.Bytes)
+}
+
+// String gives r in the form ParseRecipient takes.
+func (r *X25519Recipient) String() string {
+	return publicPrefix + base64.RawURLEncoding.EncodeToString(r.pub)
+}
+
+// ParseRecipient parses a public key as written by String.
+func ParseRecipient(s string) (*X25519Recipient, error) {
+	if !strings.HasPrefix(s, publicPrefix) {
+		return nil, fmt.Errorf("e2e: '%s' is not a public key; those start '%s'", s, publicPrefix)
+	}
+
+	pub, err := base64.RawURLEncoding.DecodeString(strings.TrimPrefix(s, publicPrefix))
+	if err != nil || len(pub) != curve25519.PointSize {
+		return nil, fmt.Errorf("e2e: '%s' is not a valid public key", s)
+	}
+
+	return &X25519Recipient{pub: pub}, nil
+}
+
+// ParseRecipients takes a comma separated list of public keys, or
+// files of them, one per line, ignoring blank lines and # comments.
+func ParseRecipients(list string) ([]Recipient, error) {
+	var rs []Recipient
+	for _, item := range strings.Split(list, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if strings.HasPrefix(item, publicPrefix) {
+			r, err := ParseRecipient(item)
+			if err != nil {
+				return nil, err
+			}
+			rs = append(rs, r)
+			continue
+		}
+
+		data, err := os.ReadFile(item)
+		if err != nil {
+			return nil, fmt.Errorf("e2e: '%s' is neither a public key nor a file of them: %v", item, err)
+		}
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		for sc.Scan() {
+			line := strings.TrimSpace(sc.Text())
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			r, err := ParseRecipient(line)
+			if err != nil {
+				return nil, fmt.Errorf("'%s': %v", item, err)
+			}
+			rs = append(rs, r)
+		}
+	}
+
+	return rs, nil
+}
+
+const x25519Label = "filetransfer/e2e/X25519"
+
+// Wrap implements Recipient.
+func (r *X25519Recipient) Wrap(fileKey []byte) (*Stanza, error) {
+	eph := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(eph); err != nil {
+		return nil, err
+	}
+	share, err := curve25519.X25519(eph, curve25519.Basepoint)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := curve25519.X25519(eph, r.pub)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := aeadSeal(wrapKey(shared, share, r.pub), fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stanza{Type: "X25519", Args: []string{base64.RawStdEncoding.EncodeToString(share)}, Body: body}, nil
+}
+
+// Unwrap implements Identity.
+func (id *X25519Identity) Unwrap(s *Stanza) ([]byte, error) {
+	if s.Type != "X25519" {
+		return nil, ErrIncorrectIdentity
+	}
+	if len(s.Args) != 1 {
+		return nil, fmt.Errorf("e2e: bad X25519 stanza")
+	}
+	share, err := base64.RawStdEncoding.DecodeString(s.Args[0])
+	if err != nil || len(share) != curve25519.PointSize {
+		return nil, fmt.Errorf("e2e: bad X25519 stanza")
+	}
+
+	shared, err := curve25519.X25519(id.priv, share)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey, err := aeadOpen(wrapKey(shared, share, id.pub), s.Body)
+	if err != nil {
+		// wrapped for some other key.
+		return nil, ErrIncorrectIdentity
+	}
+
+	return fileKey, nil
+}
+
+func wrapKey(shared, share, pub []byte) []byte {
+	salt := append(append([]byte{}, share...), pub...)
+	key := make([]byte, chacha20poly1305.KeySize)
+	_, _ = io.ReadFull(hkdf.New(sha256.New, shared, salt, []byte(x25519Label)), key)
+
+	return key
+}
+
+// ScryptLogN is the work factor we encrypt to passphrases with:
+// scrypt's N is 1<<ScryptLogN.
+const ScryptLogN = 18
+
+// maxScryptLogN bounds the work a file can ask of us.
+const maxScryptLogN = 22
+
+const scryptLabel = "filetransfer/e2e/scrypt"
+
+// Passphrase is both the Recipient and the Identity for
+// encrypting with a passphrase.
+type Passphrase struct {
+	pass []byte
+}
+
+// NewPassphrase returns a Passphrase for pass.
+func NewPassphrase(pass []byte) *Passphrase {
+	return &Passphrase{pass: pass}
+}
+
+// LoadPassphrase reads a passphrase from the first line of path.
+func LoadPassphrase(path string) (*Passphrase, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	line, _, _ := strings.Cut(string(data), "\n")
+	line = strings.TrimRight(line, "\r")
+	if line == "" {
+		return nil, fmt.Errorf("'%s' holds no passphrase", path)
+	}
+
+	return NewPassphrase([]byte(line)), nil
+}
+
+// Wrap implements Recipient.
+func (p *Passphrase) Wrap(fileKey []byte) (*Stanza, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	key, err := p.key(salt, ScryptLogN)
+	if err != nil {
+		return nil, err
+	}
+	body, err := aeadSeal(key, fileKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Stanza{Type: "scrypt", Args: []string{base64.RawStdEncoding.EncodeToString(salt), strconv.Itoa(ScryptLogN)}, Body: body}, nil
+}
+
+// Unwrap implements Identity.
+func (p *Passphrase) Unwrap(s *Stanza) ([]byte, error) {
+	if s.Type != "scrypt" {
+		return nil, ErrIncorrectIdentity
+	}
+	if len(s.Args) != 2 {
+		return nil, fmt.Errorf("e2e: bad scrypt stanza")
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(s.Args[0])
+	if err != nil || len(salt) != 16 {
+		return nil, fmt.Errorf("e2e: bad scrypt stanza")
+	}
+	logN, err := strconv.Atoi(s.Args[1])
+	if err != nil || logN <= 0 || logN > maxScryptLogN {
+		return nil, fmt.Errorf("e2e: scrypt work factor '%s' is out of bounds", s.Args[1])
+	}
+
+	key, err := p.key(salt, logN)
+	if err != nil {
+		return nil, err
+	}
+
+	fileKey, err := aeadOpen(key, s.Body)
+	if err != nil {
+		return nil, fmt.Errorf("e2e: wrong passphrase")
+	}
+
+	return fileKey, nil
+}
+
+func (p *Passphrase) key(salt []byte, logN int) ([]byte, error) {
+	return scrypt.Key(p.pass, append([]byte(scryptLabel), salt...), 1<<logN, 8, 1, chacha20poly1305.KeySize)
+}
+
+// aeadSeal and aeadOpen wrap a file key under a key used only
+// the once, so the nonce can be all zeros.
+func aeadSeal(key, plain []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nil, make([]byte, aead.NonceSize()), plain, nil), nil
+}
+
+func aeadOpen(key, sealed []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, make([]byte, aead.NonceSize()), sealed, nil)
+}
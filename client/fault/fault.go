@@ -0,0 +1,155 @@
+// Package fault names the ways a transfer can fail, so that callers
+// can tell a corrupted chunk from a dropped connection without
+// reading error messages. Across gRPC, each goes as its own status
+// code, with an ErrorInfo detail saying which it was, and of what.
+package fault
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Domain is the ErrorInfo domain our details go under.
+const Domain = "filetransfer"
+
+// Kind is the way a transfer failed.
+type Kind int
+
+const (
+	// Checksum means data did not match its checksum.
+	Checksum Kind = iota + 1
+
+	// Size means a chunk or file was not as long as was said.
+	Size
+
+	// Protocol means a peer sent what it should not have, or
+	// when it should not have: chunks out of order, for another
+	// file, or a stream that ended before the last chunk.
+	Protocol
+
+	// Transport means the connection to the peer failed.
+	Transport
+)
+
+var kinds = []struct {
+	name, reason string
+	code         codes.Code
+}{
+	Checksum:  {"checksum mismatch", "CHECKSUM_MISMATCH", codes.DataLoss},
+	Size:      {"size mismatch", "SIZE_MISMATCH", codes.OutOfRange},
+	Protocol:  {"protocol violation", "PROTOCOL_VIOLATION", codes.InvalidArgument},
+	Transport: {"transport failure", "TRANSPORT_FAILURE", codes.Unavailable},
+}
+
+func (k Kind) valid() bool {
+	return k > 0 && int(k) < len(kinds)
+}
+
+func (k Kind) String() string {
+	if !k.valid() {
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+
+	return kinds[k].name
+}
+
+// Code is the gRPC status code k goes as.
+func (k Kind) Code() codes.Code {
+	if !k.valid() {
+		return codes.Unknown
+	}
+
+	return kinds[k].code
+}
+
+// Error is a transfer of Path that failed as Kind says.
+type Error struct {
+	Kind Kind
+	Path string
+
+	// Msg says what happened, in full; Err is the error
+	// behind it, if any.
+	Msg string
+	Err error
+}
+
+// New returns an Error of kind about path, its message formatted
+// as by fmt.Errorf; wrapping the error given for %w, if any.
+func New(kind Kind, path, format string, a ...interface{}) *Error {
+	err := fmt.Errorf(format, a...)
+
+	return &Error{Kind: kind, Path: path, Msg: err.Error(), Err: errors.Unwrap(err)}
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// GRPCStatus is how e goes back to a gRPC caller. A transport
+// failure keeps the code of the failed call behind it, if any.
+func (e *Error) GRPCStatus() *status.Status {
+	code := e.Kind.Code()
+	if e.Kind == Transport && e.Err != nil {
+		if c := status.Code(e.Err); c != codes.Unknown {
+			code = c
+		}
+	}
+
+	st := status.New(code, e.Msg)
+	if !e.Kind.valid() {
+		return st
+	}
+	withInfo, err := st.WithDetails(&errdetails.ErrorInfo{
+		Reason:   kinds[e.Kind].reason,
+		Domain:   Domain,
+		Metadata: map[string]string{"path": e.Path},
+	})
+	if err != nil {
+		return st
+	}
+
+	return withInfo
+}
+
+// From returns err as an *Error: either it is one, or wraps one;
+// or it is the gRPC status a peer sent back for one.
+func From(err error) (*Error, bool) {
+	var fe *Error
+	if errors.As(err, &fe) {
+		return fe, true
+	}
+
+	st, ok := status.FromError(err)
+	if !ok || st == nil {
+		return nil, false
+	}
+	for _, d := range st.Details() {
+		info, ok := d.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != Domain {
+			continue
+		}
+		for k := range kinds {
+			if kind := Kind(k); kind.valid() && kinds[k].reason == info.Reason {
+				return &Error{Kind: kind, Path: info.Metadata["path"], Msg: err.Error(), Err: err}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// Is says if err is, or wraps, or came back from a peer as, an
+// Error of kind.
+func Is(err error, kind Kind) bool {
+	fe, ok := From(err)
+
+	return ok && fe.Kind == kind
+}
@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"time"
+)
+
+// chunkOverhead is the room we leave in each gRPC message
+// for the BigFileChunk fields other than Data: the path,
+// the two checksums, and the fixed size fields.
+const chunkOverhead = 64 << 10
+
+// MinChunkSize is the smallest chunk the ChunkSizer will shrink to.
+const MinChunkSize = 64 << 10
+
+// ChunkSizer picks the size of the next chunk to send. It
+// grows the chunk size while the observed throughput keeps
+// improving, and backs off when throughput drops. It never
+// goes over the max given to NewChunkSizer, which should be
+// derived from the negotiated gRPC message size limit.
+type ChunkSizer struct {
+	min int
+	max int
+	cur int
+
+	// rate is a smoothed (EWMA) throughput in bytes/sec.
+	rate float64
+}
+
+// NewChunkSizer returns a ChunkSizer starting at initial bytes,
+// and never going over max. Chunks shrink no further than
+// MinChunkSize, or than initial if that is smaller.
+func NewChunkSizer(initial, max int) *ChunkSizer {
+	if initial <= 0 {
+		initial = MinChunkSize
+	}
+
+	min := MinChunkSize
+	if initial < min {
+		min = initial
+	}
+	if min > max {
+		min = max
+	}
+
+	z := &ChunkSizer{min: min, max: max}
+	z.cur = z.clamp(initial)
+
+	return z
+}
+
+// Next returns the size to use for the next chunk.
+func (z *ChunkSizer) Next() int {
+	return z.cur
+}
+
+// Max returns the ceiling on chunk sizes.
+func (z *ChunkSizer) Max() int {
+	return z.max
+}
+
+// Observe records that n bytes took elap to go out,
+// and adjusts the chunk size accordingly.
+func (z *ChunkSizer) Observe(n int, elap time.Duration) {
+	if n <= 0 || elap <= 0 {
+		return
+	}
+
+	rate := float64(n) / elap.Seconds()
+
+	if z.rate == 0 {
+		z.rate = rate
+		z.cur = z.clamp(z.cur * 2)
+		return
+	}
+
+	switch {
+	case rate > z.rate*1.1:
+		z.cur = z.clamp(z.cur * 2)
+	case rate < z.rate*0.7:
+		z.cur = z.clamp(z.cur / 2)
+	}
+
+	const alpha = 0.3
+	z.rate = alpha*rate + (1-alpha)*z.rate
+}
+
+func (z *ChunkSizer) clamp(n int) int {
+	if n < z.min {
+		return z.min
+	}
+
+	if n > z.max {
+		return z.max
+	}
+
+	return n
+}
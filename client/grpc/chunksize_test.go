@@ -0,0 +1,42 @@
+package grpc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChunkSizerNeverExceedsMax(t *testing.T) {
+	max := 3 << 20
+	z := NewChunkSizer(1<<20, max)
+
+	// ever faster sends should grow the chunk, but only up to max.
+	for i := 1; i < 20; i++ {
+		z.Observe(z.Next(), time.Millisecond/time.Duration(i))
+		if z.Next() > max {
+			t.Fatalf("chunk size %v exceeds max %v", z.Next(), max)
+		}
+	}
+
+	if z.Next() != max {
+		t.Fatalf("expected chunk size to grow to max %v, got %v", max, z.Next())
+	}
+}
+
+func TestChunkSizerShrinksWhenThroughputDrops(t *testing.T) {
+	z := NewChunkSizer(1<<20, 16<<20)
+
+	z.Observe(z.Next(), time.Millisecond)
+	grown := z.Next()
+
+	z.Observe(z.Next(), time.Second)
+	if z.Next() >= grown {
+		t.Fatalf("expected chunk size to shrink below %v, got %v", grown, z.Next())
+	}
+}
+
+func TestChunkSizerInitialAboveMax(t *testing.T) {
+	z := NewChunkSizer(8<<20, 1<<20)
+	if z.Next() != 1<<20 {
+		t.Fatalf("expected initial chunk clamped to max, got %v", z.Next())
+	}
+}
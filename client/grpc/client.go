@@ -3,35 +3,171 @@ package grpc
 
 import (
 	"bytes"
+	"crypto/ed25519"
 	"fmt"
 	"hash"
 	"io"
-	"log"
+	"log/slog"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/devops-filetransfer/blake2b"
 
+	"github.com/devops-filetransfer/filetransfer/client/e2e"
+	"github.com/devops-filetransfer/filetransfer/client/fault"
+	"github.com/devops-filetransfer/filetransfer/client/logging"
+	"github.com/devops-filetransfer/filetransfer/client/manifest"
 	"github.com/devops-filetransfer/filetransfer/client/print"
 	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+	"github.com/devops-filetransfer/filetransfer/client/tracing"
 )
 
 type client struct {
 	hasher     hash.Hash
 	nextChunk  int64
 	peerClient pb.PeerClient
+
+	// maxMsgSize is our own gRPC message size limit;
+	// serverLimits are the server's, once Negotiate()d.
+	maxMsgSize   int
+	serverLimits *pb.Limits
+
+	// recipients, when set, are who we encrypt files to before
+	// they leave us; identities are who we decrypt them as.
+	recipients []e2e.Recipient
+	identities []e2e.Identity
+
+	// signKey, when set, signs a manifest of each file we send.
+	signKey ed25519.PrivateKey
+
+	// retry says how we retry transfers that fail; retries
+	// counts those made, against its Budget.
+	retry   RetryPolicy
+	retries int
 }
 
-func NewClient(conn *grpc.ClientConn) *client {
+func NewClient(conn *grpc.ClientConn, maxMsgSize int) *client {
 	h, err := blake2b.New(nil)
 	print.PanicOn(err)
 
 	return &client{
 		hasher:     h,
 		peerClient: pb.NewPeerClient(conn),
+		maxMsgSize: maxMsgSize,
+	}
+}
+
+// EncryptTo makes every file this client sends end-to-end
+// encrypted to recipients, so the server only sees ciphertext.
+func (c *client) EncryptTo(recipients []e2e.Recipient) {
+	c.recipients = recipients
+}
+
+// DecryptWith lets this client open the end-to-end encrypted
+// files it fetches that were encrypted to one of identities.
+func (c *client) DecryptWith(identities []e2e.Identity) {
+	c.identities = identities
+}
+
+// SignWith has this client sign a manifest of each file it sends
+// with key, for the server to check against the keys it trusts.
+func (c *client) SignWith(key ed25519.PrivateKey) {
+	c.signKey = key
+}
+
+// sign puts our signed manifest of the file on its last chunk nk,
+// the whole file being size bytes, if we have a key to sign with.
+func (c *client) sign(nk *pb.BigFileChunk, size int64) error {
+	if c.signKey == nil {
+		return nil
+	}
+
+	signed, err := manifest.Sign(manifest.New(nk.Filepath, size, nk.Blake2BCumulative), c.signKey)
+	if err != nil {
+		return fmt.Errorf("'%s' could not sign its manifest: %v", nk.Filepath, err)
+	}
+	nk.Signature = signed
+
+	return nil
+}
+
+// seal end-to-end encrypts data if we have recipients to encrypt
+// it to, and otherwise returns it as it is.
+func (c *client) seal(data []byte) ([]byte, error) {
+	if len(c.recipients) == 0 {
+		return data, nil
+	}
+
+	enc, err := e2e.NewEncrypter(bytes.NewReader(data), int64(len(data)), blake2bOfBytes(data), c.recipients)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(enc)
+}
+
+// Negotiate exchanges message size limits with the server
+// (only once per client), and returns the largest chunk
+// of Data that we may put in a single BigFileChunk.
+func (c *client) Negotiate(ctx context.Context) (int, error) {
+	if err := c.fetchServerLimits(ctx); err != nil {
+		return 0, err
+	}
+
+	return maxChunkFor(c.maxMsgSize, c.serverLimits.MaxRecvMsgSize)
+}
+
+// negotiateRecv is Negotiate for the other direction: the
+// largest chunk we can take from the server.
+func (c *client) negotiateRecv(ctx context.Context) (int, error) {
+	if err := c.fetchServerLimits(ctx); err != nil {
+		return 0, err
+	}
+
+	return maxChunkFor(c.maxMsgSize, c.serverLimits.MaxSendMsgSize)
+}
+
+func (c *client) fetchServerLimits(ctx context.Context) error {
+	if c.serverLimits != nil {
+		return nil
+	}
+
+	limits, err := c.peerClient.Negotiate(ctx, &pb.Limits{
+		MaxRecvMsgSize: int64(c.maxMsgSize),
+		MaxSendMsgSize: int64(c.maxMsgSize),
+	})
+	if err != nil {
+		return serverErr("", err, "could not negotiate message size limits with the server")
 	}
+	c.serverLimits = limits
+
+	return nil
+}
+
+// maxChunkFor returns the room for Data in a message that
+// must fit both our limit and theirs.
+func maxChunkFor(ours int, theirs int64) (int, error) {
+	limit := int64(ours)
+	if theirs < limit {
+		limit = theirs
+	}
+
+	maxChunk := limit - chunkOverhead
+	if maxChunk <= 0 {
+		return 0, fmt.Errorf("negotiated message size limit of %v bytes leaves no room for chunk data", limit)
+	}
+
+	return int(maxChunk), nil
+}
+
+// logger is the logger for the transfer on ctx, by myID.
+func logger(ctx context.Context, myID string) *slog.Logger {
+	return logging.From(ctx).With("client", myID)
 }
 
 func (c *client) startNewFile() {
@@ -39,27 +175,46 @@ func (c *client) startNewFile() {
 	c.nextChunk = 0
 }
 
-func (c *client) RunSendFile(path string, data []byte, maxChunkSize int, isBcastSet bool, myID string) error {
+// RunSendFile sends data to the server under the name path.
+// Chunks start out at initialChunkSize bytes, and are then
+// resized according to the observed throughput, but never
+// beyond what the negotiated message size limits allow.
+func (c *client) RunSendFile(path string, data []byte, initialChunkSize int, isBcastSet bool, myID string) (err error) {
 	startOfRunSendFile := time.Now().UTC()
 	startOfRunSendFileNanoUint64 := uint64(startOfRunSendFile.UnixNano())
 
-	c.startNewFile()
-	stream, err := c.peerClient.SendFile(context.Background())
+	ctx, span := tracing.Start(logging.NewTransfer(context.Background()), "transfer", attribute.String("path", path))
+	defer func() { tracing.End(span, err) }()
+
+	l := logger(ctx, myID)
+	defer func() {
+		if err != nil {
+			l.Warn("send failed", "path", path, "err", err)
+		}
+	}()
+
+	maxChunk, err := c.Negotiate(ctx)
 	if err != nil {
-		log.Fatalf("%v.SendFile(_) = _, %v", c.peerClient, err)
+		return err
 	}
+	sizer := NewChunkSizer(initialChunkSize, maxChunk)
 
-	n := len(data)
-	numChunk := n / maxChunkSize
-	if n%maxChunkSize > 0 {
-		numChunk++
+	data, err = c.seal(data)
+	if err != nil {
+		return err
 	}
 
+	c.startNewFile()
+	stream, err := c.peerClient.SendFile(ctx)
+	if err != nil {
+		return serverErr(path, err, "'%s' could not start SendFile", path)
+	}
+
+	n := len(data)
 	nextByte := 0
-	lastChunk := numChunk - 1
 
-	for i := 0; i < numChunk; i++ {
-		sendLen := intMin(maxChunkSize, n-(i*maxChunkSize))
+	for nextByte < n || n == 0 {
+		sendLen := intMin(sizer.Next(), n-nextByte)
 		chunk := data[nextByte:(nextByte + sendLen)]
 		nextByte += sendLen
 
@@ -72,44 +227,93 @@ func (c *client) RunSendFile(path string, data []byte, maxChunkSize int, isBcast
 		nk.OriginalStartSendTime = startOfRunSendFileNanoUint64
 
 		// checksums
+		_, hashing := tracing.Start(ctx, "hash", attribute.Int64("chunk", c.nextChunk))
 		c.hasher.Write(chunk)
 		nk.Blake2B = blake2bOfBytes(chunk)
 		nk.Blake2BCumulative = []byte(c.hasher.Sum(nil))
+		hashing.End()
 
 		nk.Data = chunk
 		nk.ChunkNumber = c.nextChunk
 		c.nextChunk++
-		nk.IsLastChunk = (i == lastChunk)
+		nk.IsLastChunk = (nextByte == n)
+		if nk.IsLastChunk {
+			if err := c.sign(&nk, int64(n)); err != nil {
+				return err
+			}
+		}
 
-		if err := stream.Send(&nk); err != nil {
+		t0 := time.Now()
+		_, sending := tracing.Start(ctx, "send", attribute.Int64("chunk", nk.ChunkNumber))
+		err := stream.Send(&nk)
+		tracing.End(sending, err)
+		if err != nil {
 			if err == io.EOF {
 				if !nk.IsLastChunk {
-					panic(fmt.Sprintf("'%s' we got io.EOF before "+
-						"the last chunk! At: %v of %v", path, nk.ChunkNumber, numChunk))
+					// The server ended the stream early; the
+					// reason is only available from its status.
+					_, err = stream.CloseAndRecv()
+					return chunkRejected(path, &nk, sizer.Max(), err)
 				} else {
 					break
 				}
 			}
-			panic(err)
+			return serverErr(path, err, "'%s' sending chunk %v", path, nk.ChunkNumber)
+		}
+		sizer.Observe(sendLen, time.Since(t0))
+
+		if nk.IsLastChunk {
+			break
 		}
 	}
 
 	reply, err := stream.CloseAndRecv()
 	if err != nil {
-		log.Printf("%v.CloseAndRecv() got error %v, want %v. reply=%v", stream, err, nil, reply)
-		return err
+		return serverErr(path, err, "'%s' SendFile failed", path)
 	}
 
 	compared := bytes.Compare(reply.WholeFileBlake2B, []byte(c.hasher.Sum(nil)))
-	log.Printf("%s client.runSendFile got from stream.CloseAndRecv() a Reply with checksum: '%x'; checksum matches the sent data: %v; size sent = %v, size received = %v. startOfRunSendFile='%v'.", myID, reply.WholeFileBlake2B, compared == 0, len(data), reply.SizeInBytes, startOfRunSendFile)
+	l.Info("sent file", "path", path, "bytes", len(data), "server_bytes", reply.SizeInBytes, "blake2b", fmt.Sprintf("%x", reply.WholeFileBlake2B), "checksum_matches", compared == 0, "elapsed", time.Since(startOfRunSendFile))
 
+	if compared != 0 {
+		return fault.New(fault.Checksum, path, "'%s' whole file checksum mismatch: server has '%x', we sent '%x'", path, reply.WholeFileBlake2B, c.hasher.Sum(nil))
+	}
 	if int64(len(data)) != reply.SizeInBytes {
-		panic("size mismatch")
+		return fault.New(fault.Size, path, "'%s' size mismatch: server got %v bytes, we sent %v", path, reply.SizeInBytes, len(data))
 	}
 
 	return nil
 }
 
+// chunkRejected explains why the server closed the stream
+// on us in the middle of a file.
+func chunkRejected(path string, nk *pb.BigFileChunk, maxChunk int, err error) error {
+	if status.Code(err) == codes.ResourceExhausted {
+		return fmt.Errorf("'%s' chunk %v of %v bytes was rejected by the server as too large "+
+			"(negotiated max chunk size is %v bytes): %w", path, nk.ChunkNumber, len(nk.Data), maxChunk, err)
+	}
+
+	return serverErr(path, err, "'%s' server closed the stream at chunk %v", path, nk.ChunkNumber)
+}
+
+// serverErr returns err, from a call to the server about path,
+// prefixed with what we were doing as format says. It is typed as
+// the *fault.Error the server sent back, if it did, or as a
+// transport failure if the connection itself failed.
+func serverErr(path string, err error, format string, a ...interface{}) error {
+	msg := fmt.Sprintf(format, a...)
+	if fe, ok := fault.From(err); ok {
+		return fault.New(fe.Kind, path, "%s: %w", msg, err)
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return fault.New(fault.Transport, path, "%s: %w", msg, err)
+	}
+
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
 func blake2bOfBytes(by []byte) []byte {
 	h, err := blake2b.New(nil)
 	print.PanicOn(err)
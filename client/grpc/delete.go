@@ -0,0 +1,23 @@
+package grpc
+
+import (
+	"fmt"
+
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/filetransfer/client/logging"
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+)
+
+// RunDeleteFile asks the server to remove its stored copy of remote.
+func (c *client) RunDeleteFile(remote string, myID string) error {
+	ctx := logging.NewTransfer(context.Background())
+	_, err := c.peerClient.DeleteFile(ctx, &pb.DeleteRequest{Filepath: remote})
+	if err != nil {
+		return fmt.Errorf("'%s' could not be deleted: %v", remote, err)
+	}
+
+	logger(ctx, myID).Info("deleted file", "path", remote)
+
+	return nil
+}
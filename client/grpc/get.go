@@ -0,0 +1,249 @@
+package grpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/blake2b"
+
+	"github.com/devops-filetransfer/filetransfer/client/attr"
+	"github.com/devops-filetransfer/filetransfer/client/e2e"
+	"github.com/devops-filetransfer/filetransfer/client/fault"
+	"github.com/devops-filetransfer/filetransfer/client/logging"
+	"github.com/devops-filetransfer/filetransfer/client/manifest"
+	"github.com/devops-filetransfer/filetransfer/client/metrics"
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+	"github.com/devops-filetransfer/filetransfer/client/sparse"
+	"github.com/devops-filetransfer/filetransfer/client/tracing"
+)
+
+// RunGetFile fetches remote from the server into the local file,
+// verifying each chunk and the whole file as they arrive. The data
+// goes to a temporary file next to local, which is renamed into
+// place, with the file's metadata applied per policy, only once
+// everything has checked out. An end-to-end encrypted file is
+// decrypted as it arrives, and must also match the plain checksum
+// sealed in its header. A file stored with a signed manifest gets
+// it saved beside it, as local+manifest.SigSuffix, for checking
+// with 'client verify'; one stored with free-form metadata gets
+// that saved, as JSON, as local+MetaSuffix. A get cut short is
+// resumed, retried as our RetryPolicy says, from the first chunk we
+// did not get.
+func (c *client) RunGetFile(remote, local string, policy attr.Policy, myID string) (err error) {
+	startOfRunGetFile := time.Now().UTC()
+
+	ctx, span := tracing.Start(logging.NewTransfer(context.Background()), "get", attribute.String("path", remote))
+	defer func() { tracing.End(span, err) }()
+
+	l := logger(ctx, myID)
+	defer func() {
+		if err != nil {
+			l.Warn("get failed", "path", remote, "err", err)
+		}
+	}()
+
+	tmp, err := os.CreateTemp(filepath.Dir(local), "."+filepath.Base(local)+".partial-*")
+	if err != nil {
+		return err
+	}
+	committed := false
+	defer func() {
+		if !committed {
+			_ = tmp.Close()
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	hasher, err := blake2b.New(nil)
+	if err != nil {
+		return err
+	}
+
+	var a *pb.FileAttr
+	var got, chunkNumber int64
+	var signed []byte
+	var md map[string]string
+
+	// dec, for an encrypted file, is where its chunks go instead.
+	var dec *e2e.Decrypter
+
+	// each try carries on from the chunk the last one stopped at,
+	// unless what we got may be what was wrong.
+	err = c.withRetries(ctx, remote, l, func(ctx context.Context, last error) error {
+		if last != nil && !resumes(last) {
+			if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+				return err
+			}
+			if err := tmp.Truncate(0); err != nil {
+				return err
+			}
+			hasher.Reset()
+			a, got, chunkNumber, signed, md, dec = nil, 0, 0, nil, nil, nil
+		}
+
+		maxChunk, err := c.negotiateRecv(ctx)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		stream, err := c.peerClient.GetFile(ctx, &pb.GetRequest{
+			Filepath:     remote,
+			MaxChunkSize: int64(maxChunk),
+			Offset:       got,
+			NextChunk:    chunkNumber,
+		})
+		if err != nil {
+			return serverErr(remote, err, "'%s' could not start GetFile", remote)
+		}
+
+		for ; ; chunkNumber++ {
+			nk, err := stream.Recv()
+			if err == io.EOF {
+				return fault.New(fault.Protocol, remote, "'%s' stream ended after %v chunks, before the last chunk", remote, chunkNumber)
+			}
+			if err != nil {
+				return serverErr(remote, err, "'%s' GetFile failed after %v bytes", remote, got)
+			}
+
+			if nk.ChunkNumber != chunkNumber {
+				return fault.New(fault.Protocol, remote, "'%s' got chunk %v, expected chunk %v", remote, nk.ChunkNumber, chunkNumber)
+			}
+			if nk.SizeInBytes != int64(len(nk.Data)) {
+				metrics.ChecksumFailed(metrics.Size)
+				return fault.New(fault.Size, remote, "'%s' chunk %v: %v == nk.SizeInBytes != int64(len(nk.Data)) == %v", remote, nk.ChunkNumber, nk.SizeInBytes, len(nk.Data))
+			}
+			if nk.HoleSize < 0 || (nk.HoleSize > 0 && len(nk.Data) > 0) {
+				metrics.ChecksumFailed(metrics.Size)
+				return fault.New(fault.Protocol, remote, "'%s' chunk %v: hole of %v bytes must come without data", remote, nk.ChunkNumber, nk.HoleSize)
+			}
+			if !bytes.Equal(blake2bOfBytes(nk.Data), nk.Blake2B) {
+				metrics.ChecksumFailed(metrics.Chunk)
+				return fault.New(fault.Checksum, remote, "'%s' chunk %v bad .Data, checksum mismatch!", remote, nk.ChunkNumber)
+			}
+
+			hasher.Write(nk.Data)
+			sparse.HashZeros(hasher, nk.HoleSize)
+			if !bytes.Equal(hasher.Sum(nil), nk.Blake2BCumulative) {
+				metrics.ChecksumFailed(metrics.Cumulative)
+				return fault.New(fault.Checksum, remote, "'%s' cumulative checksums failed at chunk %v", remote, nk.ChunkNumber)
+			}
+
+			if nk.Attr != nil {
+				a = nk.Attr
+			}
+			if nk.Metadata != nil {
+				md = nk.Metadata
+			}
+
+			if chunkNumber == 0 && e2e.IsEncrypted(nk.Data) {
+				if len(c.identities) == 0 {
+					return fmt.Errorf("'%s' is end-to-end encrypted; give -identity or -passphrase_file to decrypt it", remote)
+				}
+				dec = e2e.NewDecrypter(tmp, c.identities)
+			}
+
+			switch {
+			case dec != nil && nk.HoleSize > 0:
+				_, err = io.CopyN(dec, zeros{}, nk.HoleSize)
+			case dec != nil:
+				_, err = dec.Write(nk.Data)
+			case nk.HoleSize > 0:
+				// leave a hole, rather than writing zeros.
+				_, err = tmp.Seek(nk.HoleSize, io.SeekCurrent)
+			default:
+				_, err = tmp.Write(nk.Data)
+			}
+			if err != nil {
+				return fmt.Errorf("'%s' chunk %v: %v", remote, nk.ChunkNumber, err)
+			}
+			got += int64(len(nk.Data)) + nk.HoleSize
+
+			if nk.IsLastChunk {
+				signed = nk.Signature
+				return nil
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	size := got
+	if dec != nil {
+		if err := dec.Close(); err != nil {
+			return fmt.Errorf("'%s': %v", remote, err)
+		}
+		l.Debug("decrypted file", "path", remote, "bytes", dec.Size(), "blake2b", fmt.Sprintf("%x", dec.Sum()))
+		size = dec.Size()
+	}
+
+	// a trailing hole only counts once the size says so.
+	if err := tmp.Truncate(size); err != nil {
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := attr.Apply(tmp.Name(), a, policy); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), local); err != nil {
+		return err
+	}
+	committed = true
+
+	switch {
+	case signed == nil:
+	case dec != nil:
+		// it would never match the plain file we have now.
+		l.Info("not keeping the signed manifest, which describes the encrypted file the server holds", "path", remote)
+	default:
+		if err := os.WriteFile(local+manifest.SigSuffix, signed, 0644); err != nil {
+			return fmt.Errorf("'%s' could not keep its signed manifest: %v", local, err)
+		}
+	}
+
+	if len(md) > 0 {
+		by, err := json.Marshal(md)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(local+MetaSuffix, by, 0644); err != nil {
+			return fmt.Errorf("'%s' could not keep its metadata: %v", local, err)
+		}
+		l.Debug("file metadata", "path", remote, "metadata", md)
+	}
+
+	l.Info("got file", "path", remote, "local", local, "bytes", got, "blake2b", fmt.Sprintf("%x", hasher.Sum(nil)), "elapsed", time.Since(startOfRunGetFile))
+
+	return nil
+}
+
+// MetaSuffix is added to a fetched file's name for the name of the
+// file its free-form metadata is saved in.
+const MetaSuffix = ".meta"
+
+// zeros reads as an endless run of zero bytes.
+type zeros struct{}
+
+func (zeros) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+
+	return len(p), nil
+}
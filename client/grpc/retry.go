@@ -0,0 +1,122 @@
+package grpc
+
+import (
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/devops-filetransfer/filetransfer/client/fault"
+)
+
+// RetryPolicy says how hard a client tries to get a transfer
+// through before giving up on it.
+type RetryPolicy struct {
+	// Attempts is how many times a transfer is tried, all
+	// told; 1 or less tries just the once.
+	Attempts int
+
+	// InitialBackoff is how long we wait before the first
+	// retry; each wait after doubles it, up to MaxBackoff.
+	// Waits are jittered, so that clients cut off together
+	// do not come back together.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Budget caps the retries of all of the client's
+	// transfers together; 0 leaves them uncapped.
+	Budget int
+
+	// Deadline, if not 0, bounds each transfer, its retries
+	// and the waits between them included.
+	Deadline time.Duration
+}
+
+// RetryWith has this client retry the transfers that fail in a
+// way that trying again may fix, as p says.
+func (c *client) RetryWith(p RetryPolicy) {
+	c.retry = p
+}
+
+// Retryable says if a transfer that failed with err may get through
+// if tried again: a dropped connection may come back, and corrupted
+// data may come through intact next time; the rest would only fail
+// the same way again.
+func Retryable(err error) bool {
+	if fe, ok := fault.From(err); ok {
+		return fe.Kind == fault.Transport || fe.Kind == fault.Checksum
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.Aborted:
+		return true
+	}
+
+	return false
+}
+
+// resumes says if a transfer that failed with err may carry on where
+// it stopped, rather than starting over: only what was verified is
+// kept, and a connection that dropped takes none of that with it.
+func resumes(err error) bool {
+	return fault.Is(err, fault.Transport)
+}
+
+// backoff is how long to wait before retry n, counting from 1:
+// between half and all of InitialBackoff doubled n-1 times, and
+// never more than MaxBackoff.
+func (p *RetryPolicy) backoff(n int) time.Duration {
+	d := p.InitialBackoff
+	for i := 1; i < n && (p.MaxBackoff <= 0 || d < p.MaxBackoff); i++ {
+		d *= 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+// withRetries runs try, the transfer of path, until it succeeds,
+// fails in a way that is not Retryable, or our policy says to give
+// up. try gets the error of the try before it, nil the first time,
+// to know if it may resume.
+func (c *client) withRetries(ctx context.Context, path string, l *slog.Logger, try func(ctx context.Context, last error) error) error {
+	if c.retry.Deadline > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.retry.Deadline)
+		defer cancel()
+	}
+
+	var last error
+	for attempt := 1; ; attempt++ {
+		err := try(ctx, last)
+		if err == nil || attempt >= c.retry.Attempts || !Retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		if c.retry.Budget > 0 && c.retries >= c.retry.Budget {
+			return fmt.Errorf("'%s' not retried, as all %v retries allowed were spent: %w", path, c.retry.Budget, err)
+		}
+
+		wait := c.retry.backoff(attempt)
+		if dl, ok := ctx.Deadline(); ok && time.Until(dl) < wait {
+			return fmt.Errorf("'%s' not retried, as the deadline comes before the next try would: %w", path, err)
+		}
+		c.retries++
+		l.Warn("retrying", "path", path, "attempt", attempt+1, "of", c.retry.Attempts, "in", wait, "resume", resumes(err), "err", err)
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+		last = err
+	}
+}
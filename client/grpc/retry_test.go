@@ -0,0 +1,125 @@
+package grpc
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/devops-filetransfer/blake2b"
+
+	"github.com/devops-filetransfer/filetransfer/client/fault"
+	"github.com/devops-filetransfer/filetransfer/client/sparse"
+)
+
+func TestRetryableOnlyWhenTryingAgainMayHelp(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		want bool
+	}{
+		{fault.New(fault.Transport, "f", "cut: %w", status.Error(codes.Canceled, "gone")), true},
+		{fault.New(fault.Checksum, "f", "corrupt"), true},
+		{status.Convert(fault.New(fault.Checksum, "f", "corrupt")).Err(), true},
+		{fault.New(fault.Size, "f", "short"), false},
+		{fault.New(fault.Protocol, "f", "out of order"), false},
+		{fmt.Errorf("starting: %w", status.Error(codes.Unavailable, "draining")), true},
+		{status.Error(codes.Aborted, "conflict"), true},
+		{status.Error(codes.PermissionDenied, "no"), false},
+		{errors.New("no such file"), false},
+	} {
+		if got := Retryable(c.err); got != c.want {
+			t.Errorf("Retryable(%v) = %v; want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestBackoffDoublesWithJitterUpToMax(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	for n, full := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		full *= time.Millisecond
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(n + 1); d < full/2 || d > full {
+				t.Fatalf("retry %v waits %v; want from %v to %v", n+1, d, full/2, full)
+			}
+		}
+	}
+}
+
+func TestRetriesStopAtTheBudget(t *testing.T) {
+	c := &client{retry: RetryPolicy{Attempts: 10, InitialBackoff: time.Microsecond, MaxBackoff: time.Microsecond, Budget: 3}}
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	cut := fault.New(fault.Transport, "f", "cut")
+
+	tries := 0
+	var resumed bool
+	err := c.withRetries(context.Background(), "f", l, func(ctx context.Context, last error) error {
+		tries++
+		resumed = resumes(last)
+		return cut
+	})
+	if !errors.Is(err, cut) || tries != 4 || !resumed {
+		t.Errorf("got %v after %v tries, resumed %v; want the cut after 4, resumed", err, tries, resumed)
+	}
+
+	// the budget is spent for every transfer after, too.
+	tries = 0
+	_ = c.withRetries(context.Background(), "g", l, func(ctx context.Context, last error) error {
+		tries++
+		return cut
+	})
+	if tries != 1 {
+		t.Errorf("tried %v times with the budget spent; want 1", tries)
+	}
+}
+
+func TestSourceSkipHashesWhatItPasses(t *testing.T) {
+	data := []byte("0123456789")
+	segs := []sparse.Segment{
+		{Offset: 0, Length: 4},
+		{Offset: 4, Length: 3, Hole: true},
+		{Offset: 7, Length: 3},
+	}
+
+	for _, n := range []int64{2, 4, 7, 9} {
+		// what sending the first n bytes hashes, and sends next.
+		want, _ := blake2b.New(nil)
+		sent := newSource(bytes.NewReader(data), segs)
+		for m := int64(0); m < n; {
+			chunk, hole, err := sent.next(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want.Write(chunk)
+			sparse.HashZeros(want, hole)
+			m += int64(len(chunk)) + hole
+		}
+		wantNext, wantHole, _ := sent.next(2)
+
+		h, _ := blake2b.New(nil)
+		src := newSource(bytes.NewReader(data), segs)
+		if err := src.skip(n, h); err != nil {
+			t.Fatalf("skip(%v): %v", n, err)
+		}
+		if !bytes.Equal(h.Sum(nil), want.Sum(nil)) {
+			t.Errorf("skip(%v) hashed what it passed differently than sending it would", n)
+		}
+		next, hole, err := src.next(2)
+		if err != nil || !bytes.Equal(next, wantNext) || hole != wantHole {
+			t.Errorf("after skip(%v) got %q and a hole of %v, %v; want %q and %v", n, next, hole, err, wantNext, wantHole)
+		}
+	}
+
+	src := newSource(bytes.NewReader(data), segs)
+	h, _ := blake2b.New(nil)
+	if err := src.skip(5, h); err == nil {
+		t.Errorf("skip into the middle of a hole was taken")
+	}
+}
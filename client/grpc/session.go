@@ -0,0 +1,201 @@
+package grpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/filetransfer/client/fault"
+	"github.com/devops-filetransfer/filetransfer/client/logging"
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+	"github.com/devops-filetransfer/filetransfer/client/tracing"
+)
+
+// SessionFile is one of the files sent by RunSession.
+type SessionFile struct {
+	Path string
+	Data []byte
+
+	// Metadata is kept with the file, and comes back with it,
+	// as local+MetaSuffix, on RunGetFile.
+	Metadata map[string]string
+}
+
+// RunSession sends all of files over a single Session stream, which
+// saves setting up a stream per file when there are many small ones.
+// It returns the server's ack for each file, in order. The error is
+// non-nil if the stream failed, or if any file was not received
+// intact; the acks then tell which files made it.
+func (c *client) RunSession(files []*SessionFile, initialChunkSize int, myID string) (_ []*pb.BigFileAck, err error) {
+	startOfRunSession := time.Now().UTC()
+	startNano := uint64(startOfRunSession.UnixNano())
+
+	ctx, span := tracing.Start(logging.NewTransfer(context.Background()), "session", attribute.Int("files", len(files)))
+	defer func() { tracing.End(span, err) }()
+
+	maxChunk, err := c.Negotiate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sizer := NewChunkSizer(initialChunkSize, maxChunk)
+
+	if len(c.recipients) > 0 {
+		sealed := make([]*SessionFile, len(files))
+		for i, f := range files {
+			data, err := c.seal(f.Data)
+			if err != nil {
+				return nil, err
+			}
+			sealed[i] = &SessionFile{Path: f.Path, Data: data, Metadata: f.Metadata}
+		}
+		files = sealed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.peerClient.Session(ctx)
+	if err != nil {
+		return nil, serverErr("", err, "could not start Session")
+	}
+
+	var acks []*pb.BigFileAck
+	recvDone := make(chan error, 1)
+	go func() {
+		for {
+			ack, err := stream.Recv()
+			if err == io.EOF {
+				recvDone <- nil
+				return
+			}
+			if err != nil {
+				recvDone <- err
+				return
+			}
+			acks = append(acks, ack)
+		}
+	}()
+
+	sums := make([][]byte, len(files))
+
+	var sendErr error
+	for i, f := range files {
+		sums[i], sendErr = c.sendSessionFile(stream, f, sizer, startNano)
+		if sendErr != nil {
+			break
+		}
+	}
+	if sendErr == io.EOF {
+		// the server ended the session; its status says why.
+		sendErr = nil
+	}
+	if sendErr == nil {
+		sendErr = stream.CloseSend()
+	}
+	if sendErr != nil {
+		// the server would wait on us for ever, and we on it.
+		cancel()
+		<-recvDone
+		return acks, serverErr("", sendErr, "session failed after %v of %v files were acked", len(acks), len(files))
+	}
+
+	if err = <-recvDone; err != nil {
+		return acks, serverErr("", err, "session failed after %v of %v files were acked", len(acks), len(files))
+	}
+
+	if len(acks) != len(files) {
+		return acks, fault.New(fault.Protocol, "", "session sent %v files but got %v acks", len(files), len(acks))
+	}
+
+	failed := 0
+	var firstErr error
+	for i, ack := range acks {
+		f := files[i]
+		var ferr error
+		switch {
+		case ack.Err != "":
+			ferr = fmt.Errorf("'%s': %s", ack.Filepath, ack.Err)
+		case ack.Filepath != f.Path:
+			ferr = fault.New(fault.Protocol, f.Path, "ack %v is for '%s', expected '%s'", i, ack.Filepath, f.Path)
+		case !bytes.Equal(ack.WholeFileBlake2B, sums[i]):
+			ferr = fault.New(fault.Checksum, f.Path, "'%s' whole file checksum mismatch: server has '%x', we sent '%x'", f.Path, ack.WholeFileBlake2B, sums[i])
+		case ack.SizeInBytes != int64(len(f.Data)):
+			ferr = fault.New(fault.Size, f.Path, "'%s' size mismatch: server got %v bytes, we sent %v", f.Path, ack.SizeInBytes, len(f.Data))
+		}
+		if ferr != nil {
+			failed++
+			if firstErr == nil {
+				firstErr = ferr
+			}
+		}
+	}
+
+	logger(ctx, myID).Info("session finished", "files", len(files), "failed", failed, "elapsed", time.Since(startOfRunSession))
+
+	if firstErr != nil {
+		return acks, fmt.Errorf("%v of %v files failed in session; first: %w", failed, len(files), firstErr)
+	}
+
+	return acks, nil
+}
+
+// sendSessionFile sends the header and chunks of f,
+// returning its whole-file checksum.
+func (c *client) sendSessionFile(stream pb.Peer_SessionClient, f *SessionFile, sizer *ChunkSizer, startNano uint64) ([]byte, error) {
+	c.startNewFile()
+
+	err := stream.Send(&pb.SessionMsg{
+		Header: &pb.FileHeader{
+			Filepath:    f.Path,
+			SizeInBytes: int64(len(f.Data)),
+			Metadata:    f.Metadata,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n := len(f.Data)
+	nextByte := 0
+
+	for {
+		sendLen := intMin(sizer.Next(), n-nextByte)
+		chunk := f.Data[nextByte:(nextByte + sendLen)]
+		nextByte += sendLen
+
+		nk := &pb.BigFileChunk{
+			Filepath:              f.Path,
+			SizeInBytes:           int64(sendLen),
+			SendTime:              uint64(time.Now().UnixNano()),
+			OriginalStartSendTime: startNano,
+			Data:                  chunk,
+			ChunkNumber:           c.nextChunk,
+			IsLastChunk:           nextByte == n,
+		}
+		c.nextChunk++
+
+		// checksums
+		c.hasher.Write(chunk)
+		nk.Blake2B = blake2bOfBytes(chunk)
+		nk.Blake2BCumulative = []byte(c.hasher.Sum(nil))
+		if nk.IsLastChunk {
+			if err := c.sign(nk, int64(n)); err != nil {
+				return nil, err
+			}
+		}
+
+		t0 := time.Now()
+		if err := stream.Send(&pb.SessionMsg{Chunk: nk}); err != nil {
+			return nil, err
+		}
+		sizer.Observe(sendLen, time.Since(t0))
+
+		if nk.IsLastChunk {
+			return c.hasher.Sum(nil), nil
+		}
+	}
+}
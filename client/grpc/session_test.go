@@ -0,0 +1,88 @@
+package grpc
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+)
+
+// sessionServer takes whatever a Session sends, until the
+// client is done, and acks nothing.
+type sessionServer struct {
+	pb.PeerServer
+}
+
+func (sessionServer) Negotiate(ctx context.Context, l *pb.Limits) (*pb.Limits, error) {
+	return l, nil
+}
+
+func (sessionServer) Session(stream pb.Peer_SessionServer) error {
+	for {
+		if _, err := stream.Recv(); err != nil {
+			return nil
+		}
+	}
+}
+
+// chunklessPeer is a pb.PeerClient whose Sessions fail to send
+// any chunk on our side, as a manifest we cannot sign would.
+type chunklessPeer struct {
+	pb.PeerClient
+}
+
+func (p chunklessPeer) Session(ctx context.Context, opts ...grpc.CallOption) (pb.Peer_SessionClient, error) {
+	stream, err := p.PeerClient.Session(ctx, opts...)
+	return chunklessStream{stream}, err
+}
+
+type chunklessStream struct {
+	pb.Peer_SessionClient
+}
+
+func (s chunklessStream) Send(msg *pb.SessionMsg) error {
+	if msg.Chunk != nil {
+		return errors.New("could not make the chunk")
+	}
+	return s.Peer_SessionClient.Send(msg)
+}
+
+func TestSessionReturnsWhenWeFailToSend(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	pb.RegisterPeerServer(srv, sessionServer{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	c := NewClient(conn, 1<<20)
+	c.peerClient = chunklessPeer{c.peerClient}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := c.RunSession([]*SessionFile{{Path: "f", Data: []byte("hello")}}, 0, "me")
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("session could not send, yet did not fail")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("session still waiting on the server after failing to send")
+	}
+}
@@ -0,0 +1,123 @@
+package grpc
+
+import (
+	"fmt"
+	"hash"
+	"io"
+
+	"github.com/devops-filetransfer/filetransfer/client/sparse"
+)
+
+// source hands out the contents of a file chunk by chunk,
+// following its segments: data is read from r, while each
+// hole comes out whole, as a count of zero bytes.
+type source struct {
+	r    io.ReaderAt
+	segs []sparse.Segment
+	i    int
+	off  int64
+}
+
+func newSource(r io.ReaderAt, segs []sparse.Segment) *source {
+	s := &source{r: r, segs: segs}
+	if len(segs) > 0 {
+		s.off = segs[0].Offset
+	}
+
+	return s
+}
+
+// next returns up to max bytes of data, or else a hole.
+func (s *source) next(max int64) (data []byte, hole int64, err error) {
+	if s.i >= len(s.segs) {
+		return nil, 0, io.EOF
+	}
+
+	seg := s.segs[s.i]
+	end := seg.Offset + seg.Length
+
+	if seg.Hole {
+		s.advance()
+		return nil, seg.Length, nil
+	}
+
+	n := end - s.off
+	if n > max {
+		n = max
+	}
+
+	// each chunk gets its own buffer, as the caller may
+	// keep it around until acked, for retransmits.
+	data = make([]byte, n)
+	if n > 0 {
+		if _, err := s.r.ReadAt(data, s.off); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	s.off += n
+	if s.off >= end {
+		s.advance()
+	}
+
+	return data, 0, nil
+}
+
+// skip passes over the first n bytes, to resume a transfer the
+// server has that much of, hashing them into h as they would have
+// been sent. n must not end within a hole, as no chunk does.
+func (s *source) skip(n int64, h hash.Hash) error {
+	for ; n > 0; s.advance() {
+		if s.i >= len(s.segs) {
+			return io.ErrUnexpectedEOF
+		}
+
+		seg := s.segs[s.i]
+		m := seg.Offset + seg.Length - s.off
+		if m > n {
+			if seg.Hole {
+				return fmt.Errorf("cannot resume %v bytes into a hole of %v", n, seg.Length)
+			}
+			m = n
+		}
+
+		if seg.Hole {
+			sparse.HashZeros(h, m)
+		} else if _, err := io.Copy(h, io.NewSectionReader(s.r, s.off, m)); err != nil {
+			return err
+		}
+		n -= m
+
+		if s.off += m; s.off < seg.Offset+seg.Length {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (s *source) advance() {
+	s.i++
+	if s.i < len(s.segs) {
+		s.off = s.segs[s.i].Offset
+	}
+}
+
+// inOrder lets a source read from a stream, such as an Encrypter,
+// which it can so long as it reads in order, as it does: chunks
+// that must be resent are kept, not read again.
+type inOrder struct {
+	r   io.Reader
+	off int64
+}
+
+func (o *inOrder) ReadAt(p []byte, off int64) (int, error) {
+	if off != o.off {
+		return 0, fmt.Errorf("read at offset %v, but the stream is at %v", off, o.off)
+	}
+
+	n, err := io.ReadFull(o.r, p)
+	o.off += int64(n)
+
+	return n, err
+}
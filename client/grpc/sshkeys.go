@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"fmt"
+	"log/slog"
+
+	"golang.org/x/net/context"
+
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+)
+
+// RunAddSSHKey asks the server to let user log in over SSH
+// with the public key in authorizedKey.
+func (c *client) RunAddSSHKey(user string, authorizedKey []byte, myID string) (*pb.SSHKey, error) {
+	k, err := c.peerClient.AddSSHKey(context.Background(), &pb.SSHKey{User: user, AuthorizedKey: string(authorizedKey)})
+	if err != nil {
+		return nil, fmt.Errorf("key for '%s' could not be added: %v", user, err)
+	}
+
+	slog.Info("added SSH key", "client", myID, "user", k.User, "type", k.Type, "fingerprint", k.Fingerprint)
+
+	return k, nil
+}
+
+// RunListSSHKeys returns the SSH keys of user, or of everyone if
+// user is "".
+func (c *client) RunListSSHKeys(user string) ([]*pb.SSHKey, error) {
+	list, err := c.peerClient.ListSSHKeys(context.Background(), &pb.SSHKeyQuery{User: user})
+	if err != nil {
+		return nil, fmt.Errorf("keys could not be listed: %v", err)
+	}
+
+	return list.Keys, nil
+}
+
+// RunRevokeSSHKey asks the server to stop user logging in with
+// the key with the given fingerprint.
+func (c *client) RunRevokeSSHKey(user, fingerprint string, myID string) error {
+	k, err := c.peerClient.RevokeSSHKey(context.Background(), &pb.SSHKey{User: user, Fingerprint: fingerprint})
+	if err != nil {
+		return fmt.Errorf("key %s for '%s' could not be revoked: %v", fingerprint, user, err)
+	}
+
+	slog.Info("revoked SSH key", "client", myID, "user", k.User, "type", k.Type, "fingerprint", k.Fingerprint)
+
+	return nil
+}
@@ -0,0 +1,420 @@
+package grpc
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/blake2b"
+
+	"github.com/devops-filetransfer/filetransfer/client/attr"
+	"github.com/devops-filetransfer/filetransfer/client/e2e"
+	"github.com/devops-filetransfer/filetransfer/client/fault"
+	"github.com/devops-filetransfer/filetransfer/client/logging"
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+	"github.com/devops-filetransfer/filetransfer/client/sparse"
+	"github.com/devops-filetransfer/filetransfer/client/tracing"
+)
+
+// transferWindow is how many chunks we keep in flight,
+// unacknowledged, during a TransferFile call.
+const transferWindow = 16
+
+// maxRetransmits bounds how often we resend any single
+// chunk after the server naks it.
+const maxRetransmits = 3
+
+// Progress is called as the server confirms chunks
+// of a file: verified bytes so far, out of total.
+type Progress func(path string, verified, total int64)
+
+// RunTransferFile sends data to the server under the name path,
+// like RunSendFile, but over the bidirectional TransferFile
+// call: the server acks or naks each chunk as it verifies it.
+// Naked chunks are retransmitted, a fatal error from the server
+// stops the transfer right away, and progress (if not nil) is
+// told about each verified chunk.
+func (c *client) RunTransferFile(path string, data []byte, initialChunkSize int, isBcastSet bool, myID string, progress Progress) error {
+	data, err := c.seal(data)
+	if err != nil {
+		return err
+	}
+	segs := []sparse.Segment{{Offset: 0, Length: int64(len(data))}}
+
+	up := &upload{
+		path:      path,
+		total:     int64(len(data)),
+		open:      func() (*source, error) { return newSource(bytes.NewReader(data), segs), nil },
+		resumable: true,
+	}
+
+	return c.runTransfer(logging.NewTransfer(context.Background()), up, initialChunkSize, isBcastSet, myID, progress)
+}
+
+// PutOptions tune RunPutFile.
+type PutOptions struct {
+	// WithXattrs also sends extended attributes, and so ACLs.
+	WithXattrs bool
+
+	// Sparse sends the holes in a sparse file as
+	// holes, rather than reading and sending zeros.
+	Sparse bool
+
+	// ChunkSize is the initial chunk size.
+	ChunkSize int
+
+	Progress Progress
+}
+
+// RunPutFile sends the local file to the server, to be stored as
+// remote, along with its metadata. It works like RunTransferFile,
+// reading the file as it goes rather than all at once. An end-to-end
+// encrypted file is read twice: once for the checksum that goes
+// in its encrypted header, then again to encrypt and send it. As
+// each try encrypts it afresh, under a new key, a try cut short is
+// not resumed, but started over.
+func (c *client) RunPutFile(local, remote string, opts *PutOptions, myID string) (err error) {
+	ctx, span := tracing.Start(logging.NewTransfer(context.Background()), "put", attribute.String("path", remote))
+	defer func() { tracing.End(span, err) }()
+
+	a, err := attr.FromFile(local, opts.WithXattrs)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("'%s' is not a regular file", local)
+	}
+
+	size := fi.Size()
+	segs := []sparse.Segment{{Offset: 0, Length: size}}
+	up := &upload{
+		path:      remote,
+		total:     size,
+		attr:      a,
+		open:      func() (*source, error) { return newSource(f, segs), nil },
+		resumable: true,
+	}
+
+	if len(c.recipients) > 0 {
+		// holes are encrypted like any other data, rather than
+		// giving away where the zeros are.
+		encrypt, err := c.encrypter(ctx, f, size)
+		if err != nil {
+			return err
+		}
+		enc, err := encrypt()
+		if err != nil {
+			return err
+		}
+		up.total = enc.Size()
+		up.open = func() (*source, error) {
+			if enc == nil {
+				if enc, err = encrypt(); err != nil {
+					return nil, err
+				}
+			}
+			src := newSource(&inOrder{r: enc}, []sparse.Segment{{Offset: 0, Length: enc.Size()}})
+			enc = nil
+			return src, nil
+		}
+		up.resumable = false
+	} else if opts.Sparse {
+		segs, err = sparse.Map(f, size)
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.runTransfer(ctx, up, opts.ChunkSize, false, myID, opts.Progress)
+}
+
+// encrypter checksums the size bytes of f, and returns a func that
+// makes Encrypters of them, to our recipients.
+func (c *client) encrypter(ctx context.Context, f *os.File, size int64) (func() (*e2e.Encrypter, error), error) {
+	h, err := blake2b.New(nil)
+	if err != nil {
+		return nil, err
+	}
+	_, span := tracing.Start(ctx, "hash", attribute.Int64("bytes", size))
+	_, err = io.Copy(h, io.NewSectionReader(f, 0, size))
+	tracing.End(span, err)
+	if err != nil {
+		return nil, err
+	}
+	sum := h.Sum(nil)
+
+	return func() (*e2e.Encrypter, error) {
+		return e2e.NewEncrypter(io.NewSectionReader(f, 0, size), size, sum, c.recipients)
+	}, nil
+}
+
+// upload is a file for runTransfer to send.
+type upload struct {
+	path  string
+	total int64 // its logical bytes
+
+	// attr, if not nil, rides along on the first chunk.
+	attr *pb.FileAttr
+
+	// open returns the file's contents from the start; each try
+	// at sending it opens them afresh.
+	open func() (*source, error)
+
+	// resumable says if a try cut short may carry on from where
+	// the server got to, as it may if open gives the same bytes
+	// every time.
+	resumable bool
+}
+
+// runTransfer sends up, in the transfer on ctx, trying again as our
+// RetryPolicy says if it fails in a way that may not last.
+func (c *client) runTransfer(ctx context.Context, up *upload, initialChunkSize int, isBcastSet bool, myID string, progress Progress) (err error) {
+	ctx, span := tracing.Start(ctx, "transfer", attribute.String("path", up.path), attribute.Int64("bytes", up.total))
+	defer func() { tracing.End(span, err) }()
+
+	l := logger(ctx, myID)
+	defer func() {
+		if err != nil {
+			l.Warn("transfer failed", "path", up.path, "err", err)
+		}
+	}()
+
+	var sizer *ChunkSizer
+
+	return c.withRetries(ctx, up.path, l, func(ctx context.Context, last error) error {
+		maxChunk, err := c.Negotiate(ctx)
+		if err != nil {
+			return err
+		}
+		if sizer == nil {
+			sizer = NewChunkSizer(initialChunkSize, maxChunk)
+		}
+
+		return c.tryTransfer(ctx, up, sizer, up.resumable && resumes(last), isBcastSet, l, progress)
+	})
+}
+
+// tryTransfer makes one try at sending up, over a TransferFile call
+// of its own; to resume, it first asks the server how far the last
+// try got, and carries on from there.
+func (c *client) tryTransfer(ctx context.Context, up *upload, sizer *ChunkSizer, resume bool, isBcastSet bool, l *slog.Logger, progress Progress) error {
+	path, total, a := up.path, up.total, up.attr
+
+	startOfTry := time.Now().UTC()
+	startNano := uint64(startOfTry.UnixNano())
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	src, err := up.open()
+	if err != nil {
+		return err
+	}
+
+	c.startNewFile()
+
+	stream, err := c.peerClient.TransferFile(ctx)
+	if err != nil {
+		return serverErr(path, err, "'%s' could not start TransferFile", path)
+	}
+
+	// acks is closed once the ack stream ends, after any acks
+	// received before then, so the final ack is never passed over
+	// for the end of the stream; recvErr says why it ended.
+	acks := make(chan *pb.ChunkAck, transferWindow)
+	var recvErr error
+	go func() {
+		defer close(acks)
+		for {
+			ack, err := stream.Recv()
+			if err != nil {
+				recvErr = err
+				return
+			}
+			select {
+			case acks <- ack:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	var nextByte int64
+	allSent := false
+
+	// inflight holds the chunks sent but not yet acked, in order.
+	var inflight []*pb.BigFileChunk
+	retransmits := make(map[int64]int)
+
+	lastAckTime := time.Now()
+	var lastVerified int64
+
+	// ended says why the ack stream ended before the final ack.
+	ended := func() error {
+		if recvErr == io.EOF {
+			return fault.New(fault.Protocol, path, "'%s' TransferFile failed after %v of %v bytes were verified: server ended the stream without a final ack", path, lastVerified, total)
+		}
+		return serverErr(path, recvErr, "'%s' TransferFile failed after %v of %v bytes were verified", path, lastVerified, total)
+	}
+
+	send := func(nk *pb.BigFileChunk) (err error) {
+		_, sending := tracing.Start(ctx, "send", attribute.Int64("chunk", nk.ChunkNumber))
+		defer func() { tracing.End(sending, err) }()
+
+		nk.SendTime = uint64(time.Now().UnixNano())
+		if err := stream.Send(nk); err != nil {
+			if err == io.EOF {
+				// the server ended the call; the reason
+				// comes to us through the ack stream.
+				return nil
+			}
+			return serverErr(path, err, "'%s' sending chunk %v", path, nk.ChunkNumber)
+		}
+		return nil
+	}
+
+	if resume {
+		if err := stream.Send(&pb.BigFileChunk{Filepath: path, Resume: true}); err != nil && err != io.EOF {
+			return serverErr(path, err, "'%s' asking to resume", path)
+		}
+		ack, ok := <-acks
+		if !ok {
+			return ended()
+		}
+		if ack.ChunkNumber >= 0 {
+			if err := src.skip(ack.BytesVerified, c.hasher); err != nil {
+				return fmt.Errorf("'%s' resuming at offset %v: %v", path, ack.BytesVerified, err)
+			}
+			c.nextChunk = ack.ChunkNumber + 1
+			nextByte = ack.BytesVerified
+			lastVerified = ack.BytesVerified
+		}
+		l.Info("resuming transfer", "path", path, "chunk", c.nextChunk, "bytes", nextByte)
+	}
+
+	for {
+		// keep the window full.
+		for !allSent && len(inflight) < transferWindow {
+			chunk, hole, err := src.next(int64(sizer.Next()))
+			if err != nil {
+				return fmt.Errorf("'%s' reading chunk %v at offset %v: %v", path, c.nextChunk, nextByte, err)
+			}
+			nextByte += int64(len(chunk)) + hole
+
+			nk := &pb.BigFileChunk{
+				IsBcastSet:            isBcastSet,
+				Filepath:              path,
+				SizeInBytes:           int64(len(chunk)),
+				HoleSize:              hole,
+				OriginalStartSendTime: startNano,
+				Data:                  chunk,
+				ChunkNumber:           c.nextChunk,
+				IsLastChunk:           nextByte == total,
+			}
+			if nk.ChunkNumber == 0 {
+				nk.Attr = a
+			}
+			c.nextChunk++
+
+			// checksums
+			_, hashing := tracing.Start(ctx, "hash", attribute.Int64("chunk", nk.ChunkNumber))
+			c.hasher.Write(chunk)
+			sparse.HashZeros(c.hasher, hole)
+			nk.Blake2B = blake2bOfBytes(chunk)
+			nk.Blake2BCumulative = []byte(c.hasher.Sum(nil))
+			hashing.End()
+			if nk.IsLastChunk {
+				if err := c.sign(nk, total); err != nil {
+					return err
+				}
+			}
+
+			if err := send(nk); err != nil {
+				return err
+			}
+			inflight = append(inflight, nk)
+			allSent = nk.IsLastChunk
+		}
+
+		ack, ok := <-acks
+		if !ok {
+			return ended()
+		}
+
+		switch ack.Status {
+		case pb.AckStatus_FATAL:
+			// the status the call ends with says what kind of
+			// failure it was.
+			for range acks {
+			}
+			if recvErr == io.EOF {
+				return fmt.Errorf("'%s' server aborted the transfer at chunk %v, after %v of %v bytes were verified: %s", path, ack.ChunkNumber, ack.BytesVerified, total, ack.Err)
+			}
+			return serverErr(path, recvErr, "'%s' server aborted the transfer at chunk %v, after %v of %v bytes were verified", path, ack.ChunkNumber, ack.BytesVerified, total)
+
+		case pb.AckStatus_NAK:
+			retransmits[ack.ChunkNumber]++
+			if retransmits[ack.ChunkNumber] > maxRetransmits {
+				return fmt.Errorf("'%s' chunk %v was rejected %v times; giving up. Last reason: %s", path, ack.ChunkNumber, maxRetransmits+1, ack.Err)
+			}
+			l.Warn("chunk naked; resending from there", "path", path, "chunk", ack.ChunkNumber, "reason", ack.Err)
+
+			// go-back-N: resend the naked chunk and all after it.
+			for _, nk := range inflight {
+				if nk.ChunkNumber < ack.ChunkNumber {
+					continue
+				}
+				if err := send(nk); err != nil {
+					return err
+				}
+			}
+			continue
+
+		case pb.AckStatus_ACK:
+			for len(inflight) > 0 && inflight[0].ChunkNumber <= ack.ChunkNumber {
+				inflight = inflight[1:]
+			}
+
+			now := time.Now()
+			sizer.Observe(int(ack.BytesVerified-lastVerified), now.Sub(lastAckTime))
+			lastAckTime = now
+			lastVerified = ack.BytesVerified
+
+			if progress != nil {
+				progress(path, ack.BytesVerified, total)
+			}
+		}
+
+		if ack.IsFinal {
+			_ = stream.CloseSend()
+
+			compared := bytes.Compare(ack.WholeFileBlake2B, []byte(c.hasher.Sum(nil)))
+			l.Info("sent file", "path", path, "bytes", total, "verified", ack.BytesVerified, "blake2b", fmt.Sprintf("%x", ack.WholeFileBlake2B), "checksum_matches", compared == 0, "elapsed", time.Since(startOfTry))
+
+			if compared != 0 {
+				return fault.New(fault.Checksum, path, "'%s' whole file checksum mismatch: server has '%x', we sent '%x'", path, ack.WholeFileBlake2B, c.hasher.Sum(nil))
+			}
+			if ack.BytesVerified != total {
+				return fault.New(fault.Size, path, "'%s' size mismatch: server verified %v bytes, we sent %v", path, ack.BytesVerified, total)
+			}
+			return nil
+		}
+	}
+}
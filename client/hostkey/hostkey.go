@@ -0,0 +1,268 @@
+// Package hostkey decides whether to trust the host key an SSH
+// server presents: one pinned by fingerprint, one recorded in our
+// known-hosts file, or, on first contact and only when asked, one
+// we have never seen. Whatever it trusts without being told to by
+// name, it writes down in an audit log.
+package hostkey
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// KnownHostsFile is our known-hosts file, in OpenSSH's format,
+// under the client's SSH directory.
+const KnownHostsFile = "known_hosts"
+
+// AuditSuffix names the audit log kept beside the known-hosts
+// file, and OldSuffix the copy of the file as it was before we
+// last replaced a key in it.
+const (
+	AuditSuffix = ".log"
+	OldSuffix   = ".old"
+)
+
+// Policy says which host keys to trust.
+type Policy struct {
+	// KnownHostsPath is the known-hosts file.
+	KnownHostsPath string
+
+	// Pin, when set, is the SHA256 fingerprint of the only key
+	// we accept; the known-hosts file is not consulted.
+	Pin string
+
+	// TOFU trusts a host we have no key for, the first time we
+	// meet it, and records its key.
+	TOFU bool
+
+	// Rotate, when set, is the SHA256 fingerprint of a new key
+	// to accept in place of the one we know for the host, after
+	// the server's administrator has rotated its host key.
+	Rotate string
+
+	mu sync.Mutex
+}
+
+// Event is one record in the audit log.
+type Event struct {
+	Time        time.Time `json:"time"`
+	Host        string    `json:"host"`
+	Event       string    `json:"event"`
+	Fingerprint string    `json:"fingerprint"`
+	Previous    []string  `json:"previous,omitempty"`
+}
+
+// The events we record.
+const (
+	TrustedOnFirstUse = "trusted-on-first-use"
+	Rotated           = "rotated"
+)
+
+// MismatchError is returned when a host presents a key other
+// than the one we expected of it.
+type MismatchError struct {
+	Host     string
+	Expected []string // fingerprints
+	Actual   string
+	Source   string // where we got Expected from
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("host key mismatch for '%s': expected %s (%s), got %s; the host key has changed, or someone is in the middle",
+		e.Host, strings.Join(e.Expected, " or "), e.Source, e.Actual)
+}
+
+// UnknownHostError is returned for a host we have no key for,
+// when we are not to trust it on first use.
+type UnknownHostError struct {
+	Host   string
+	Actual string
+}
+
+func (e *UnknownHostError) Error() string {
+	return fmt.Sprintf("unknown host '%s' presents key %s; check that with the server's administrator, then pin it with -host_key %s, or trust it on first use with -new",
+		e.Host, e.Actual, e.Actual)
+}
+
+// Check returns nil if we trust key for host, a "host:port"
+// address, recording it first if that is the policy.
+func (p *Policy) Check(host string, key ssh.PublicKey) error {
+	actual := ssh.FingerprintSHA256(key)
+
+	if p.Pin != "" {
+		if actual != p.Pin {
+			return &MismatchError{Host: host, Expected: []string{p.Pin}, Actual: actual, Source: "pinned by -host_key"}
+		}
+		return nil
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	known, err := p.known(host)
+	if err != nil {
+		return err
+	}
+
+	for _, k := range known {
+		if bytes.Equal(k.Marshal(), key.Marshal()) {
+			return nil
+		}
+	}
+
+	if len(known) == 0 {
+		if !p.TOFU {
+			return &UnknownHostError{Host: host, Actual: actual}
+		}
+		if err := p.replace(host, key); err != nil {
+			return err
+		}
+		return p.audit(&Event{Host: host, Event: TrustedOnFirstUse, Fingerprint: actual})
+	}
+
+	var expected []string
+	for _, k := range known {
+		expected = append(expected, ssh.FingerprintSHA256(k))
+	}
+
+	if p.Rotate == "" || actual != p.Rotate {
+		return &MismatchError{Host: host, Expected: expected, Actual: actual, Source: "from " + p.KnownHostsPath}
+	}
+
+	if err := p.replace(host, key); err != nil {
+		return err
+	}
+
+	return p.audit(&Event{Host: host, Event: Rotated, Fingerprint: actual, Previous: expected})
+}
+
+// known returns the keys our known-hosts file has for host.
+func (p *Policy) known(host string) ([]ssh.PublicKey, error) {
+	if _, err := os.Stat(p.KnownHostsPath); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	cb, err := knownhosts.New(p.KnownHostsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// ask about a key nobody has; the error lists the ones we know.
+	err = cb(host, &net.TCPAddr{}, noKey{})
+	var keyErr *knownhosts.KeyError
+	if !errors.As(err, &keyErr) {
+		return nil, fmt.Errorf("known-hosts '%s': %v", p.KnownHostsPath, err)
+	}
+
+	var keys []ssh.PublicKey
+	for _, k := range keyErr.Want {
+		keys = append(keys, k.Key)
+	}
+
+	return keys, nil
+}
+
+// replace makes key the only one in the known-hosts file for
+// host, keeping the file as it was in OldSuffix.
+func (p *Policy) replace(host string, key ssh.PublicKey) error {
+	norm := knownhosts.Normalize(host)
+
+	var kept []string
+	old, err := os.ReadFile(p.KnownHostsPath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	sc := bufio.NewScanner(bytes.NewReader(old))
+	for sc.Scan() {
+		if !linesHost(sc.Text(), norm) {
+			kept = append(kept, sc.Text())
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	kept = append(kept, knownhosts.Line([]string{norm}, key))
+
+	if err := os.MkdirAll(filepath.Dir(p.KnownHostsPath), 0700); err != nil {
+		return err
+	}
+	if len(old) > 0 {
+		if err := os.WriteFile(p.KnownHostsPath+OldSuffix, old, 0600); err != nil {
+			return err
+		}
+	}
+
+	tmp := p.KnownHostsPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strings.Join(kept, "\n")+"\n"), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, p.KnownHostsPath)
+}
+
+// linesHost says whether the known-hosts line is one for norm,
+// a host as written by knownhosts.Normalize.
+func linesHost(line, norm string) bool {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || strings.HasPrefix(fields[0], "#") {
+		return false
+	}
+	hosts := fields[0]
+	if strings.HasPrefix(hosts, "@") {
+		// a marker, such as @revoked; leave it be.
+		return false
+	}
+
+	for _, h := range strings.Split(hosts, ",") {
+		if h == norm {
+			return true
+		}
+	}
+
+	return false
+}
+
+// audit appends e to the audit log, and logs it.
+func (p *Policy) audit(e *Event) error {
+	e.Time = time.Now().UTC()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(p.KnownHostsPath+AuditSuffix, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("hostkey: '%s' key %s %s; recorded in '%s'", e.Host, e.Fingerprint, e.Event, p.KnownHostsPath+AuditSuffix)
+
+	return nil
+}
+
+// noKey is a key no host has.
+type noKey struct{}
+
+func (noKey) Type() string                                 { return "none" }
+func (noKey) Marshal() []byte                              { return []byte("none") }
+func (noKey) Verify(data []byte, sig *ssh.Signature) error { return errors.New("no key") }
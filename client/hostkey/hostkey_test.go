@@ -0,0 +1,95 @@
+package hostkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newKey(t *testing.T) ssh.PublicKey {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+func TestPolicy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), KnownHostsFile)
+	host := "files.example.org:10000"
+	first, second, mallory := newKey(t), newKey(t), newKey(t)
+
+	p := &Policy{KnownHostsPath: path}
+	var unknown *UnknownHostError
+	if err := p.Check(host, first); !errors.As(err, &unknown) {
+		t.Fatalf("unknown host let in: %v", err)
+	}
+
+	p.TOFU = true
+	if err := p.Check(host, first); err != nil {
+		t.Fatal(err)
+	}
+	p.TOFU = false
+	if err := p.Check(host, first); err != nil {
+		t.Fatalf("recorded key not trusted: %v", err)
+	}
+	if err := p.Check("other.example.org:10000", first); err == nil {
+		t.Fatal("key trusted for another host")
+	}
+
+	// trusting on first use does not let a known host change its key.
+	p.TOFU = true
+	err := p.Check(host, mallory)
+	var mismatch *MismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("changed key let in: %v", err)
+	}
+	for _, fp := range []string{ssh.FingerprintSHA256(first), ssh.FingerprintSHA256(mallory)} {
+		if !strings.Contains(err.Error(), fp) {
+			t.Fatalf("mismatch error does not name %s: %v", fp, err)
+		}
+	}
+
+	p.Rotate = ssh.FingerprintSHA256(second)
+	if err := p.Check(host, mallory); err == nil {
+		t.Fatal("rotated to a key other than the one named")
+	}
+	if err := p.Check(host, second); err != nil {
+		t.Fatal(err)
+	}
+	p.Rotate = ""
+	if err := p.Check(host, first); err == nil {
+		t.Fatal("old key still trusted after rotation")
+	}
+	if _, err := os.Stat(path + OldSuffix); err != nil {
+		t.Fatalf("no copy of the known-hosts file from before rotation: %v", err)
+	}
+
+	audit, err := os.ReadFile(path + AuditSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(audit)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], TrustedOnFirstUse) || !strings.Contains(lines[1], Rotated) {
+		t.Fatalf("audit log:\n%s", audit)
+	}
+
+	pinned := &Policy{KnownHostsPath: path, Pin: ssh.FingerprintSHA256(first)}
+	if err := pinned.Check(host, first); err != nil {
+		t.Fatalf("pinned key refused: %v", err)
+	}
+	if err := pinned.Check(host, second); !errors.As(err, &mismatch) || !strings.Contains(err.Error(), "-host_key") {
+		t.Fatalf("pin not enforced: %v", err)
+	}
+}
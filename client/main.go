@@ -9,12 +9,16 @@ import (
 	"runtime/pprof"
 	"time"
 
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 
 	"github.com/devops-filetransfer/filetransfer/client/config"
 	_grpc "github.com/devops-filetransfer/filetransfer/client/grpc"
+	"github.com/devops-filetransfer/filetransfer/client/logging"
 	"github.com/devops-filetransfer/filetransfer/client/print"
+	"github.com/devops-filetransfer/filetransfer/client/settings"
+	"github.com/devops-filetransfer/filetransfer/client/tracing"
 )
 
 func SequentialPayload(n int64) []byte {
@@ -36,18 +40,104 @@ func SequentialPayload(n int64) []byte {
 
 const ProgramName = "client"
 
-func main() {
+// tenthsProgress reports each 10% of a file that the server has verified.
+func tenthsProgress() _grpc.Progress {
+	last := int64(-1)
+
+	return func(path string, verified, total int64) {
+		if total == 0 {
+			return
+		}
+
+		tenth := verified * 10 / total
+		if tenth != last {
+			last = tenth
+			print.P("'%s': %v%% verified by the server (%v of %v bytes)", path, tenth*10, verified, total)
+		}
+	}
+}
+
+// EnvPrefix starts the names of the environment variables that
+// set our flags, as FILETRANSFER_CLIENT_HOST sets -host.
+const EnvPrefix = "FILETRANSFER_CLIENT_"
+
+// loadConfig parses our flags from args, taking those not given
+// from the environment, and then from our config file and the
+// -profile picked in it.
+func loadConfig(args []string) (*config.ClientConfig, *flag.FlagSet, *settings.Effective, error) {
 	myflags := flag.NewFlagSet(ProgramName, flag.ContinueOnError)
 
 	cfg := &config.ClientConfig{}
 	cfg.DefineFlags(myflags)
 	cfg.SkipEncryption = true
 
-	err := myflags.Parse(os.Args[1:])
+	src := &settings.Sources{EnvPrefix: EnvPrefix, Secrets: []string{"token"}}
+	src.DefineFlags(myflags, settings.DefaultPath(ProgramName), true)
+
+	if err := myflags.Parse(args); err != nil {
+		return nil, nil, nil, err
+	}
+
+	eff, err := src.Load(myflags)
 	if err != nil {
-		log.Fatalf("%s command line flag error: '%s'", ProgramName, err)
+		return nil, nil, nil, err
 	}
 
+	return cfg, myflags, eff, nil
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cert" {
+		if err := runCertCommand(os.Args[2:]); err != nil {
+			log.Fatalf("%s cert: %s", ProgramName, err)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "ssh" {
+		if err := runSSHCommand(os.Args[2:]); err != nil {
+			log.Fatalf("%s ssh: %s", ProgramName, err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := runVerifyCommand(os.Args[2:]); err != nil {
+			log.Fatalf("%s verify: %s", ProgramName, err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "e2e" {
+		if err := runE2ECommand(os.Args[2:]); err != nil {
+			log.Fatalf("%s e2e: %s", ProgramName, err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfigCommand(os.Args[2:]); err != nil {
+			log.Fatalf("%s config: %s", ProgramName, err)
+		}
+		return
+	}
+
+	cfg, myflags, _, err := loadConfig(os.Args[1:])
+	if err != nil {
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
+	}
+	stopTracing, err := tracing.Setup(cfg.Trace, ProgramName)
+	if err != nil {
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
+	}
+	defer func() {
+		if err := stopTracing(context.Background()); err != nil {
+			log.Printf("%s could not send the last traces: '%s'", ProgramName, err)
+		}
+	}()
+
 	if cfg.CpuProfilePath != "" {
 		f, err := os.Create(cfg.CpuProfilePath)
 		if err != nil {
@@ -59,7 +149,7 @@ func main() {
 
 	err = cfg.ValidateConfig()
 	if err != nil {
-		log.Fatalf("%s command line flag error: '%s'", ProgramName, err)
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
 	}
 
 	var opts []grpc.DialOption
@@ -74,6 +164,11 @@ func main() {
 		cfg.SetupSSH(&opts)
 	}
 
+	cfg.SetupToken(&opts)
+	cfg.SetupMsgSize(&opts)
+	cfg.SetupMetrics(&opts)
+	cfg.SetupTracing(&opts)
+
 	serverAddr := fmt.Sprintf("%v:%v", cfg.ServerHost, cfg.ServerPort)
 
 	conn, err := grpc.Dial(serverAddr, opts...)
@@ -82,9 +177,22 @@ func main() {
 	}
 	defer conn.Close()
 
-	// SendFile
-	c := _grpc.NewClient(conn)
 	myID := "test-client-0"
+
+	if args := myflags.Args(); len(args) > 0 {
+		err = runCommand(conn, cfg, args, myID)
+		if err != nil {
+			_ = stopTracing(context.Background())
+			log.Fatalf("%s %s: %s", ProgramName, args[0], err)
+		}
+		return
+	}
+
+	// SendFile
+	c := _grpc.NewClient(conn, cfg.MaxMsgSize)
+	c.EncryptTo(cfg.Recipients)
+	c.SignWith(cfg.SignKey)
+	c.RetryWith(retryPolicy(cfg))
 	data := []byte("hello peer, it is nice to meet you!!")
 	err = c.RunSendFile("file1", data, 3, false, myID)
 	print.PanicOn(err)
@@ -93,13 +201,29 @@ func main() {
 	err = c.RunSendFile("file2", data2, 3, false, myID)
 	print.PanicOn(err)
 
+	// many small files over one stream
+	if cfg.SessionFiles > 0 {
+		files := make([]*_grpc.SessionFile, cfg.SessionFiles)
+		for i := range files {
+			files[i] = &_grpc.SessionFile{
+				Path:     fmt.Sprintf("small/file%05d", i),
+				Data:     []byte(fmt.Sprintf("small file number %v", i)),
+				Metadata: map[string]string{"from": myID},
+			}
+		}
+
+		t0 := time.Now()
+		_, err = c.RunSession(files, cfg.ChunkSize, myID)
+		print.PanicOn(err)
+		print.P("c: sent %v small files over one session in %v", len(files), time.Since(t0))
+	}
+
 	//n := 1 << 29 // test with 512MB file. Works with up to 1MB or 2MB chunks.
 	n := cfg.PayloadSizeMegaBytes * 1 << 20
 
 	print.P("generating test data of size %v bytes", n)
 	data3 := SequentialPayload(int64(n))
-	//chunkSz := 1 << 22 // 4MB // GRPC will fail with EOF.
-	chunkSz := 1 << 20
+	chunkSz := cfg.ChunkSize
 
 	c2done := make(chan struct{})
 
@@ -111,7 +235,7 @@ func main() {
 			time.Sleep(10 * time.Millisecond)
 			print.P("after 10msec of sleep, comencing bigfile3...")
 
-			c2 := _grpc.NewClient(conn)
+			c2 := _grpc.NewClient(conn, cfg.MaxMsgSize)
 			t0 := time.Now()
 
 			err = c2.RunSendFile("bigfile3", data3, chunkSz, false, myID)
@@ -125,7 +249,7 @@ func main() {
 	}()
 
 	t0 := time.Now()
-	err = c.RunSendFile("bigfile4", data3, chunkSz, false, myID)
+	err = c.RunTransferFile("bigfile4", data3, chunkSz, false, myID, tenthsProgress())
 
 	t1 := time.Now()
 	print.PanicOn(err)
@@ -0,0 +1,146 @@
+package metrics
+
+import (
+	"io"
+	"net"
+	"path"
+	"sync"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// chunkOf returns the chunk of file data m carries, if any.
+func chunkOf(m interface{}) *pb.BigFileChunk {
+	switch m := m.(type) {
+	case *pb.BigFileChunk:
+		return m
+	case *pb.SessionMsg:
+		return m.Chunk
+	}
+
+	return nil
+}
+
+func observe(method string, start time.Time, err error) {
+	rpcDuration.WithLabelValues(path.Base(method), status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
+
+// UnaryInterceptor times unary calls.
+func UnaryInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		observe(method, start, err)
+
+		return err
+	}
+}
+
+// StreamInterceptor times streams, keeps count of those open, and
+// counts the chunks that go each way on them. A stream is over
+// once we have its last message or its error, or it is cancelled.
+func StreamInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		start := time.Now()
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			observe(method, start, err)
+			return nil, err
+		}
+
+		s := &countedStream{
+			ClientStream: cs,
+			desc:         desc,
+			method:       method,
+			start:        start,
+			active:       activeStreams.WithLabelValues(path.Base(method)),
+		}
+		s.active.Inc()
+		go func() {
+			<-cs.Context().Done()
+			s.closed.Do(s.active.Dec)
+		}()
+
+		return s, nil
+	}
+}
+
+type countedStream struct {
+	grpc.ClientStream
+	desc   *grpc.StreamDesc
+	method string
+	start  time.Time
+	active prometheus.Gauge
+
+	closed, timed sync.Once
+}
+
+// done ends the stream with err.
+func (s *countedStream) done(err error) {
+	s.closed.Do(s.active.Dec)
+	s.timed.Do(func() { observe(s.method, s.start, err) })
+}
+
+func (s *countedStream) SendMsg(m interface{}) error {
+	if err := s.ClientStream.SendMsg(m); err != nil {
+		return err
+	}
+	if nk := chunkOf(m); nk != nil {
+		chunksSent.Inc()
+		bytesSent.Add(float64(len(nk.Data)))
+	}
+
+	return nil
+}
+
+func (s *countedStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	switch {
+	case err == io.EOF:
+		s.done(nil)
+	case err != nil:
+		s.done(err)
+	default:
+		if nk := chunkOf(m); nk != nil {
+			chunksReceived.Inc()
+			bytesReceived.Add(float64(len(nk.Data)))
+		}
+		if !s.desc.ServerStreams {
+			// its one reply is all there is.
+			s.done(nil)
+		}
+	}
+
+	return err
+}
+
+// Handshakes wraps creds, counting the handshakes that fail as
+// failures of transport.
+func Handshakes(creds credentials.TransportCredentials, transport string) credentials.TransportCredentials {
+	return &countedCreds{TransportCredentials: creds, transport: transport}
+}
+
+type countedCreds struct {
+	credentials.TransportCredentials
+	transport string
+}
+
+func (c *countedCreds) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ClientHandshake(ctx, authority, conn)
+	if err != nil {
+		HandshakeFailed(c.transport)
+	}
+
+	return conn, info, err
+}
+
+func (c *countedCreds) Clone() credentials.TransportCredentials {
+	return Handshakes(c.TransportCredentials.Clone(), c.transport)
+}
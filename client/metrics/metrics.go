@@ -0,0 +1,142 @@
+// Package metrics counts what the client does, for Prometheus to
+// scrape in its text format: the bytes and chunks of file data
+// that come and go, checksum failures by the check that failed,
+// the streams open at any one time, how long each RPC takes, and
+// failed TLS and SSH handshakes.
+//
+// The interceptors count the traffic; the code fetching files
+// counts only what they cannot see, such as which check a chunk
+// failed. We only serve the metrics while we run, so they suit
+// long transfers, or a client kept running, best.
+package metrics
+
+import (
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "filetransfer_client"
+
+// The kinds of checksum failure.
+const (
+	// Chunk is a chunk whose data does not match its own checksum.
+	Chunk = "chunk"
+
+	// Size is a chunk whose data is not the size it says.
+	Size = "size"
+
+	// Cumulative is a file whose running checksum went astray.
+	Cumulative = "cumulative"
+)
+
+// The transports whose handshakes can fail.
+const (
+	TLS = "tls"
+	SSH = "ssh"
+)
+
+var (
+	bytesReceived = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_received_total",
+		Help:      "Bytes of file data received in chunks.",
+	})
+	bytesSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "bytes_sent_total",
+		Help:      "Bytes of file data sent in chunks.",
+	})
+	chunksReceived = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chunks_received_total",
+		Help:      "Chunks received.",
+	})
+	chunksSent = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "chunks_sent_total",
+		Help:      "Chunks sent.",
+	})
+	checksumFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "checksum_failures_total",
+		Help:      "Chunks that failed a check, by type: chunk, size or cumulative.",
+	}, []string{"type"})
+	activeStreams = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "active_streams",
+		Help:      "Streaming RPCs in progress, by method.",
+	}, []string{"method"})
+	rpcDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "How long RPCs took, by method and status code; for streams, from start to end.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 4, 10),
+	}, []string{"method", "code"})
+	handshakeFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "handshake_failures_total",
+		Help:      "Connections to the server whose handshake failed, by transport: tls or ssh.",
+	}, []string{"transport"})
+)
+
+// Registry holds our metrics, along with the Go runtime's and
+// the process's.
+var Registry = prometheus.NewRegistry()
+
+func init() {
+	Registry.MustRegister(
+		bytesReceived, bytesSent, chunksReceived, chunksSent,
+		checksumFailures, activeStreams, rpcDuration, handshakeFailures,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	// so that they read zero, rather than not at all, until the first.
+	for _, kind := range []string{Chunk, Size, Cumulative} {
+		checksumFailures.WithLabelValues(kind)
+	}
+	for _, transport := range []string{TLS, SSH} {
+		handshakeFailures.WithLabelValues(transport)
+	}
+}
+
+// ChecksumFailed counts a chunk that failed the check kind.
+func ChecksumFailed(kind string) {
+	checksumFailures.WithLabelValues(kind).Inc()
+}
+
+// HandshakeFailed counts a connection whose transport handshake failed.
+func HandshakeFailed(transport string) {
+	handshakeFailures.WithLabelValues(transport).Inc()
+}
+
+// Handler serves the metrics in Registry.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// Serve serves the metrics at /metrics on addr, in the background.
+// It returns once it is listening, or could not listen.
+func Serve(addr string) (net.Addr, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", Handler())
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := srv.Serve(lis); err != nil {
+			log.Printf("metrics: no longer serving on %v: %v", lis.Addr(), err)
+		}
+	}()
+
+	return lis.Addr(), nil
+}
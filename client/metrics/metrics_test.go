@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	pb "github.com/devops-filetransfer/filetransfer/client/protobuf"
+)
+
+// fakeStream takes what is sent, and hands back one reply.
+type fakeStream struct {
+	grpc.ClientStream
+	ctx     context.Context
+	replied bool
+}
+
+func (s *fakeStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	if s.replied {
+		return io.EOF
+	}
+	s.replied = true
+
+	return nil
+}
+
+func scrape(t *testing.T) string {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	return rec.Body.String()
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	streamer := func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return &fakeStream{ctx: ctx}, nil
+	}
+	desc := &grpc.StreamDesc{ClientStreams: true}
+	cs, err := StreamInterceptor()(ctx, desc, nil, "/protobuf.Peer/SendFile", streamer)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cs.SendMsg(&pb.BigFileChunk{Data: make([]byte, 100)})
+	cs.SendMsg(&pb.BigFileChunk{Data: make([]byte, 23), IsLastChunk: true})
+	if got := scrape(t); !strings.Contains(got, `filetransfer_client_active_streams{method="SendFile"} 1`) {
+		t.Errorf("stream not counted as active:\n%s", got)
+	}
+
+	// the reply to a client stream ends it.
+	if err := cs.RecvMsg(&pb.BigFileAck{}); err != nil {
+		t.Fatal(err)
+	}
+	cancel()
+
+	got := scrape(t)
+	for _, want := range []string{
+		"filetransfer_client_bytes_sent_total 123",
+		"filetransfer_client_chunks_sent_total 2",
+		"filetransfer_client_bytes_received_total 0",
+		`filetransfer_client_active_streams{method="SendFile"} 0`,
+		`filetransfer_client_rpc_duration_seconds_count{code="OK",method="SendFile"} 1`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("missing '%s' in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package pki makes the key and certificate request a client
+// needs to be issued a certificate by the server's local CA.
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+)
+
+// The files we keep in the directory. CAFile is a copy of the
+// server's CA certificate, which we check the server against.
+const (
+	CAFile         = "ca.pem"
+	ClientKeyFile  = "client.key"
+	ClientCSRFile  = "client.csr"
+	ClientCertFile = "client.pem"
+)
+
+// DefaultDir is where we keep our certificates unless told otherwise.
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	return filepath.Join(home, ".filetransfer", "tls")
+}
+
+// Request writes a new client key into dir, along with a
+// request for a certificate naming us as name, and email if
+// that is not empty. It returns the path of the request.
+func Request(dir, name, email string) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl := &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: name},
+	}
+	if email != "" {
+		tmpl.EmailAddresses = []string{email}
+	}
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, tmpl, key)
+	if err != nil {
+		return "", err
+	}
+
+	kder, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ClientKeyFile), pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: kder}), 0600); err != nil {
+		return "", err
+	}
+
+	csrPath := filepath.Join(dir, ClientCSRFile)
+	if err := os.WriteFile(csrPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), 0644); err != nil {
+		return "", err
+	}
+
+	return csrPath, nil
+}
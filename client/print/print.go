@@ -1,6 +1,9 @@
 package print
 
-import "fmt"
+import (
+	"fmt"
+	"log/slog"
+)
 
 func PanicOn(err error) {
 	if err != nil {
@@ -8,8 +11,7 @@ func PanicOn(err error) {
 	}
 }
 
-// P is a shortcut for a call to fmt.Printf that implicitly starts
-// and ends its message with a newline.
+// P logs its message at info level, formatted as by fmt.Printf.
 func P(format string, stuff ...interface{}) {
-	fmt.Printf("\n "+format+"\n", stuff...)
+	slog.Info(fmt.Sprintf(format, stuff...))
 }
@@ -0,0 +1,329 @@
+// Package settings lets a config file, and the environment, give
+// the flags the command line leaves out. The command line wins;
+// then the environment; then the file, where a profile wins over
+// the top level; and a flag keeps its default only when none of
+// them set it.
+package settings
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/BurntSushi/toml"
+)
+
+// The flags we add, which say where the rest come from.
+const (
+	ConfigFlag  = "config"
+	ProfileFlag = "profile"
+)
+
+// ProfilesKey holds the profiles in a config file, by name.
+const ProfilesKey = "profiles"
+
+// Redacted stands in for secrets when we print them.
+const Redacted = "<redacted>"
+
+// DefaultPath is the config file program reads unless told
+// otherwise; it need not exist.
+func DefaultPath(program string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	return filepath.Join(home, ".filetransfer", program+".yaml")
+}
+
+// Sources says where to look for settings, besides the command line.
+type Sources struct {
+	// Path is the config file, YAML or, if it ends in .toml,
+	// TOML; its keys are flag names. Empty for none.
+	Path string
+
+	// Profile picks one of the file's profiles, whose settings
+	// win over those at the file's top level.
+	Profile string
+
+	// EnvPrefix starts the names of the environment variables
+	// we read: -cert_file is read from EnvPrefix+"CERT_FILE".
+	EnvPrefix string
+
+	// Secrets are the flags whose values Print redacts.
+	Secrets []string
+
+	defaultPath string
+}
+
+// DefineFlags adds -config to fs, reading defaultPath if it is
+// there, and -profile too, if withProfiles.
+func (s *Sources) DefineFlags(fs *flag.FlagSet, defaultPath string, withProfiles bool) {
+	s.defaultPath = defaultPath
+	fs.StringVar(&s.Path, ConfigFlag, defaultPath, "YAML or TOML file of settings, by flag name, for the flags not given; the environment's "+s.env("<FLAG>")+" wins over it")
+	if withProfiles {
+		fs.StringVar(&s.Profile, ProfileFlag, "", "take settings from this one of the config file's profiles first")
+	}
+}
+
+// env is the environment variable for the flag name.
+func (s *Sources) env(name string) string {
+	return s.EnvPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// Load sets the flags in fs that were not on the command line from
+// the environment, and then from the config file. Call it once fs
+// is parsed.
+func (s *Sources) Load(fs *flag.FlagSet) (*Effective, error) {
+	e := &Effective{
+		fs:      fs,
+		from:    make(map[string]string),
+		secrets: s.Secrets,
+	}
+	fs.Visit(func(f *flag.Flag) {
+		e.from[f.Name] = "flag"
+	})
+
+	// the environment comes first, as it may say which file to read.
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || e.from[f.Name] != "" {
+			return
+		}
+		name := s.env(f.Name)
+		val, ok := os.LookupEnv(name)
+		if !ok {
+			return
+		}
+		if err = fs.Set(f.Name, val); err != nil {
+			err = fmt.Errorf("%s: %s", name, err)
+			return
+		}
+		e.from[f.Name] = name
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if s.Path == "" {
+		return e, nil
+	}
+	e.path, e.profile = s.Path, s.Profile
+
+	top, profiles, err := readFile(s.Path)
+	if os.IsNotExist(err) && s.Path == s.defaultPath {
+		e.path = ""
+		return e, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(profiles) > 0 && fs.Lookup(ProfileFlag) == nil {
+		return nil, fmt.Errorf("'%s': %s are not supported here", s.Path, ProfilesKey)
+	}
+	if s.Profile != "" {
+		p, ok := profiles[s.Profile]
+		if !ok {
+			return nil, fmt.Errorf("'%s' has no profile '%s'", s.Path, s.Profile)
+		}
+		if err := e.set(p, fmt.Sprintf("profile '%s' in '%s'", s.Profile, s.Path)); err != nil {
+			return nil, err
+		}
+	}
+	if err := e.set(top, fmt.Sprintf("'%s'", s.Path)); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// Effective is what the flags were set to, and where from.
+type Effective struct {
+	fs      *flag.FlagSet
+	from    map[string]string // by flag name; default if absent
+	secrets []string
+
+	path    string // the config file we read, if any
+	profile string
+}
+
+// set gives the flags in vals that nothing has set yet their
+// values, which came from where.
+func (e *Effective) set(vals map[string]string, where string) error {
+	names := make([]string, 0, len(vals))
+	for name := range vals {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == ConfigFlag || name == ProfileFlag {
+			return fmt.Errorf("%s: '%s' cannot be set in a config file", where, name)
+		}
+		if e.fs.Lookup(name) == nil {
+			return fmt.Errorf("%s: no such setting as '%s'", where, name)
+		}
+		if e.from[name] != "" {
+			continue
+		}
+		if err := e.fs.Set(name, vals[name]); err != nil {
+			return fmt.Errorf("%s: %s: %s", where, name, err)
+		}
+		e.from[name] = where
+	}
+
+	return nil
+}
+
+// From says where the flag name got its value: "flag", an
+// environment variable, the config file, or "default".
+func (e *Effective) From(name string) string {
+	if from, ok := e.from[name]; ok {
+		return from
+	}
+
+	return "default"
+}
+
+// Source names the config file we read, and the profile in it.
+func (e *Effective) Source() string {
+	switch {
+	case e.path == "":
+		return "no config file"
+	case e.profile == "":
+		return fmt.Sprintf("config file '%s'", e.path)
+	}
+
+	return fmt.Sprintf("config file '%s', profile '%s'", e.path, e.profile)
+}
+
+// Print writes every setting as YAML that a config file could
+// hold, noting those that did not come from their defaults, with
+// secrets redacted.
+func (e *Effective) Print(w io.Writer) error {
+	doc := &yaml.Node{Kind: yaml.MappingNode, HeadComment: e.Source()}
+
+	e.fs.VisitAll(func(f *flag.Flag) {
+		if f.Name == ConfigFlag || f.Name == ProfileFlag {
+			return
+		}
+
+		val := &yaml.Node{Kind: yaml.ScalarNode, Value: f.Value.String()}
+		if !plain(f.Value) {
+			val.Tag = "!!str"
+		}
+		if val.Value != "" && e.secret(f.Name) {
+			val.Value = Redacted
+		}
+		if from, ok := e.from[f.Name]; ok {
+			val.LineComment = "from " + from
+		}
+
+		doc.Content = append(doc.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: f.Name}, val)
+	})
+
+	enc := yaml.NewEncoder(w)
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func (e *Effective) secret(name string) bool {
+	for _, s := range e.secrets {
+		if s == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// plain says if v prints as a YAML number, boolean or duration,
+// rather than a string that may need quoting.
+func plain(v flag.Value) bool {
+	g, ok := v.(flag.Getter)
+	if !ok {
+		return false
+	}
+	_, isString := g.Get().(string)
+
+	return !isString
+}
+
+// readFile reads the settings at the top level of the config file
+// at path, and those in each of its profiles.
+func readFile(path string) (top map[string]string, profiles map[string]map[string]string, err error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var raw map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".toml") {
+		err = toml.Unmarshal(data, &raw)
+	} else {
+		err = yaml.Unmarshal(data, &raw)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("'%s': %s", path, err)
+	}
+
+	if p, ok := raw[ProfilesKey]; ok {
+		delete(raw, ProfilesKey)
+
+		byName, ok := p.(map[string]interface{})
+		if !ok {
+			return nil, nil, fmt.Errorf("'%s': %s must map names to settings", path, ProfilesKey)
+		}
+		profiles = make(map[string]map[string]string, len(byName))
+		for name, p := range byName {
+			vals, ok := p.(map[string]interface{})
+			if !ok {
+				return nil, nil, fmt.Errorf("'%s': profile '%s' must map flag names to values", path, name)
+			}
+			if profiles[name], err = values(vals); err != nil {
+				return nil, nil, fmt.Errorf("'%s': profile '%s': %s", path, name, err)
+			}
+		}
+	}
+
+	if top, err = values(raw); err != nil {
+		return nil, nil, fmt.Errorf("'%s': %s", path, err)
+	}
+
+	return top, profiles, nil
+}
+
+// values turns the settings in raw into what we would give the
+// flags on the command line. Lists, as -encrypt_to takes, are
+// joined with commas.
+func values(raw map[string]interface{}) (map[string]string, error) {
+	vals := make(map[string]string, len(raw))
+	for name, v := range raw {
+		switch v := v.(type) {
+		case nil:
+			vals[name] = ""
+		case map[string]interface{}:
+			return nil, fmt.Errorf("'%s' must be a value, not a table of them", name)
+		case []interface{}:
+			parts := make([]string, len(v))
+			for i, part := range v {
+				parts[i] = fmt.Sprint(part)
+			}
+			vals[name] = strings.Join(parts, ",")
+		default:
+			vals[name] = fmt.Sprint(v)
+		}
+	}
+
+	return vals, nil
+}
@@ -0,0 +1,125 @@
+package settings
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "client.yaml")
+	err := os.WriteFile(path, []byte(`
+host: files.example.com
+port: 10000
+chunk: 1024
+token: from-file
+encrypt_to: [a.pub, b.pub]
+profiles:
+  prod-eu:
+    host: eu.example.com
+    port: 443
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("client", flag.ContinueOnError)
+	host := fs.String("host", "127.0.0.1", "")
+	port := fs.Int("port", 1, "")
+	chunk := fs.Int("chunk", 1, "")
+	tls := fs.Bool("tls", false, "")
+	token := fs.String("token", "", "")
+	encryptTo := fs.String("encrypt_to", "", "")
+	payload := fs.Int("payload", 128, "")
+
+	src := &Sources{EnvPrefix: "TEST_CLIENT_", Secrets: []string{"token"}}
+	src.DefineFlags(fs, "", true)
+
+	t.Setenv("TEST_CLIENT_PORT", "8443")
+	t.Setenv("TEST_CLIENT_CHUNK", "2048")
+	t.Setenv("TEST_CLIENT_PROFILE", "prod-eu")
+	if err := fs.Parse([]string{"-config", path, "-chunk", "4096"}); err != nil {
+		t.Fatal(err)
+	}
+	eff, err := src.Load(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// flag > environment > profile > file > default.
+	if *chunk != 4096 || eff.From("chunk") != "flag" {
+		t.Errorf("chunk %v from %s; want 4096 from the flag", *chunk, eff.From("chunk"))
+	}
+	if *port != 8443 || eff.From("port") != "TEST_CLIENT_PORT" {
+		t.Errorf("port %v from %s; want 8443 from the environment", *port, eff.From("port"))
+	}
+	if *host != "eu.example.com" || !strings.HasPrefix(eff.From("host"), "profile 'prod-eu'") {
+		t.Errorf("host %v from %s; want the profile's", *host, eff.From("host"))
+	}
+	if *token != "from-file" || *encryptTo != "a.pub,b.pub" {
+		t.Errorf("token %v, encrypt_to %v; want the file's", *token, *encryptTo)
+	}
+	if *tls || *payload != 128 || eff.From("payload") != "default" {
+		t.Errorf("tls %v, payload %v from %s; want the defaults", *tls, *payload, eff.From("payload"))
+	}
+
+	var out bytes.Buffer
+	if err := eff.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "from-file") || !strings.Contains(out.String(), "token: "+Redacted) {
+		t.Errorf("token not redacted:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "port: 8443 # from TEST_CLIENT_PORT") {
+		t.Errorf("port not printed with where it came from:\n%s", out.String())
+	}
+}
+
+func TestFileMistakes(t *testing.T) {
+	dir := t.TempDir()
+
+	for name, body := range map[string]string{
+		"unknown.yaml": "hots: example.com\n",
+		"bad.toml":     "port = \"many\"\n",
+		"profile.yaml": "profiles:\n  dev:\n    host: dev\n",
+		"config.yaml":  "config: other.yaml\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		fs := flag.NewFlagSet("client", flag.ContinueOnError)
+		fs.String("host", "", "")
+		fs.Int("port", 0, "")
+		src := &Sources{EnvPrefix: "TEST_CLIENT_"}
+		src.DefineFlags(fs, "", true)
+		args := []string{"-config", path}
+		if name == "profile.yaml" {
+			args = append(args, "-profile", "prod")
+		}
+		if err := fs.Parse(args); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := src.Load(fs); err == nil {
+			t.Errorf("%s: loaded without complaint", name)
+		}
+	}
+
+	// the default file need not be there; any other must be.
+	for _, def := range []string{filepath.Join(dir, "missing.yaml"), ""} {
+		fs := flag.NewFlagSet("client", flag.ContinueOnError)
+		src := &Sources{}
+		src.DefineFlags(fs, def, false)
+		if err := fs.Parse([]string{"-config", filepath.Join(dir, "missing.yaml")}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := src.Load(fs); (err == nil) != (def != "") {
+			t.Errorf("missing file, default '%s': got %v", def, err)
+		}
+	}
+}
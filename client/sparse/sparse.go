@@ -0,0 +1,74 @@
+// Package sparse finds the holes in sparse files, so that
+// we need not read or send the zeros they stand for.
+package sparse
+
+import (
+	"hash"
+	"os"
+)
+
+// MinHole is the smallest hole worth sending as such;
+// smaller holes are just sent as zeros.
+const MinHole = 64 << 10
+
+// Segment is a run of a file that is either data or a hole.
+type Segment struct {
+	Offset int64
+	Length int64
+	Hole   bool
+}
+
+// Map returns the data and hole segments that make up the
+// first size bytes of f, in order. Where holes cannot be
+// detected, the whole file is one data segment.
+func Map(f *os.File, size int64) ([]Segment, error) {
+	if size == 0 {
+		return []Segment{{Offset: 0, Length: 0}}, nil
+	}
+
+	segs, err := mapHoles(f, size)
+	if err != nil || len(segs) == 0 {
+		return []Segment{{Offset: 0, Length: size}}, nil
+	}
+
+	return coalesce(segs), nil
+}
+
+// coalesce folds holes smaller than MinHole into
+// the data around them, and merges neighbours.
+func coalesce(segs []Segment) []Segment {
+	var out []Segment
+	for _, s := range segs {
+		if s.Length == 0 {
+			continue
+		}
+		if s.Hole && s.Length < MinHole {
+			s.Hole = false
+		}
+		if n := len(out); n > 0 && out[n-1].Hole == s.Hole {
+			out[n-1].Length += s.Length
+			continue
+		}
+		out = append(out, s)
+	}
+
+	if len(out) == 0 {
+		out = append(out, Segment{})
+	}
+
+	return out
+}
+
+var zeros = make([]byte, 64<<10)
+
+// HashZeros writes n zero bytes to h, for checksumming holes.
+func HashZeros(h hash.Hash, n int64) {
+	for n > 0 {
+		k := int64(len(zeros))
+		if n < k {
+			k = n
+		}
+		h.Write(zeros[:k])
+		n -= k
+	}
+}
@@ -0,0 +1,53 @@
+//go:build linux
+
+package sparse
+
+import (
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// mapHoles walks f with SEEK_DATA and SEEK_HOLE.
+func mapHoles(f *os.File, size int64) ([]Segment, error) {
+	fd := int(f.Fd())
+
+	var segs []Segment
+	var off int64
+
+	for off < size {
+		data, err := unix.Seek(fd, off, unix.SEEK_DATA)
+		if err == unix.ENXIO {
+			// no data from off to the end: all hole.
+			data = size
+		} else if err != nil {
+			return nil, err
+		}
+		if data > size {
+			data = size
+		}
+		if data > off {
+			segs = append(segs, Segment{Offset: off, Length: data - off, Hole: true})
+		}
+		if data >= size {
+			break
+		}
+
+		hole, err := unix.Seek(fd, data, unix.SEEK_HOLE)
+		if err != nil {
+			return nil, err
+		}
+		if hole > size {
+			hole = size
+		}
+		segs = append(segs, Segment{Offset: data, Length: hole - data})
+		off = hole
+	}
+
+	// we moved the file offset around; put it back.
+	if _, err := f.Seek(0, 0); err != nil {
+		return nil, err
+	}
+
+	return segs, nil
+}
@@ -29,10 +29,17 @@ SOFTWARE.
 
 import (
 	"context"
+	"fmt"
 	"net"
+	"os"
+	"strconv"
 	"time"
 
-	tun "github.com/devops-filetransfer/sshego"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/devops-filetransfer/filetransfer/client/hostkey"
+	"github.com/devops-filetransfer/filetransfer/client/metrics"
+	xssh "github.com/glycerine/sshego/xendor/github.com/glycerine/xcryptossh"
 )
 
 /*
@@ -41,41 +48,83 @@ min and max observed:
 elap time to send 512 MB was 13.484078385s => 37.971 MB/sec
 elap time to send 512 MB was 10.078974477s => 50.799 MB/sec
 */
-func ClientSshMain(trustNewServer, testAllowOneshotConnect bool, rsaPrivateKeyPath, knownHostsPath, username, host, destHostPort string, serverExternalPort int64) (func(string, time.Duration) (net.Conn, error), error) {
-	dc := tun.DialConfig{
-		ClientKnownHostsPath: knownHostsPath,
-		Mylogin:              username,
-		RsaPath:              rsaPrivateKeyPath,
-		TotpUrl:              "",
-		Pw:                   "",
-		Sshdhost:             host,
-		Sshdport:             serverExternalPort,
-		DownstreamHostPort:   destHostPort,
-		Verbose:              false,
-
-		TofuAddIfNotKnown:       trustNewServer,
-		TestAllowOneshotConnect: testAllowOneshotConnect,
+
+// ClientSshMain returns a dialer that logs in to the sshd at
+// host:serverExternalPort as username, with the private key in
+// privateKeyPath, and has it forward each connection on to
+// destHostPort. The server's host key must satisfy policy.
+func ClientSshMain(policy *hostkey.Policy, privateKeyPath, username, host, destHostPort string, serverExternalPort int64) (func(string, time.Duration) (net.Conn, error), error) {
+	pem, err := os.ReadFile(privateKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := xssh.ParsePrivateKey(pem)
+	if err != nil {
+		return nil, fmt.Errorf("private key '%s': %v", privateKeyPath, err)
+	}
+
+	sshd := net.JoinHostPort(host, strconv.FormatInt(serverExternalPort, 10))
+
+	checkHostKey := func(hostname string, remote net.Addr, key xssh.PublicKey) error {
+		pub, err := ssh.ParsePublicKey(key.Marshal())
+		if err != nil {
+			return err
+		}
+		return policy.Check(sshd, pub)
 	}
 
 	f := func(addr string, dur time.Duration) (net.Conn, error) {
-		ctx := context.Background()
-		channelToTcpServer, _, _, err := dc.Dial(ctx, nil, false)
-
-		/*
-			if strings.HasPrefix(err.Error(), "good: added "+
-				"previously unknown sshd host") ||
-				strings.Contains(err.Error(), "Re-run without -new") {
-				// for prevention of MITM, enforce that
-				// client to not do TOFU every time.
-				if trustNewServer {
-					dc.TofuAddIfNotKnown = false
-					channelToTcpServer, _, err = dc.Dial()
-				}
-			}
-		*/
-
-		return channelToTcpServer, err
+		halt := xssh.NewHalter()
+		cfg := &xssh.ClientConfig{
+			User:            username,
+			HostPort:        sshd,
+			Auth:            []xssh.AuthMethod{xssh.PublicKeys(signer)},
+			HostKeyCallback: checkHostKey,
+			Config: xssh.Config{
+				Ciphers: []string{"aes128-gcm@openssh.com"},
+				Halt:    halt,
+			},
+			Timeout: dur,
+		}
+
+		nConn, err := net.DialTimeout("tcp", sshd, dur)
+		if err != nil {
+			halt.RequestStop()
+			return nil, fmt.Errorf("ssh to '%s' as '%s': %v", sshd, username, err)
+		}
+		sshConn, chans, reqs, err := xssh.NewClientConn(context.Background(), nConn, sshd, cfg)
+		if err != nil {
+			metrics.HandshakeFailed(metrics.SSH)
+			halt.RequestStop()
+			return nil, fmt.Errorf("ssh to '%s' as '%s': %v", sshd, username, err)
+		}
+		cli := xssh.NewClient(context.Background(), sshConn, chans, reqs, halt)
+
+		ch, err := cli.Dial("tcp", destHostPort)
+		if err != nil {
+			cli.Close()
+			halt.RequestStop()
+			return nil, fmt.Errorf("ssh to '%s' could not reach '%s': %v", sshd, destHostPort, err)
+		}
+
+		return &tunnelConn{Conn: ch, cli: cli, halt: halt}, nil
 	}
 
 	return f, nil
 }
+
+// tunnelConn is the one connection we forward over an SSH
+// login; closing it logs out.
+type tunnelConn struct {
+	net.Conn
+	cli  *xssh.Client
+	halt *xssh.Halter
+}
+
+func (c *tunnelConn) Close() error {
+	err := c.Conn.Close()
+	c.cli.Close()
+	c.halt.RequestStop()
+
+	return err
+}
@@ -0,0 +1,139 @@
+// Package sshkey makes the key pair a client logs in over SSH
+// with. The private key never leaves this machine: the server
+// is only ever given the public half.
+package sshkey
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// KeyFile is our private key under DefaultDir; the public key
+// is beside it, with PubSuffix.
+const (
+	KeyFile   = "id_ed25519"
+	PubSuffix = ".pub"
+)
+
+// DefaultDir is where we keep our SSH keys unless told otherwise.
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	return filepath.Join(home, ".filetransfer", "ssh")
+}
+
+// DefaultPath is the private key we log in with unless told otherwise.
+func DefaultPath() string {
+	return filepath.Join(DefaultDir(), KeyFile)
+}
+
+// Generate writes a new Ed25519 key pair to path and
+// path+PubSuffix, refusing to replace a key already there.
+// The private key is in OpenSSH's format, so ssh and ssh-keygen
+// can use it too; comment goes with the public key. It returns
+// the public key, in authorized_keys form.
+func Generate(path, comment string) ([]byte, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	line := ssh.MarshalAuthorizedKey(sshPub)
+	if comment != "" {
+		line = append(line[:len(line)-1], []byte(" "+comment+"\n")...)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if err := writeNew(path, marshalOpenSSH(sshPub, priv, comment), 0600); err != nil {
+		return nil, err
+	}
+	if err := writeNew(path+PubSuffix, line, 0644); err != nil {
+		return nil, err
+	}
+
+	return line, nil
+}
+
+// marshalOpenSSH encodes an unencrypted Ed25519 private key as
+// an "OPENSSH PRIVATE KEY", per OpenSSH's PROTOCOL.key.
+func marshalOpenSSH(pub ssh.PublicKey, priv ed25519.PrivateKey, comment string) []byte {
+	var check [4]byte
+	_, _ = rand.Read(check[:])
+
+	key := ssh.Marshal(struct {
+		Check1  uint32
+		Check2  uint32
+		Type    string
+		Pub     []byte
+		Priv    []byte
+		Comment string
+	}{
+		Check1:  binary.BigEndian.Uint32(check[:]),
+		Check2:  binary.BigEndian.Uint32(check[:]),
+		Type:    ssh.KeyAlgoED25519,
+		Pub:     priv.Public().(ed25519.PublicKey),
+		Priv:    priv,
+		Comment: comment,
+	})
+
+	// pad to the cipher block size, 8 for "none".
+	for i := byte(1); len(key)%8 != 0; i++ {
+		key = append(key, i)
+	}
+
+	body := ssh.Marshal(struct {
+		Cipher     string
+		KDF        string
+		KDFOptions string
+		NumKeys    uint32
+		Pub        []byte
+		Priv       []byte
+	}{
+		Cipher:  "none",
+		KDF:     "none",
+		NumKeys: 1,
+		Pub:     pub.Marshal(),
+		Priv:    key,
+	})
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "OPENSSH PRIVATE KEY",
+		Bytes: append([]byte("openssh-key-v1\x00"), body...),
+	})
+}
+
+// writeNew writes data to a new file at path, refusing to
+// overwrite one that is already there.
+func writeNew(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if os.IsExist(err) {
+		return fmt.Errorf("'%s' already exists; not replacing it", path)
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
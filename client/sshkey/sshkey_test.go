@@ -0,0 +1,54 @@
+package sshkey
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+
+	xssh "github.com/glycerine/sshego/xendor/github.com/glycerine/xcryptossh"
+)
+
+func TestGenerate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), KeyFile)
+
+	line, err := Generate(path, "alice@laptop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey(line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment != "alice@laptop" {
+		t.Fatalf("comment '%s'", comment)
+	}
+
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// both the stock ssh package and the one the tunnel logs
+	// in with must read the private key, and find the pair.
+	signer, err := ssh.ParsePrivateKey(pem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(signer.PublicKey().Marshal(), pub.Marshal()) {
+		t.Fatal("private key does not match the public key")
+	}
+	xsigner, err := xssh.ParsePrivateKey(pem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(xsigner.PublicKey().Marshal(), pub.Marshal()) {
+		t.Fatal("tunnel reads a different key")
+	}
+
+	if _, err := Generate(path, ""); err == nil {
+		t.Fatal("replaced an existing key")
+	}
+}
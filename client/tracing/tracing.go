@@ -0,0 +1,159 @@
+// Package tracing follows transfers with OpenTelemetry, so that we
+// can see where the time goes: hashing, sending, verifying, writing
+// to disk or committing. gRPC calls carry the trace from the client
+// to the server, and on to its peers.
+package tracing
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+)
+
+// instrumentation names us as the maker of our spans.
+const instrumentation = "github.com/devops-filetransfer/filetransfer"
+
+// The exporters Setup knows, as given to -trace.
+const (
+	Stdout = "stdout"
+	File   = "file"
+	OTLP   = "otlp"
+)
+
+// parse splits an exporter, as given to -trace, into its kind
+// and what follows the first colon.
+func parse(exporter string) (kind, arg string, err error) {
+	kind, arg, _ = strings.Cut(exporter, ":")
+	switch {
+	case kind == Stdout && arg == "":
+	case kind == File && arg != "":
+	case kind == OTLP:
+	default:
+		return "", "", fmt.Errorf("-trace '%s' is not %s, %s:<path>, %s or %s:<host:port>", exporter, Stdout, File, OTLP, OTLP)
+	}
+
+	return kind, arg, nil
+}
+
+// Check says if Setup would take exporter.
+func Check(exporter string) error {
+	if exporter == "" {
+		return nil
+	}
+	_, _, err := parse(exporter)
+
+	return err
+}
+
+// Setup sends the spans we make, as service, to exporter: stdout;
+// file:<path>, appending a JSON object per span; or otlp, to the
+// OpenTelemetry collector at <host:port> if given, and otherwise
+// where the usual OTEL_EXPORTER_OTLP_ environment variables say.
+// With no exporter, spans cost next to nothing and go nowhere.
+// The func returned sends off what is left, and stops.
+func Setup(exporter, service string) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if exporter == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	kind, arg, err := parse(exporter)
+	if err != nil {
+		return nil, err
+	}
+
+	var exp sdktrace.SpanExporter
+	var f *os.File
+	switch kind {
+	case Stdout:
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout), stdouttrace.WithPrettyPrint())
+	case File:
+		f, err = os.OpenFile(arg, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, err
+		}
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	case OTLP:
+		var opts []otlptracegrpc.Option
+		if arg != "" {
+			opts = append(opts, otlptracegrpc.WithEndpoint(arg))
+		}
+		exp, err = otlptracegrpc.New(context.Background(), opts...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("-trace '%s': %v", exporter, err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(service))),
+	)
+	otel.SetTracerProvider(tp)
+
+	return func(ctx context.Context) error {
+		err := tp.Shutdown(ctx)
+		if f != nil {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}
+		return err
+	}, nil
+}
+
+// DialOptions trace the calls made over a connection dialed with
+// them, and send the trace along with them.
+func DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}
+}
+
+// Start starts the span name, as a child of the one on ctx, if any.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(instrumentation).Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// End ends span, as having failed with err, if not nil.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Inject returns the W3C traceparent of the span on ctx, for taking
+// the trace along where gRPC metadata does not go; "" if there is
+// none.
+func Inject(ctx context.Context) string {
+	carrier := propagation.MapCarrier{}
+	propagation.TraceContext{}.Inject(ctx, carrier)
+
+	return carrier.Get("traceparent")
+}
+
+// Extract returns ctx as part of the trace whose traceparent Inject
+// gave.
+func Extract(ctx context.Context, traceparent string) context.Context {
+	if traceparent == "" {
+		return ctx
+	}
+
+	return propagation.TraceContext{}.Extract(ctx, propagation.MapCarrier{"traceparent": traceparent})
+}
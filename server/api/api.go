@@ -27,17 +27,33 @@ type Peerface interface {
 // calls below determines if we return the Val
 // on Get calls. Val must always be provided
 // on Set.
+//
+// Key, for a stored file, is its tenant, a NUL,
+// and its path within the tenant's directory.
+//
+// Signature, when the file came with one, is
+// the signed manifest it was uploaded with, as
+// checked against the key of Signer.
+//
+// TransferID names the upload that brought
+// the file, so peers can log it too; and
+// TraceParent is the trace it was committed
+// in, so peers can trace under it.
 type KeyInv struct {
-	Key     []byte
-	Who     string
-	When    time.Time
-	Size    int64
-	Blake2b []byte
-	Val     []byte
+	Key         []byte
+	Who         string
+	When        time.Time
+	Size        int64
+	Blake2b     []byte
+	Val         []byte
+	Signer      string
+	Signature   []byte
+	TransferID  string
+	TraceParent string
 }
 
 func (ki *KeyInv) String() string {
-	return fmt.Sprintf(`{Key:"%s", Who:"%s", When:"%s", Size:%v, Blake2b:"%x"}`,
+	return fmt.Sprintf(`{Key:%q, Who:"%s", When:"%s", Size:%v, Blake2b:"%x"}`,
 		string(ki.Key), ki.Who, ki.When.UTC(), ki.Size, ki.Blake2b)
 }
 
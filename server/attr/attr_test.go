@@ -0,0 +1,51 @@
+package attr
+
+import (
+	"reflect"
+	"testing"
+
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+func TestFullAppliesOnlyUserXattrsAndACLs(t *testing.T) {
+	got := allowedXattrs(map[string][]byte{
+		"user.origin":              []byte("ci"),
+		"system.posix_acl_access":  []byte("acl"),
+		"system.posix_acl_default": []byte("acl"),
+		"security.capability":      []byte("cap"),
+		"security.selinux":         []byte("ctx"),
+		"trusted.overlay.opaque":   []byte("y"),
+		"system.nfs4_acl":          []byte("acl"),
+	})
+
+	want := map[string][]byte{
+		"user.origin":              []byte("ci"),
+		"system.posix_acl_access":  []byte("acl"),
+		"system.posix_acl_default": []byte("acl"),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("applied %v; want %v", got, want)
+	}
+}
+
+func TestSetIDBitsForRootOnlyWhenAsked(t *testing.T) {
+	a := &pb.FileAttr{Mode: modeSetuid | modeSetgid | 0755}
+
+	for _, c := range []struct {
+		p         Policy
+		uid, gid  int
+		rootSetID bool
+		want      uint32
+	}{
+		{Mode, -1, -1, false, 0755},
+		{Owner, 1000, 1000, false, modeSetuid | modeSetgid | 0755},
+		{Owner, 0, 1000, false, modeSetgid | 0755},
+		{Owner, 1000, 0, false, modeSetuid | 0755},
+		{Full, 0, 0, false, 0755},
+		{Full, 0, 0, true, modeSetuid | modeSetgid | 0755},
+	} {
+		if got := modeFor(a, c.p, c.uid, c.gid, c.rootSetID); got != c.want {
+			t.Errorf("%v, owned by %v:%v, rootSetID %v: mode %o; want %o", c.p, c.uid, c.gid, c.rootSetID, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,371 @@
+// Package audit keeps an append-only record of what was done to
+// the files we keep, and by whom. Records are JSON, one per line,
+// and each carries the SHA-256 of its predecessor, so a record
+// that is altered, dropped or reordered breaks the chain from
+// there on; Verify walks it.
+//
+// The log rotates by size and by age. The current file keeps its
+// name; older ones get the sequence number of their last record
+// added, zero padded, so they sort in order. The chain runs on
+// from one file to the next, and old files may be removed from
+// the front of it without breaking what is left.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Record is one operation.
+type Record struct {
+	Seq     uint64        `json:"seq"`
+	Time    time.Time     `json:"time"`
+	Who     string        `json:"who"`
+	Op      string        `json:"op"`
+	Path    string        `json:"path,omitempty"`
+	Detail  string        `json:"detail,omitempty"`
+	Size    int64         `json:"size"`
+	Blake2b string        `json:"blake2b,omitempty"`
+	Result  string        `json:"result"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+
+	// TransferID is the ID of the transfer the operation was
+	// part of, as our logs know it.
+	TransferID string `json:"transfer_id,omitempty"`
+
+	// Prev is the Hash of the record before; empty for the first.
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+
+	// line is the record as read from the log.
+	line []byte
+}
+
+// OK is the Result of an operation that succeeded; otherwise it
+// is the error.
+const OK = "ok"
+
+// hash is the SHA-256 of rec, its Hash aside.
+func (rec Record) hash() (string, error) {
+	rec.Hash = ""
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log appends records to the file at Path, rotating it once it
+// holds MaxSize bytes, or its first record is MaxAge old. Zero
+// for either means no limit. It is safe for concurrent use.
+type Log struct {
+	Path    string
+	MaxSize int64
+	MaxAge  time.Duration
+
+	mu      sync.Mutex
+	f       *os.File
+	size    int64
+	started time.Time // of the first record in f
+	last    Record
+}
+
+// Open opens the log at path, picking its chain up where it
+// left off, in it or in the newest rotated file. A last line left
+// unfinished, by a crash as it was appended, is cut off.
+func Open(path string, maxSize int64, maxAge time.Duration) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+
+	l := &Log{Path: path, MaxSize: maxSize, MaxAge: maxAge}
+
+	recs, torn, err := readFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(torn) > 0 {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.Truncate(path, fi.Size()-int64(len(torn))); err != nil {
+			return nil, fmt.Errorf("audit: could not cut the torn last line off '%s': %v", path, err)
+		}
+		slog.Warn("audit log ended in a torn line, left by a crash as it was appended; cut it off", "path", path, "bytes", len(torn), "after_seq", lastSeq(recs))
+	}
+	if len(recs) > 0 {
+		l.started = recs[0].Time
+		l.last = recs[len(recs)-1]
+	} else {
+		rotated, err := Rotated(path)
+		if err != nil {
+			return nil, err
+		}
+		if n := len(rotated); n > 0 {
+			prev, _, err := readFile(rotated[n-1])
+			if err != nil {
+				return nil, err
+			}
+			if len(prev) > 0 {
+				l.last = prev[len(prev)-1]
+			}
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	l.f, l.size = f, fi.Size()
+
+	return l, nil
+}
+
+// readFile returns the records in the log file at path, none if
+// there is no such file, and torn, its last line if that was never
+// finished.
+func readFile(path string) (recs []Record, torn []byte, err error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	for n := 1; ; n++ {
+		line, err := br.ReadBytes('\n')
+		if err == io.EOF {
+			return recs, line, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rec := Record{line: line[:len(line)-1]}
+		if err := json.Unmarshal(rec.line, &rec); err != nil {
+			return nil, nil, fmt.Errorf("audit: '%s' line %v is not a record: %v", path, n, err)
+		}
+		recs = append(recs, rec)
+	}
+}
+
+// lastSeq is the Seq of the last of recs; 0 if there are none.
+func lastSeq(recs []Record) uint64 {
+	if len(recs) == 0 {
+		return 0
+	}
+
+	return recs[len(recs)-1].Seq
+}
+
+// Append chains rec onto the log, setting its Seq, Time (if not
+// already set), Prev and Hash, and syncs it to disk.
+func (l *Log) Append(rec *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if rec.Time.IsZero() {
+		rec.Time = time.Now()
+	}
+	rec.Time = rec.Time.UTC().Round(0)
+	rec.Seq = l.last.Seq + 1
+	rec.Prev = l.last.Hash
+
+	var err error
+	if rec.Hash, err = rec.hash(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	if l.due(rec.Time, len(line)) {
+		if err := l.rotate(); err != nil {
+			return fmt.Errorf("audit: could not rotate '%s': %v", l.Path, err)
+		}
+	}
+
+	if _, err := l.f.Write(line); err != nil {
+		return err
+	}
+	if err := l.f.Sync(); err != nil {
+		return err
+	}
+
+	if l.size == 0 {
+		l.started = rec.Time
+	}
+	l.size += int64(len(line))
+	l.last = *rec
+
+	return nil
+}
+
+// due says whether the current file should be rotated before a
+// record of n bytes, made at now, goes in.
+func (l *Log) due(now time.Time, n int) bool {
+	if l.size == 0 {
+		return false
+	}
+
+	return (l.MaxSize > 0 && l.size+int64(n) > l.MaxSize) ||
+		(l.MaxAge > 0 && now.Sub(l.started) >= l.MaxAge)
+}
+
+func (l *Log) rotate() error {
+	if err := l.f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(l.Path, rotatedName(l.Path, l.last.Seq)); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+	l.f, l.size = f, 0
+
+	return nil
+}
+
+func rotatedName(path string, lastSeq uint64) string {
+	return fmt.Sprintf("%s.%012d", path, lastSeq)
+}
+
+// Close closes the log.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.f.Close()
+}
+
+// Rotated returns the rotated files of the log at path, oldest first.
+func Rotated(path string) ([]string, error) {
+	matches, err := filepath.Glob(path + ".*")
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, m := range matches {
+		suffix := strings.TrimPrefix(m, path+".")
+		if _, err := strconv.ParseUint(suffix, 10, 64); err == nil {
+			files = append(files, m)
+		}
+	}
+	sort.Strings(files)
+
+	return files, nil
+}
+
+// Summary describes a chain that Verify found intact.
+type Summary struct {
+	Files   int
+	Records int
+
+	// First and Last are the sequence numbers of the ends of the
+	// chain; First is above 1 if older files have been removed.
+	First, Last uint64
+	LastHash    string
+}
+
+// Verify checks the chain of the log at path, across its rotated
+// files, recomputing every hash. Anything cut from the end of the
+// log leaves no trace in the chain itself, so keep LastHash
+// somewhere else, and compare.
+func Verify(path string) (*Summary, error) {
+	files, err := Rotated(path)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, path)
+
+	sum := &Summary{}
+	var last *Record
+	for _, file := range files {
+		recs, torn, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if len(torn) > 0 {
+			return nil, fmt.Errorf("audit: '%s' line %v is torn: it was never finished, as by a crash while it was appended", file, len(recs)+1)
+		}
+		if len(recs) > 0 {
+			sum.Files++
+		}
+
+		for i := range recs {
+			rec := &recs[i]
+			where := fmt.Sprintf("'%s' line %v (seq %v)", file, i+1, rec.Seq)
+
+			// the hash covers the fields we know; anything else
+			// in the line would go unseen.
+			canon, err := json.Marshal(rec)
+			if err != nil {
+				return nil, err
+			}
+			if !bytes.Equal(canon, rec.line) {
+				return nil, fmt.Errorf("audit: %s has been altered: it is not as we write records", where)
+			}
+
+			h, err := rec.hash()
+			if err != nil {
+				return nil, err
+			}
+			if h != rec.Hash {
+				return nil, fmt.Errorf("audit: %s has been altered: its hash is %s, but it says %s", where, h, rec.Hash)
+			}
+
+			if last == nil {
+				sum.First = rec.Seq
+			} else {
+				if rec.Seq != last.Seq+1 {
+					return nil, fmt.Errorf("audit: %s follows seq %v; records are missing or out of order", where, last.Seq)
+				}
+				if rec.Prev != last.Hash {
+					return nil, fmt.Errorf("audit: %s does not chain onto the record before it", where)
+				}
+			}
+			if rec.Seq == 1 && rec.Prev != "" {
+				return nil, fmt.Errorf("audit: %s is the first record, yet names one before it", where)
+			}
+
+			last = rec
+			sum.Records++
+		}
+	}
+
+	if last != nil {
+		sum.Last, sum.LastHash = last.Seq, last.Hash
+	}
+
+	return sum, nil
+}
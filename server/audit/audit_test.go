@@ -0,0 +1,160 @@
+package audit
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func write(t *testing.T, l *Log, n int) {
+	for i := 0; i < n; i++ {
+		err := l.Append(&Record{Who: "alice", Op: "TransferFile", Path: "a/b.bin", Size: int64(i), Result: OK})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	// small enough to rotate every few records.
+	l, err := Open(path, 600, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, 10)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the chain carries on after a restart.
+	l, err = Open(path, 600, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, 5)
+	l.Close()
+
+	sum, err := Verify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Records != 15 || sum.First != 1 || sum.Last != 15 || sum.Files < 3 {
+		t.Fatalf("got %+v", sum)
+	}
+
+	// losing the oldest file leaves the rest verifiable.
+	rotated, _ := Rotated(path)
+	os.Remove(rotated[0])
+	sum, err = Verify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.First == 1 || sum.Last != 15 {
+		t.Fatalf("got %+v", sum)
+	}
+}
+
+func TestRotateByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	l, err := Open(path, 0, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(time.Minute), now.Add(2 * time.Hour)} {
+		if err := l.Append(&Record{Op: "GetFile", Result: OK, Time: at}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if rotated, _ := Rotated(path); len(rotated) != 1 {
+		t.Fatalf("rotated into %v", rotated)
+	}
+	if _, err := Verify(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTampering(t *testing.T) {
+	for name, tamper := range map[string]func([][]byte) [][]byte{
+		"altered": func(lines [][]byte) [][]byte {
+			lines[2] = bytes.Replace(lines[2], []byte(`"size":2`), []byte(`"size":9`), 1)
+			return lines
+		},
+		"dropped": func(lines [][]byte) [][]byte {
+			return append(lines[:2], lines[3:]...)
+		},
+		"reordered": func(lines [][]byte) [][]byte {
+			lines[1], lines[2] = lines[2], lines[1]
+			return lines
+		},
+		"added a field": func(lines [][]byte) [][]byte {
+			lines[3] = bytes.Replace(lines[3], []byte(`{`), []byte(`{"note":"x",`), 1)
+			return lines
+		},
+	} {
+		path := filepath.Join(t.TempDir(), "audit.log")
+		l, err := Open(path, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		write(t, l, 5)
+		l.Close()
+
+		data, _ := os.ReadFile(path)
+		lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+		lines = tamper(lines)
+		os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0600)
+
+		if _, err := Verify(path); err == nil || !strings.Contains(err.Error(), "line") {
+			t.Fatalf("%s: not caught: %v", name, err)
+		}
+	}
+}
+
+func TestTornLastLineIsCutOffOnOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	l, err := Open(path, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write(t, l, 3)
+	l.Close()
+
+	// a crash halfway through the fourth.
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"seq":4,"time":"20`); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	if _, err := Verify(path); err == nil || !strings.Contains(err.Error(), "torn") {
+		t.Fatalf("Verify of a torn log gave %v; want it to say so", err)
+	}
+
+	l, err = Open(path, 0, 0)
+	if err != nil {
+		t.Fatalf("could not reopen a torn log: %v", err)
+	}
+	write(t, l, 1)
+	l.Close()
+
+	sum, err := Verify(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sum.Records != 4 || sum.Last != 4 {
+		t.Errorf("got %v records, up to seq %v; want 4, the torn one replaced", sum.Records, sum.Last)
+	}
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	"github.com/devops-filetransfer/filetransfer/server/authz"
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	"github.com/devops-filetransfer/filetransfer/server/logging"
+)
+
+// The handlers record what they do themselves, as only they know
+// the sizes and checksums. The interceptors record the calls that
+// were refused before a handler got to say anything, such as by
+// the authorization policy; they should come after the ones that
+// establish who the caller is, so that is known.
+
+type callKey struct{}
+
+// call is what the interceptors know of a call in progress.
+type call struct {
+	recorded bool
+
+	// paths are those named by the last message received.
+	paths []string
+}
+
+// Record appends rec, made by the caller on ctx, to the log, and
+// marks the call as recorded. Without a Path, rec gets the paths
+// named by the last message received on the call.
+func (l *Log) Record(ctx context.Context, rec *Record) error {
+	if c, ok := ctx.Value(callKey{}).(*call); ok {
+		c.recorded = true
+		if rec.Path == "" {
+			rec.Path = strings.Join(c.paths, ", ")
+		}
+	}
+	if rec.Who == "" {
+		rec.Who = identity.FromContext(ctx)
+	}
+	if rec.TransferID == "" {
+		rec.TransferID = logging.TransferID(ctx)
+	}
+
+	return l.Append(rec)
+}
+
+// refused records a call to method that failed with err before
+// its handler recorded anything.
+func (l *Log) refused(ctx context.Context, method string, start time.Time, err error) {
+	rec := &Record{
+		Op:      path.Base(method),
+		Result:  err.Error(),
+		Time:    start,
+		Elapsed: time.Since(start),
+	}
+	if err := l.Record(ctx, rec); err != nil {
+		log.Printf("audit: could not record %s: '%s'", rec.Op, err)
+	}
+}
+
+func track(ctx context.Context) (context.Context, *call) {
+	c := &call{}
+
+	return context.WithValue(ctx, callKey{}, c), c
+}
+
+// UnaryInterceptor records unary calls refused before their handler.
+func (l *Log) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx, c := track(ctx)
+		_, c.paths = authz.OpsOf(req)
+
+		resp, err := handler(ctx, req)
+		if err != nil && !c.recorded {
+			l.refused(ctx, info.FullMethod, start, err)
+		}
+
+		return resp, err
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streams; the path
+// recorded is from the last message received.
+func (l *Log) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx, c := track(ss.Context())
+
+		err := handler(srv, &auditedStream{ServerStream: ss, ctx: ctx, call: c})
+		if err != nil && !c.recorded {
+			l.refused(ctx, info.FullMethod, start, err)
+		}
+
+		return err
+	}
+}
+
+type auditedStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	call *call
+}
+
+func (s *auditedStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *auditedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if _, paths := authz.OpsOf(m); len(paths) > 0 {
+		s.call.paths = paths
+	}
+
+	return nil
+}
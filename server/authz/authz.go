@@ -0,0 +1,225 @@
+// Package authz decides which callers may read, write and
+// delete which paths, and who may administer the server,
+// according to a policy file.
+package authz
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/devops-filetransfer/filetransfer/server/jail"
+)
+
+// Op is something a caller can do to a path.
+type Op string
+
+const (
+	Read   Op = "read"
+	Write  Op = "write"
+	Delete Op = "delete"
+
+	// Admin is managing the server itself, such as its SSH
+	// keys; it is not done to any path, so a rule allowing it
+	// need name none.
+	Admin Op = "admin"
+)
+
+// Anyone, as a Rule's Who, matches every caller, including
+// those we cannot identify.
+const Anyone = "*"
+
+// Rule lets the callers in Who perform the operations in
+// Allow on the paths matching any of Paths. A path pattern
+// is matched a segment at a time as by path.Match, except
+// that a "**" segment matches any number of segments; so
+// "artifacts/**" matches everything under artifacts.
+type Rule struct {
+	Who   []string `json:"who"`
+	Allow []Op     `json:"allow"`
+	Paths []string `json:"paths"`
+}
+
+// Policy is a list of rules. Anything no rule allows is denied.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// ParsePolicy reads a JSON policy, such as
+//
+//	{"rules": [
+//	    {"who": ["ci-bot"], "allow": ["write"], "paths": ["artifacts/**"]},
+//	    {"who": ["alice"], "allow": ["read", "write", "delete"], "paths": ["**"]},
+//	    {"who": ["ops@example.org"], "allow": ["admin"]}
+//	]}
+func ParsePolicy(data []byte) (*Policy, error) {
+	p := &Policy{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(p); err != nil {
+		return nil, err
+	}
+
+	for i, r := range p.Rules {
+		if len(r.Who) == 0 {
+			return nil, fmt.Errorf("rule %v: no 'who'", i)
+		}
+		for _, op := range r.Allow {
+			switch op {
+			case Read, Write, Delete, Admin:
+			default:
+				return nil, fmt.Errorf("rule %v: unknown operation '%s'; expected read, write, delete or admin", i, op)
+			}
+		}
+		for _, pat := range r.Paths {
+			for _, seg := range strings.Split(pat, "/") {
+				if _, err := path.Match(seg, ""); err != nil {
+					return nil, fmt.Errorf("rule %v: bad path pattern '%s': %v", i, pat, err)
+				}
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// Allowed says whether who may do op to p. For Admin, p
+// is ignored.
+func (pol *Policy) Allowed(who string, op Op, p string) bool {
+	if op == Admin {
+		for _, r := range pol.Rules {
+			if r.permits(who, op) {
+				return true
+			}
+		}
+		return false
+	}
+
+	clean, err := jail.Clean(p)
+	if err != nil {
+		return false
+	}
+
+	for _, r := range pol.Rules {
+		if r.allows(who, op, clean) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// permits says whether r lets who do op, to some path.
+func (r *Rule) permits(who string, op Op) bool {
+	if !contains(r.Who, who) && !contains(r.Who, Anyone) {
+		return false
+	}
+
+	return contains(r.Allow, op)
+}
+
+func (r *Rule) allows(who string, op Op, p string) bool {
+	if !r.permits(who, op) {
+		return false
+	}
+
+	for _, pat := range r.Paths {
+		if Match(pat, p) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains[T comparable](list []T, x T) bool {
+	for _, y := range list {
+		if y == x {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Match reports whether the slash separated path p matches
+// pattern; see Rule for the syntax.
+func Match(pattern, p string) bool {
+	return matchSegs(strings.Split(pattern, "/"), strings.Split(p, "/"))
+}
+
+func matchSegs(pat, segs []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			// try every split of what is left.
+			for i := 0; i <= len(segs); i++ {
+				if matchSegs(pat[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], segs[0]); !ok {
+			return false
+		}
+		pat, segs = pat[1:], segs[1:]
+	}
+
+	return len(segs) == 0
+}
+
+// Authorizer holds the policy loaded from Path, which
+// can be reloaded while we are serving.
+type Authorizer struct {
+	Path string
+
+	mu  sync.RWMutex
+	pol *Policy
+}
+
+// Load returns an Authorizer enforcing the policy file at p.
+func Load(p string) (*Authorizer, error) {
+	a := &Authorizer{Path: p}
+	if err := a.Reload(); err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Reload reads the policy file again. If it cannot be read
+// or parsed, the policy already in force stays in force.
+func (a *Authorizer) Reload() error {
+	data, err := os.ReadFile(a.Path)
+	if err != nil {
+		return err
+	}
+
+	pol, err := ParsePolicy(data)
+	if err != nil {
+		return fmt.Errorf("authz policy '%s': %v", a.Path, err)
+	}
+
+	a.mu.Lock()
+	a.pol = pol
+	a.mu.Unlock()
+
+	return nil
+}
+
+// Allowed says whether who may do op to p under the current policy.
+func (a *Authorizer) Allowed(who string, op Op, p string) bool {
+	a.mu.RLock()
+	pol := a.pol
+	a.mu.RUnlock()
+
+	return pol.Allowed(who, op, p)
+}
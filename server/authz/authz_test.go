@@ -0,0 +1,116 @@
+package authz
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"artifacts/**", "artifacts/a.tar", true},
+		{"artifacts/**", "artifacts/x/y/z.tar", true},
+		{"artifacts/**", "artifactsx/a.tar", false},
+		{"artifacts/**", "other/artifacts/a.tar", false},
+		{"**", "anything/at/all", true},
+		{"**/*.log", "a/b/c.log", true},
+		{"**/*.log", "c.log", true},
+		{"**/*.log", "a/b/c.txt", false},
+		{"logs/*.log", "logs/a/b.log", false},
+		{"logs/*.log", "logs/b.log", true},
+	}
+	for _, c := range cases {
+		if got := Match(c.pattern, c.path); got != c.want {
+			t.Errorf("Match('%s', '%s') == %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	pol, err := ParsePolicy([]byte(`{"rules": [
+		{"who": ["ci-bot"], "allow": ["write"], "paths": ["artifacts/**"]},
+		{"who": ["alice"], "allow": ["read", "write", "delete"], "paths": ["**"]},
+		{"who": ["*"], "allow": ["read"], "paths": ["public/**"]},
+		{"who": ["ops"], "allow": ["admin"]}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		who  string
+		op   Op
+		path string
+		want bool
+	}{
+		{"ci-bot", Write, "artifacts/build/1.tar", true},
+		{"ci-bot", Write, "artifacts/../secrets/key", false},
+		{"ci-bot", Write, "secrets/key", false},
+		{"ci-bot", Read, "artifacts/build/1.tar", false},
+		{"ci-bot", Delete, "artifacts/build/1.tar", false},
+		{"ci-bot", Read, "public/readme", true},
+		{"", Read, "public/readme", true},
+		{"", Write, "public/readme", false},
+		{"alice", Delete, "artifacts/build/1.tar", true},
+		{"mallory", Write, "artifacts/build/1.tar", false},
+		{"ops", Admin, "", true},
+		{"ops", Read, "artifacts/build/1.tar", false},
+		{"alice", Admin, "", false},
+		{"", Admin, "", false},
+	}
+	for _, c := range cases {
+		if got := pol.Allowed(c.who, c.op, c.path); got != c.want {
+			t.Errorf("Allowed('%s', %s, '%s') == %v, want %v", c.who, c.op, c.path, got, c.want)
+		}
+	}
+}
+
+func TestParsePolicyRejectsTypos(t *testing.T) {
+	for _, bad := range []string{
+		`{"rules": [{"who": ["a"], "allow": ["wrte"], "paths": ["**"]}]}`,
+		`{"rules": [{"who": ["a"], "alow": ["write"], "paths": ["**"]}]}`,
+		`{"rules": [{"allow": ["write"], "paths": ["**"]}]}`,
+		`{"rules": [{"who": ["a"], "allow": ["write"], "paths": ["[a"]}]}`,
+	} {
+		if _, err := ParsePolicy([]byte(bad)); err == nil {
+			t.Errorf("expected an error from %s", bad)
+		}
+	}
+}
+
+func TestReloadKeepsOldPolicyOnError(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "policy.json")
+	write := func(s string) {
+		if err := os.WriteFile(p, []byte(s), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"rules": [{"who": ["a"], "allow": ["read"], "paths": ["**"]}]}`)
+	a, err := Load(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Allowed("a", Read, "x") || a.Allowed("a", Write, "x") {
+		t.Fatal("initial policy not in force")
+	}
+
+	write(`{"rules": [{"who": ["a"], "allow": ["write"], "paths": ["**"]}]}`)
+	if err := a.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if a.Allowed("a", Read, "x") || !a.Allowed("a", Write, "x") {
+		t.Fatal("reloaded policy not in force")
+	}
+
+	write(`{"rules": [`)
+	if err := a.Reload(); err == nil {
+		t.Fatal("expected an error reloading a broken policy")
+	}
+	if !a.Allowed("a", Write, "x") {
+		t.Fatal("a broken policy should leave the old one in force")
+	}
+}
@@ -0,0 +1,97 @@
+package authz
+
+import (
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+// OpsOf returns what receiving m asks us to do, and to which
+// paths. Messages that name no path, such as Limits, ask for
+// nothing; so every message that does name one must be here,
+// as must every message asking for Admin.
+func OpsOf(m interface{}) (Op, []string) {
+	switch m := m.(type) {
+	case *pb.BigFileChunk:
+		return Write, []string{m.Filepath}
+	case *pb.SessionMsg:
+		var paths []string
+		if m.Header != nil {
+			paths = append(paths, m.Header.Filepath)
+		}
+		if m.Chunk != nil {
+			paths = append(paths, m.Chunk.Filepath)
+		}
+		return Write, paths
+	case *pb.GetRequest:
+		return Read, []string{m.Filepath}
+	case *pb.DeleteRequest:
+		return Delete, []string{m.Filepath}
+	case *pb.SSHKey, *pb.SSHKeyQuery:
+		return Admin, nil
+	}
+
+	return "", nil
+}
+
+// check returns a PermissionDenied error unless the caller
+// on ctx may do what m asks.
+func (a *Authorizer) check(ctx context.Context, m interface{}) error {
+	op, paths := OpsOf(m)
+	if op == "" {
+		return nil
+	}
+
+	who := identity.FromContext(ctx)
+	if op == Admin {
+		if !a.Allowed(who, Admin, "") {
+			return status.Errorf(codes.PermissionDenied, "'%s' may not administer this server", who)
+		}
+		return nil
+	}
+
+	for _, p := range paths {
+		if !a.Allowed(who, op, p) {
+			return status.Errorf(codes.PermissionDenied, "'%s' may not %s '%s'", who, op, p)
+		}
+	}
+
+	return nil
+}
+
+// UnaryInterceptor checks each unary request against the policy.
+func (a *Authorizer) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := a.check(ctx, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor checks each message received on a stream
+// against the policy, ending the stream at the first one that
+// is not allowed.
+func (a *Authorizer) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &checkedStream{ServerStream: ss, a: a})
+	}
+}
+
+type checkedStream struct {
+	grpc.ServerStream
+	a *Authorizer
+}
+
+func (s *checkedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return s.a.check(s.Context(), m)
+}
@@ -0,0 +1,155 @@
+// Package certmgr serves a TLS certificate that can be replaced
+// on disk while we are running: new handshakes get the new pair,
+// and connections already up carry on with the old one.
+package certmgr
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// PollInterval is how often Watch looks for changed files.
+const PollInterval = 10 * time.Second
+
+// WarnBefore is how long before expiry we start complaining,
+// and warnEvery how often we repeat ourselves.
+const (
+	WarnBefore = 30 * 24 * time.Hour
+	warnEvery  = time.Hour
+)
+
+// Manager holds the certificate and key loaded from CertPath
+// and KeyPath.
+type Manager struct {
+	CertPath string
+	KeyPath  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	notAfter time.Time
+	stamp    string
+	lastWarn time.Time
+}
+
+// New returns a Manager serving the pair in certPath and keyPath.
+func New(certPath, keyPath string) (*Manager, error) {
+	m := &Manager{CertPath: certPath, KeyPath: keyPath}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// GetCertificate is for tls.Config.GetCertificate.
+func (m *Manager) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.cert, nil
+}
+
+// NotAfter is when the certificate being served expires.
+func (m *Manager) NotAfter() time.Time {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.notAfter
+}
+
+// Reload reads the pair again. If that fails, say because we
+// caught the files half way through being replaced, we keep
+// serving the pair we had.
+func (m *Manager) Reload() error {
+	stamp, err := m.fileStamp()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(m.CertPath, m.KeyPath)
+	if err != nil {
+		return fmt.Errorf("certmgr: '%s': %v", m.CertPath, err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("certmgr: '%s': %v", m.CertPath, err)
+	}
+	cert.Leaf = leaf
+
+	m.mu.Lock()
+	m.cert = &cert
+	m.notAfter = leaf.NotAfter
+	m.stamp = stamp
+	m.lastWarn = time.Time{}
+	m.mu.Unlock()
+
+	log.Printf("certmgr: serving '%s' for '%s', good until %v", m.CertPath, leaf.Subject.CommonName, leaf.NotAfter.UTC())
+	m.checkExpiry(time.Now())
+
+	return nil
+}
+
+// fileStamp sums up the sizes and modification times of the
+// pair, so we can tell when either changes.
+func (m *Manager) fileStamp() (string, error) {
+	var stamp string
+	for _, p := range []string{m.CertPath, m.KeyPath} {
+		fi, err := os.Stat(p)
+		if err != nil {
+			return "", err
+		}
+		stamp += fmt.Sprintf("%v:%v;", fi.Size(), fi.ModTime().UnixNano())
+	}
+
+	return stamp, nil
+}
+
+// Watch reloads the pair whenever the files change, until
+// stop is closed, and keeps warning as expiry draws near.
+func (m *Manager) Watch(stop <-chan struct{}) {
+	tick := time.NewTicker(PollInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-tick.C:
+		case <-stop:
+			return
+		}
+
+		stamp, err := m.fileStamp()
+		m.mu.RLock()
+		changed := err == nil && stamp != m.stamp
+		m.mu.RUnlock()
+
+		if changed {
+			if err := m.Reload(); err != nil {
+				log.Printf("certmgr: keeping the certificate we have: %v", err)
+			}
+		}
+
+		m.checkExpiry(time.Now())
+	}
+}
+
+func (m *Manager) checkExpiry(now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	left := m.notAfter.Sub(now)
+	if left > WarnBefore || now.Sub(m.lastWarn) < warnEvery {
+		return
+	}
+	m.lastWarn = now
+
+	if left <= 0 {
+		log.Printf("certmgr: WARNING: '%s' EXPIRED at %v; clients will refuse it", m.CertPath, m.notAfter.UTC())
+		return
+	}
+	log.Printf("certmgr: WARNING: '%s' expires in %v, at %v", m.CertPath, left.Round(time.Minute), m.notAfter.UTC())
+}
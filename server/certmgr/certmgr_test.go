@@ -0,0 +1,88 @@
+package certmgr
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writePair writes a self-signed certificate for cn, good for validFor.
+func writePair(t *testing.T, certPath, keyPath, cn string, validFor time.Duration) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(validFor),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kder, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: kder}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func served(t *testing.T, m *Manager) string {
+	cert, err := m.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return cert.Leaf.Subject.CommonName
+}
+
+func TestReload(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "server.pem"), filepath.Join(dir, "server.key")
+
+	writePair(t, certPath, keyPath, "old", 24*time.Hour)
+	m, err := New(certPath, keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if served(t, m) != "old" {
+		t.Fatal("not serving the first pair")
+	}
+
+	writePair(t, certPath, keyPath, "new", 48*time.Hour)
+	if err := m.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if served(t, m) != "new" {
+		t.Fatal("not serving the rotated pair")
+	}
+	if time.Until(m.NotAfter()) < 47*time.Hour {
+		t.Fatalf("NotAfter %v is that of the old pair", m.NotAfter())
+	}
+
+	// half way through a rotation: the key no longer matches.
+	writePair(t, filepath.Join(dir, "other.pem"), keyPath, "other", time.Hour)
+	if err := m.Reload(); err == nil {
+		t.Fatal("loaded a mismatched pair")
+	}
+	if served(t, m) != "new" {
+		t.Fatal("a failed reload should leave the old pair in service")
+	}
+}
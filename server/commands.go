@@ -0,0 +1,386 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/devops-filetransfer/filetransfer/server/audit"
+	"github.com/devops-filetransfer/filetransfer/server/authz"
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	"github.com/devops-filetransfer/filetransfer/server/pki"
+	"github.com/devops-filetransfer/filetransfer/server/seal"
+	"github.com/devops-filetransfer/filetransfer/server/sshkeys"
+	"github.com/devops-filetransfer/filetransfer/server/token"
+)
+
+// runCommand carries out one of the server's housekeeping
+// commands, given in place of the usual flags:
+//
+//	cert init [-dir <dir>] [-host <names>] [-days 365]
+//	cert sign -csr <file> [-out <file>] [-dir <dir>] [-days 365]
+//	token keygen [-alg EdDSA|HS256] -out <file> [-pub <file>]
+//	token issue -key <file> -sub <subject> [-ops read,write,delete,admin] [-ttl 1h]
+//	ssh key add [-keys <file>] -user <login> <key.pub>
+//	ssh key list [-keys <file>] [-user <login>]
+//	ssh key revoke [-keys <file>] -user <login> -fingerprint <SHA256:...>
+//	store keygen -out <file>
+//	audit verify -log <file>
+//	config validate|print [flags]
+func runCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("usage: %s cert init|sign, %s token keygen|issue, %s ssh key add|list|revoke, %s store keygen, %s audit verify or %s config validate|print [flags]", ProgramName, ProgramName, ProgramName, ProgramName, ProgramName, ProgramName)
+	}
+
+	switch args[0] + " " + args[1] {
+	case "cert init":
+		return certInit(args[2:])
+	case "cert sign":
+		return certSign(args[2:])
+	case "token keygen":
+		return tokenKeygen(args[2:])
+	case "token issue":
+		return tokenIssue(args[2:])
+	case "ssh key":
+		return sshKey(args[2:])
+	case "store keygen":
+		return storeKeygen(args[2:])
+	case "audit verify":
+		return auditVerify(args[2:])
+	case "config validate":
+		return configValidate(args[2:])
+	case "config print":
+		return configPrint(args[2:])
+	}
+
+	return fmt.Errorf("unknown command '%s'", strings.Join(args[:2], " "))
+}
+
+// caValidFor is how long our CA lasts; the certificates it
+// issues last for -days.
+const caValidFor = 10 * 365 * 24 * time.Hour
+
+func certInit(args []string) error {
+	fs := flag.NewFlagSet("cert init", flag.ContinueOnError)
+	dir := fs.String("dir", pki.DefaultDir(), "directory to keep the CA and certificates in")
+	hosts := fs.String("host", "127.0.0.1,localhost", "comma separated names and IP addresses clients will reach this server by")
+	days := fs.Int("days", 365, "days the server certificate is good for")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *days <= 0 {
+		return fmt.Errorf("-days must be positive")
+	}
+
+	names := serverNames(*hosts)
+	if len(names) == 0 {
+		return fmt.Errorf("-host names no hosts")
+	}
+
+	ca, err := pki.InitCA(*dir, caValidFor)
+	if err != nil {
+		return err
+	}
+	if err := ca.IssueServer(*dir, names, time.Duration(*days)*24*time.Hour); err != nil {
+		return err
+	}
+
+	fmt.Printf("CA certificate:     %s\n", filepath.Join(*dir, pki.CAFile))
+	fmt.Printf("server certificate: %s, for %s\n", filepath.Join(*dir, pki.ServerCertFile), strings.Join(names, ", "))
+	fmt.Printf("give clients a copy of %s; sign their requests with '%s cert sign'\n", pki.CAFile, ProgramName)
+
+	return nil
+}
+
+// serverNames splits the -host list, standing in this host's
+// own names for an address that means any interface.
+func serverNames(hosts string) []string {
+	var names []string
+	for _, h := range strings.Split(hosts, ",") {
+		h = strings.TrimSpace(h)
+		if ip := net.ParseIP(h); h == "" || (ip != nil && ip.IsUnspecified()) {
+			if hn, err := os.Hostname(); err == nil {
+				names = append(names, hn)
+			}
+			names = append(names, "localhost", "127.0.0.1", "::1")
+			continue
+		}
+		names = append(names, h)
+	}
+
+	return names
+}
+
+func certSign(args []string) error {
+	fs := flag.NewFlagSet("cert sign", flag.ContinueOnError)
+	dir := fs.String("dir", pki.DefaultDir(), "directory the CA is kept in")
+	csrPath := fs.String("csr", "", "client certificate request to sign, from 'client cert request'")
+	out := fs.String("out", "", "file to write the client certificate to (default: next to the request, as .pem)")
+	days := fs.Int("days", 365, "days the client certificate is good for")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *csrPath == "" {
+		return fmt.Errorf("-csr is required")
+	}
+	if *days <= 0 {
+		return fmt.Errorf("-days must be positive")
+	}
+	if *out == "" {
+		*out = strings.TrimSuffix(*csrPath, filepath.Ext(*csrPath)) + ".pem"
+	}
+
+	ca, err := pki.LoadCA(*dir)
+	if err != nil {
+		return fmt.Errorf("no CA in '%s'; run '%s cert init' first: %v", *dir, ProgramName, err)
+	}
+
+	csr, err := os.ReadFile(*csrPath)
+	if err != nil {
+		return err
+	}
+	cert, data, err := ca.SignClient(csr, time.Duration(*days)*24*time.Hour)
+	if err != nil {
+		return err
+	}
+	if err := writeNew(*out, data, 0644); err != nil {
+		return err
+	}
+
+	fmt.Printf("signed '%s' for '%s', until %v\n", *out, identity.FromCert(cert), cert.NotAfter.UTC())
+
+	return nil
+}
+
+func tokenKeygen(args []string) error {
+	fs := flag.NewFlagSet("token keygen", flag.ContinueOnError)
+	alg := fs.String("alg", token.EdDSA, "signing algorithm: EdDSA or HS256")
+	out := fs.String("out", "", "file to write the new key to")
+	pub := fs.String("pub", "", "with EdDSA, also write the public key here, for servers that only check tokens")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return fmt.Errorf("-out is required")
+	}
+
+	data, err := token.GenerateKey(*alg)
+	if err != nil {
+		return err
+	}
+	if err := writeNew(*out, data, 0600); err != nil {
+		return err
+	}
+
+	if *pub != "" {
+		k, err := token.LoadKey(*out)
+		if err != nil {
+			return err
+		}
+		data, err := k.PublicKey()
+		if err != nil {
+			return err
+		}
+		if err := writeNew(*pub, data, 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func tokenIssue(args []string) error {
+	fs := flag.NewFlagSet("token issue", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "key file to sign with")
+	sub := fs.String("sub", "", "subject: who the bearer is")
+	ops := fs.String("ops", "read,write", "comma separated operations the bearer may perform: read, write, delete, admin")
+	ttl := fs.Duration("ttl", time.Hour, "how long the token is good for")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keyPath == "" || *sub == "" {
+		return fmt.Errorf("-key and -sub are required")
+	}
+	if *ttl <= 0 {
+		return fmt.Errorf("-ttl must be positive")
+	}
+
+	c := &token.Claims{Subject: *sub}
+	for _, op := range strings.Split(*ops, ",") {
+		switch o := authz.Op(strings.TrimSpace(op)); o {
+		case authz.Read, authz.Write, authz.Delete, authz.Admin:
+			c.Ops = append(c.Ops, o)
+		default:
+			return fmt.Errorf("unknown operation '%s'; expected read, write, delete or admin", op)
+		}
+	}
+
+	k, err := token.LoadKey(*keyPath)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	c.IssuedAt = now.Unix()
+	c.Expiry = now.Add(*ttl).Unix()
+
+	tok, err := k.Issue(c)
+	if err != nil {
+		return err
+	}
+	fmt.Println(tok)
+
+	return nil
+}
+
+// sshKey manages the keys SSH logins may use, by editing the
+// key file directly; a running server picks up the change at
+// the next login.
+func sshKey(args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("usage: %s ssh key add|list|revoke [flags]", ProgramName)
+	}
+
+	fs := flag.NewFlagSet("ssh key "+args[0], flag.ContinueOnError)
+	path := fs.String("keys", sshkeys.DefaultPath(), "the key file, as given to the server's -ssh_keys")
+	user := fs.String("user", "", "the SSH login")
+	fp := fs.String("fingerprint", "", "with revoke, the SHA256 fingerprint of the key, as shown by list")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	keys, err := sshkeys.Open(*path)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "add":
+		if *user == "" || fs.NArg() != 1 {
+			return fmt.Errorf("usage: %s ssh key add -user <login> <key.pub>", ProgramName)
+		}
+		line, err := os.ReadFile(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		k, err := keys.Add(*user, line)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("'%s' may now log in with %s key %s\n", k.User, k.Type, k.Fingerprint)
+
+	case "list":
+		list, err := keys.List(*user)
+		if err != nil {
+			return err
+		}
+		for _, k := range list {
+			fmt.Printf("%-16s %-12s %s  %s  %s\n", k.User, k.Type, k.Fingerprint, k.Added.Format(time.RFC3339), k.Comment)
+		}
+
+	case "revoke":
+		if *user == "" || *fp == "" {
+			return fmt.Errorf("usage: %s ssh key revoke -user <login> -fingerprint <SHA256:...>", ProgramName)
+		}
+		k, err := keys.Revoke(*user, *fp)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("revoked %s key %s for '%s'\n", k.Type, k.Fingerprint, k.User)
+
+	default:
+		return fmt.Errorf("unknown command 'ssh key %s'; expected add, list or revoke", args[0])
+	}
+
+	return nil
+}
+
+func storeKeygen(args []string) error {
+	fs := flag.NewFlagSet("store keygen", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write the new master key to")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return fmt.Errorf("-out is required")
+	}
+
+	data, err := seal.GenerateKey()
+	if err != nil {
+		return err
+	}
+
+	return writeNew(*out, data, 0600)
+}
+
+// auditVerify checks the hash chain of the audit log and its
+// rotated files.
+func auditVerify(args []string) error {
+	fs := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	path := fs.String("log", "", "the audit log, as given to -audit_log")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("-log is required")
+	}
+
+	sum, err := audit.Verify(*path)
+	if err != nil {
+		return err
+	}
+	if sum.Records == 0 {
+		return fmt.Errorf("no audit records at '%s'", *path)
+	}
+
+	fmt.Printf("chain intact: %v records, seq %v to %v, in %v files\n", sum.Records, sum.First, sum.Last, sum.Files)
+	if sum.First > 1 {
+		fmt.Printf("records before seq %v have been rotated away\n", sum.First)
+	}
+	fmt.Printf("last hash %s; keep it elsewhere to detect records cut from the end\n", sum.LastHash)
+
+	return nil
+}
+
+func configValidate(args []string) error {
+	cfg, _, eff, err := loadConfig(args, flag.ContinueOnError)
+	if err != nil {
+		return err
+	}
+	if err := cfg.ValidateConfig(); err != nil {
+		return err
+	}
+
+	fmt.Printf("valid, from %s, the environment and the flags given\n", eff.Source())
+
+	return nil
+}
+
+func configPrint(args []string) error {
+	_, _, eff, err := loadConfig(args, flag.ContinueOnError)
+	if err != nil {
+		return err
+	}
+
+	return eff.Print(os.Stdout)
+}
+
+// writeNew writes data to a new file at path, refusing to
+// overwrite one that is already there.
+func writeNew(path string, data []byte, perm os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, perm)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	return f.Close()
+}
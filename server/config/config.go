@@ -12,5 +12,7 @@ func NewServerConfig(myID string) *_grpc.ServerConfig {
 		ServerGotGetReply:   make(chan *api.BcastGetReply),
 		ServerGotSetRequest: make(chan *api.BcastSetRequest),
 		Halt:                idem.NewHalter(),
+		MaxMsgSize:          _grpc.DefaultMaxMsgSize,
+		DrainTimeout:        _grpc.DefaultDrainTimeout,
 	}
 }
@@ -0,0 +1,40 @@
+package fault
+
+import (
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestKindSurvivesTheTripToTheCaller(t *testing.T) {
+	sent := New(Checksum, "a/b", "'%s' chunk %v: checksum mismatch", "a/b", 3)
+
+	// what the caller gets back from grpc.
+	got := status.Convert(fmt.Errorf("wrapped: %w", sent)).Err()
+
+	if c := status.Code(got); c != codes.DataLoss {
+		t.Errorf("got code %v; want %v", c, codes.DataLoss)
+	}
+	fe, ok := From(got)
+	if !ok {
+		t.Fatalf("From(%v) found no fault", got)
+	}
+	if fe.Kind != Checksum || fe.Path != "a/b" {
+		t.Errorf("got %v of '%s'; want %v of 'a/b'", fe.Kind, fe.Path, Checksum)
+	}
+	if !Is(got, Checksum) || Is(got, Size) {
+		t.Errorf("Is got the kind of %v wrong", got)
+	}
+
+	// a transport failure keeps the code of the call that failed.
+	cut := New(Transport, "a/b", "sending: %w", status.Error(codes.DeadlineExceeded, "too slow"))
+	if c := status.Code(cut); c != codes.DeadlineExceeded {
+		t.Errorf("got code %v; want %v", c, codes.DeadlineExceeded)
+	}
+
+	if _, ok := From(status.Error(codes.Unavailable, "not ours")); ok {
+		t.Errorf("From found a fault in a plain status")
+	}
+}
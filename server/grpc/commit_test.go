@@ -0,0 +1,47 @@
+package grpc
+
+import (
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/filetransfer/server/api"
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+)
+
+// keyRecorder is an api.LocalGetSet that notes the keys set.
+type keyRecorder struct {
+	inventory
+	keys map[string]bool
+}
+
+func (k *keyRecorder) LocalSet(ki *api.KeyInv) error {
+	k.keys[string(ki.Key)] = true
+	return nil
+}
+
+func TestInventoryKeysAreEachTenantsOwn(t *testing.T) {
+	inv := &keyRecorder{keys: make(map[string]bool)}
+	s := NewPeerServerClass(inv, &ServerConfig{})
+
+	for _, who := range []string{"alice", "bob", ""} {
+		r, err := newReceiver()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := context.Background()
+		if who != "" {
+			ctx = identity.NewContext(ctx, who)
+		}
+		if err := s.commit(ctx, r, "./same/path"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(inv.keys) != 3 {
+		t.Errorf("three tenants' 'same/path' went in under %v keys: %v", len(inv.keys), inv.keys)
+	}
+	if !inv.keys["alice\x00same/path"] {
+		t.Errorf("alice's file is not under her tenant and its clean path: %v", inv.keys)
+	}
+}
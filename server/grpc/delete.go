@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/filetransfer/server/audit"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+// DeleteFile implements pb.PeerServer; it removes one of the
+// caller's stored files.
+func (s *PeerServerClass) DeleteFile(ctx context.Context, req *pb.DeleteRequest) (reply *pb.DeleteReply, err error) {
+	defer func(start time.Time) {
+		s.record(ctx, &audit.Record{Op: "DeleteFile", Path: req.Filepath}, start, err)
+	}(time.Now())
+
+	if s.cfg.Store == nil {
+		return nil, fmt.Errorf("this server does not keep files; start it with -store")
+	}
+
+	if err := s.cfg.Store.Remove(s.tenant(ctx), req.Filepath); err != nil {
+		return nil, err
+	}
+
+	s.logger(ctx).Info("deleted file", "path", req.Filepath)
+
+	return &pb.DeleteReply{Filepath: req.Filepath}, nil
+}
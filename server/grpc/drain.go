@@ -0,0 +1,74 @@
+package grpc
+
+import (
+	"log/slog"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultDrainTimeout is how long calls in flight get to finish
+// once we are asked to shut down.
+const DefaultDrainTimeout = 30 * time.Second
+
+// drainGrace is how long, after DrainTimeout, calls get to stop at
+// their next chunk before they are cut off.
+const drainGrace = 5 * time.Second
+
+// Drain shuts GrpcServer down gracefully. We tell health checkers
+// we are no longer serving and take no new calls, but those in
+// flight get DrainTimeout to finish. Any still going after that
+// are stopped at their next chunk, and any stuck waiting for a
+// chunk are cut off drainGrace later. We keep nothing of the
+// uploads stopped, nor of those parked for resuming, as those
+// live only in memory: the client must send them again in full.
+// Halt has had its stop requested by the time Drain returns.
+func (c *ServerConfig) Drain() {
+	defer c.Halt.RequestStop()
+	if c.Cls != nil {
+		defer c.Cls.discardParked()
+	}
+
+	if c.Health != nil {
+		c.Health.Shutdown()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		c.GrpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		return
+	case <-time.After(c.DrainTimeout):
+	}
+
+	slog.Warn("calls still running; stopping them at their next chunk", "after", c.DrainTimeout)
+	c.Halt.RequestStop()
+
+	select {
+	case <-stopped:
+	case <-time.After(drainGrace):
+		slog.Warn("calls still running; cutting them off", "after", c.DrainTimeout+drainGrace)
+		c.GrpcServer.Stop()
+		<-stopped
+	}
+}
+
+// draining returns the error that stops a call on path once Drain
+// wants calls in flight to stop; nil until then. An empty path is
+// for a call between files.
+func (s *PeerServerClass) draining(path string) error {
+	if s.cfg.Halt == nil || !s.cfg.Halt.IsStopRequested() {
+		return nil
+	}
+
+	if path == "" {
+		return status.Errorf(codes.Unavailable, "server is shutting down; try again later")
+	}
+
+	return status.Errorf(codes.Unavailable, "server is shutting down; '%s' was stopped before it was done; try again later", path)
+}
@@ -0,0 +1,95 @@
+package grpc
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/idem"
+)
+
+func TestDrainStopsTransfersLeftRunning(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ServerConfig{
+		MaxMsgSize:   DefaultMaxMsgSize,
+		Halt:         idem.NewHalter(),
+		DrainTimeout: 50 * time.Millisecond,
+		GrpcServer:   grpc.NewServer(),
+	}
+	s := NewPeerServerClass(nil, cfg)
+	pb.RegisterPeerServer(cfg.GrpcServer, s)
+	go cfg.GrpcServer.Serve(lis)
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	stream, err := pb.NewPeerClient(conn).TransferFile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// one chunk in, and then the client dawdles.
+	data := []byte("hello")
+	first := &pb.BigFileChunk{
+		Filepath:          "f",
+		Data:              data,
+		SizeInBytes:       int64(len(data)),
+		Blake2B:           blake2bOfBytes(data),
+		Blake2BCumulative: blake2bOfBytes(data),
+	}
+	if err := stream.Send(first); err != nil {
+		t.Fatal(err)
+	}
+	if ack, err := stream.Recv(); err != nil || ack.Status != pb.AckStatus_ACK {
+		t.Fatalf("first chunk got %v, %v", ack, err)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		cfg.Drain()
+		close(drained)
+	}()
+	for !cfg.Halt.IsStopRequested() {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	next := *first
+	next.ChunkNumber = 1
+	if err := stream.Send(&next); err != nil {
+		t.Fatal(err)
+	}
+	ack, err := stream.Recv()
+	if err != nil || ack.Status != pb.AckStatus_FATAL || ack.BytesVerified != 5 || !strings.Contains(ack.Err, "shutting down") {
+		t.Fatalf("draining got %v, %v", ack, err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Fatalf("stream ended with %v; want Unavailable", err)
+	}
+
+	select {
+	case <-drained:
+	case <-time.After(drainGrace):
+		t.Fatal("Drain did not return once the stream was stopped")
+	}
+
+	// and nothing of it is kept for a resume.
+	s.parkMut.Lock()
+	defer s.parkMut.Unlock()
+	if n := len(s.parked); n != 0 {
+		t.Errorf("%v uploads kept after being stopped", n)
+	}
+}
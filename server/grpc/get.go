@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/devops-filetransfer/blake2b"
+	"github.com/devops-filetransfer/filetransfer/server/audit"
+	"github.com/devops-filetransfer/filetransfer/server/fault"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/filetransfer/server/sparse"
+)
+
+// chunkOverhead is the room we leave in each gRPC message
+// for the BigFileChunk fields other than Data.
+const chunkOverhead = 64 << 10
+
+// GetFile implements pb.PeerServer; it streams a stored file back
+// to the client, chunked and checksummed just as the client sends
+// them to us. The first chunk carries the file's attributes and
+// any free-form metadata it was stored with, and the last its
+// signed manifest, if it was stored with one. Given an Offset, it
+// carries on from there, as from NextChunk.
+func (s *PeerServerClass) GetFile(req *pb.GetRequest, stream pb.Peer_GetFileServer) (err error) {
+	var sent int64
+	var sum []byte
+	defer func(start time.Time) {
+		rec := &audit.Record{Op: "GetFile", Path: req.Filepath, Size: sent}
+		if err == nil {
+			rec.Blake2b = fmt.Sprintf("%x", sum)
+		}
+		s.record(stream.Context(), rec, start, err)
+	}(time.Now())
+
+	if s.cfg.Store == nil {
+		return fmt.Errorf("this server does not keep files; start it with -store")
+	}
+
+	f, a, err := s.cfg.Store.Open(s.tenant(stream.Context()), req.Filepath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	total := f.Size()
+	if req.Offset < 0 || req.NextChunk < 0 || req.Offset > total || (req.Offset == total && total > 0) {
+		return fault.New(fault.Protocol, req.Filepath, "'%s' is %v bytes; cannot resume it from byte %v, chunk %v", req.Filepath, total, req.Offset, req.NextChunk)
+	}
+
+	signed, err := s.cfg.Store.Signature(s.tenant(stream.Context()), req.Filepath)
+	if err != nil {
+		return err
+	}
+
+	md, err := s.cfg.Store.Metadata(s.tenant(stream.Context()), req.Filepath)
+	if err != nil {
+		return err
+	}
+
+	chunkSz := int64(s.cfg.MaxMsgSize - chunkOverhead)
+	if req.MaxChunkSize > 0 && req.MaxChunkSize < chunkSz {
+		chunkSz = req.MaxChunkSize
+	}
+
+	segs, err := f.Segments()
+	if err != nil {
+		return err
+	}
+
+	hasher, err := blake2b.New(nil)
+	if err != nil {
+		return err
+	}
+
+	start := uint64(time.Now().UnixNano())
+	chunkNumber := req.NextChunk
+	sent = req.Offset
+
+	for _, seg := range segs {
+		off := seg.Offset
+
+		// hash what the client already has, as it did.
+		if had := req.Offset - seg.Offset; had > 0 {
+			if had > seg.Length {
+				had = seg.Length
+			}
+			switch {
+			case seg.Hole && had < seg.Length:
+				return fault.New(fault.Protocol, req.Filepath, "'%s' has no chunk that ends at byte %v", req.Filepath, req.Offset)
+			case seg.Hole:
+				sparse.HashZeros(hasher, had)
+			default:
+				if _, err := io.Copy(hasher, io.NewSectionReader(f, seg.Offset, had)); err != nil {
+					return fmt.Errorf("reading '%s' at offset %v: %v", req.Filepath, seg.Offset, err)
+				}
+			}
+			if had == seg.Length {
+				continue
+			}
+			off += had
+		}
+
+		for off < seg.Offset+seg.Length || seg.Length == 0 {
+			if err := s.draining(req.Filepath); err != nil {
+				return err
+			}
+
+			nk := &pb.BigFileChunk{
+				Filepath:              req.Filepath,
+				OriginalStartSendTime: start,
+				ChunkNumber:           chunkNumber,
+			}
+			if chunkNumber == 0 {
+				nk.Attr = a
+				nk.Metadata = md
+			}
+
+			if seg.Hole {
+				nk.HoleSize = seg.Length
+				sparse.HashZeros(hasher, nk.HoleSize)
+			} else {
+				n := seg.Offset + seg.Length - off
+				if n > chunkSz {
+					n = chunkSz
+				}
+
+				nk.Data = make([]byte, n)
+				if _, err := f.ReadAt(nk.Data, off); err != nil && !(err == io.EOF && n == 0) {
+					return fmt.Errorf("reading '%s' at offset %v: %v", req.Filepath, off, err)
+				}
+				nk.SizeInBytes = n
+				hasher.Write(nk.Data)
+			}
+
+			n := nk.SizeInBytes + nk.HoleSize
+			off += n
+			sent += n
+			chunkNumber++
+
+			nk.Blake2B = blake2bOfBytes(nk.Data)
+			nk.Blake2BCumulative = hasher.Sum(nil)
+			nk.IsLastChunk = sent == total
+			if nk.IsLastChunk {
+				nk.Signature = signed
+			}
+			nk.SendTime = uint64(time.Now().UnixNano())
+
+			if err := stream.Send(nk); err != nil {
+				return err
+			}
+
+			if nk.IsLastChunk {
+				sum = nk.Blake2BCumulative
+				s.logger(stream.Context()).Info("sent file", "path", req.Filepath, "chunks", chunkNumber, "bytes", sent, "blake2b", fmt.Sprintf("%x", nk.Blake2BCumulative))
+				return nil
+			}
+		}
+	}
+
+	return fmt.Errorf("'%s' changed while we were sending it", req.Filepath)
+}
@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// HealthInterval is how often Health.Watch checks that we are
+// ready to serve.
+const HealthInterval = 5 * time.Second
+
+// Health serves the standard grpc.health.v1 service, saying that
+// we, and each service we offer, are serving only while every
+// one of the checks added to it passes.
+type Health struct {
+	*health.Server
+
+	mu       sync.Mutex
+	checks   map[string]func() error // by what they check
+	services []string
+	serving  bool
+	why      string // what last failed, if not serving
+}
+
+// NewHealth returns a Health that says we are not serving until
+// it is registered, and has checked.
+func NewHealth() *Health {
+	h := &Health{
+		Server: health.NewServer(),
+		checks: make(map[string]func() error),
+	}
+	h.Server.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return h
+}
+
+// Add has h check what, with check, before saying we are serving.
+func (h *Health) Add(what string, check func() error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[what] = check
+}
+
+// Register puts h on s, alongside the services already there,
+// and checks them.
+func (h *Health) Register(s *grpc.Server) {
+	h.mu.Lock()
+	for name := range s.GetServiceInfo() {
+		h.services = append(h.services, name)
+	}
+	h.mu.Unlock()
+
+	healthpb.RegisterHealthServer(s, h.Server)
+	h.Update()
+}
+
+// Update runs the checks, and reports what they say.
+func (h *Health) Update() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var failed []string
+	for _, check := range h.checks {
+		if err := check(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	sort.Strings(failed)
+
+	why := strings.Join(failed, "; ")
+	status := healthpb.HealthCheckResponse_SERVING
+	if len(failed) > 0 {
+		status = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	// only say so when it changes.
+	if serving := len(failed) == 0; serving != h.serving || why != h.why {
+		if serving {
+			slog.Info("health: serving")
+		} else {
+			slog.Warn("health: not serving", "why", why)
+		}
+		h.serving, h.why = serving, why
+	}
+
+	h.Server.SetServingStatus("", status)
+	for _, name := range h.services {
+		h.Server.SetServingStatus(name, status)
+	}
+}
+
+// Watch updates h every HealthInterval, until stop is closed.
+func (h *Health) Watch(stop <-chan struct{}) {
+	tick := time.NewTicker(HealthInterval)
+	defer tick.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-tick.C:
+			h.Update()
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package grpc
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+func TestHealthFollowsChecks(t *testing.T) {
+	s := grpc.NewServer()
+	pb.RegisterPeerServer(s, NewPeerServerClass(nil, &ServerConfig{}))
+
+	var broken error
+	h := NewHealth()
+	h.Add("store", func() error { return broken })
+	h.Register(s)
+
+	status := func(service string) healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := h.Check(context.Background(), &healthpb.HealthCheckRequest{Service: service})
+		if err != nil {
+			t.Fatalf("'%s': %v", service, err)
+		}
+		return resp.Status
+	}
+
+	for _, service := range []string{"", "streambigfile.Peer"} {
+		if got := status(service); got != healthpb.HealthCheckResponse_SERVING {
+			t.Fatalf("'%s' is %v with all checks passing", service, got)
+		}
+	}
+
+	broken = fmt.Errorf("disk full")
+	h.Update()
+	for _, service := range []string{"", "streambigfile.Peer"} {
+		if got := status(service); got != healthpb.HealthCheckResponse_NOT_SERVING {
+			t.Fatalf("'%s' is %v with the store broken", service, got)
+		}
+	}
+
+	broken = nil
+	h.Update()
+	if got := status(""); got != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("still %v once the store recovered", got)
+	}
+}
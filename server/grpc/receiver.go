@@ -0,0 +1,210 @@
+package grpc
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/blake2b"
+	"github.com/devops-filetransfer/filetransfer/server/fault"
+	"github.com/devops-filetransfer/filetransfer/server/jail"
+	"github.com/devops-filetransfer/filetransfer/server/metrics"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/filetransfer/server/sparse"
+	"github.com/devops-filetransfer/filetransfer/server/store"
+	"github.com/devops-filetransfer/filetransfer/server/tracing"
+)
+
+// receiver verifies the chunks of a single incoming file,
+// in order, and keeps the running whole-file checksum.
+type receiver struct {
+	hasher     hash.Hash
+	nextChunk  int64
+	bytesSeen  int64
+	chunkCount int64
+
+	// w is where verified chunks go; nil if we keep nothing.
+	w    *store.Writer
+	attr *pb.FileAttr
+
+	// signed is the signed manifest the file came with, if any,
+	// and signer who signed it, once we have checked.
+	signed []byte
+	signer string
+
+	// metadata is the free-form metadata to keep with the file.
+	metadata map[string]string
+}
+
+func newReceiver() (*receiver, error) {
+	h, err := blake2b.New(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &receiver{hasher: h}, nil
+}
+
+// open starts storing the file as tenant's path in st, to be
+// given the metadata a when committed. A nil st stores nothing,
+// but the path must pass muster all the same.
+func (r *receiver) open(st *store.Store, tenant, path string, a *pb.FileAttr) error {
+	if _, err := jail.Clean(path); err != nil {
+		return err
+	}
+
+	r.attr = a
+	if st == nil {
+		return nil
+	}
+
+	w, err := st.Create(tenant, path)
+	if err != nil {
+		return err
+	}
+	r.w = w
+
+	return nil
+}
+
+// commit makes the stored file permanent, along with its
+// signed manifest and metadata.
+func (r *receiver) commit() error {
+	if r.w == nil {
+		return nil
+	}
+
+	if r.signed != nil {
+		r.w.Sign(r.signed)
+	}
+	r.w.SetMetadata(r.metadata)
+	err := r.w.Commit(r.attr)
+	r.w = nil
+
+	return err
+}
+
+// abort discards anything stored and not yet committed.
+func (r *receiver) abort() {
+	if r.w != nil {
+		_ = r.w.Abort()
+		r.w = nil
+	}
+}
+
+// badChunkError means a chunk of Path failed a check that covers
+// only that chunk. Nothing was consumed, so the sender may
+// retransmit it. Kind says which check it failed.
+type badChunkError struct {
+	Kind        fault.Kind
+	Path        string
+	ChunkNumber int64
+	Reason      string
+}
+
+func (e *badChunkError) Error() string {
+	return fmt.Sprintf("chunk %v: %s", e.ChunkNumber, e.Reason)
+}
+
+// Unwrap is e as a *fault.Error, for where it ends the transfer.
+func (e *badChunkError) Unwrap() error {
+	return &fault.Error{Kind: e.Kind, Path: e.Path, Msg: e.Error()}
+}
+
+// accept checks nk and, if it passes, folds it into the
+// whole-file checksum and stores it. A *badChunkError return
+// leaves the receiver untouched; any other error is fatal to
+// the file.
+func (r *receiver) accept(ctx context.Context, nk *pb.BigFileChunk) error {
+	chunk := attribute.Int64("chunk", nk.ChunkNumber)
+
+	_, span := tracing.Start(ctx, "verify", chunk)
+	err := r.verify(nk)
+	tracing.End(span, err)
+	if err != nil {
+		return err
+	}
+
+	_, span = tracing.Start(ctx, "write", chunk)
+	err = r.write(nk)
+	tracing.End(span, err)
+
+	return err
+}
+
+// verify checks nk, and folds it into the whole-file checksum.
+func (r *receiver) verify(nk *pb.BigFileChunk) error {
+	if nk.HoleSize < 0 || (nk.HoleSize > 0 && len(nk.Data) > 0) {
+		metrics.ChecksumFailed(metrics.Size)
+		return &badChunkError{
+			Kind:        fault.Protocol,
+			Path:        nk.Filepath,
+			ChunkNumber: nk.ChunkNumber,
+			Reason:      fmt.Sprintf("hole of %v bytes must come without data, got %v bytes", nk.HoleSize, len(nk.Data)),
+		}
+	}
+
+	if nk.SizeInBytes != int64(len(nk.Data)) {
+		metrics.ChecksumFailed(metrics.Size)
+		return &badChunkError{
+			Kind:        fault.Size,
+			Path:        nk.Filepath,
+			ChunkNumber: nk.ChunkNumber,
+			Reason:      fmt.Sprintf("%v == nk.SizeInBytes != int64(len(nk.Data)) == %v", nk.SizeInBytes, int64(len(nk.Data))),
+		}
+	}
+
+	if !bytes.Equal(blake2bOfBytes(nk.Data), nk.Blake2B) {
+		metrics.ChecksumFailed(metrics.Chunk)
+		return &badChunkError{
+			Kind:        fault.Checksum,
+			Path:        nk.Filepath,
+			ChunkNumber: nk.ChunkNumber,
+			Reason:      "bad .Data, checksum mismatch!",
+		}
+	}
+
+	// INVAR: the chunk is internally consistent.
+	_, _ = r.hasher.Write(nk.Data)
+	sparse.HashZeros(r.hasher, nk.HoleSize)
+	cumul := r.hasher.Sum(nil)
+	if !bytes.Equal(cumul, nk.Blake2BCumulative) {
+		metrics.ChecksumFailed(metrics.Cumulative)
+		return fault.New(fault.Checksum, nk.Filepath, "cumulative checksums failed at chunk %v of '%s'. Observed: '%x', expected: '%x'.", nk.ChunkNumber, nk.Filepath, cumul, nk.Blake2BCumulative)
+	}
+
+	return nil
+}
+
+// write stores the verified nk, and counts it.
+func (r *receiver) write(nk *pb.BigFileChunk) error {
+	if r.w != nil {
+		var err error
+		if nk.HoleSize > 0 {
+			err = r.w.Hole(nk.HoleSize)
+		} else {
+			_, err = r.w.Write(nk.Data)
+		}
+		if err != nil {
+			return fmt.Errorf("storing chunk %v of '%s': %v", nk.ChunkNumber, nk.Filepath, err)
+		}
+	}
+
+	if len(nk.Signature) > 0 {
+		r.signed = nk.Signature
+	}
+
+	r.bytesSeen += int64(len(nk.Data)) + nk.HoleSize
+	r.chunkCount++
+	r.nextChunk = nk.ChunkNumber + 1
+
+	return nil
+}
+
+// sum returns the whole-file checksum of everything accepted so far.
+func (r *receiver) sum() []byte {
+	return r.hasher.Sum(nil)
+}
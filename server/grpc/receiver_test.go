@@ -0,0 +1,84 @@
+package grpc
+
+import (
+	"errors"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/devops-filetransfer/filetransfer/server/fault"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+func TestReceiverNaksBadChunkWithoutConsumingIt(t *testing.T) {
+	r, err := newReceiver()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	good := &pb.BigFileChunk{
+		Filepath:    "f",
+		Data:        []byte("hello"),
+		SizeInBytes: 5,
+		Blake2B:     blake2bOfBytes([]byte("hello")),
+	}
+	good.Blake2BCumulative = blake2bOfBytes(good.Data)
+
+	bad := *good
+	bad.Blake2B = []byte("corrupt")
+
+	var badErr *badChunkError
+	if err := r.accept(context.Background(), &bad); !errors.As(err, &badErr) {
+		t.Fatalf("expected a badChunkError, got %v", err)
+	}
+	if r.bytesSeen != 0 || r.nextChunk != 0 {
+		t.Fatalf("bad chunk was consumed: bytesSeen=%v nextChunk=%v", r.bytesSeen, r.nextChunk)
+	}
+
+	if err := r.accept(context.Background(), good); err != nil {
+		t.Fatalf("retransmitted chunk should pass, got %v", err)
+	}
+	if r.bytesSeen != 5 || r.nextChunk != 1 {
+		t.Fatalf("good chunk not consumed: bytesSeen=%v nextChunk=%v", r.bytesSeen, r.nextChunk)
+	}
+}
+
+func TestReceiverSaysWhatKindOfFailureItWas(t *testing.T) {
+	chunk := func() *pb.BigFileChunk {
+		return &pb.BigFileChunk{
+			Filepath:          "f",
+			Data:              []byte("hello"),
+			SizeInBytes:       5,
+			Blake2B:           blake2bOfBytes([]byte("hello")),
+			Blake2BCumulative: blake2bOfBytes([]byte("hello")),
+		}
+	}
+
+	corrupt, short, hole, cumulative := chunk(), chunk(), chunk(), chunk()
+	corrupt.Blake2B = []byte("corrupt")
+	short.SizeInBytes = 4
+	hole.HoleSize = 7
+	cumulative.Blake2BCumulative = []byte("corrupt")
+
+	for _, c := range []struct {
+		nk   *pb.BigFileChunk
+		kind fault.Kind
+		code codes.Code
+	}{
+		{corrupt, fault.Checksum, codes.DataLoss},
+		{short, fault.Size, codes.OutOfRange},
+		{hole, fault.Protocol, codes.InvalidArgument},
+		{cumulative, fault.Checksum, codes.DataLoss},
+	} {
+		r, err := newReceiver()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = r.accept(context.Background(), c.nk)
+		if !fault.Is(err, c.kind) || status.Code(err) != c.code {
+			t.Errorf("got %v (%v); want a %v, as %v", err, status.Code(err), c.kind, c.code)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/devops-filetransfer/filetransfer/server/fault"
+	"github.com/devops-filetransfer/filetransfer/server/logging"
+)
+
+// resumeWindow is how long we keep an upload that was cut short
+// by a transport failure, for its sender to carry on with.
+const resumeWindow = 10 * time.Minute
+
+// parkedUpload is an upload that was cut short, as far as we got
+// with it, waiting to be resumed.
+type parkedUpload struct {
+	r     *receiver
+	timer *time.Timer
+}
+
+// uploadKey names the upload of path by the caller on ctx, in the
+// transfer ctx is part of: a resumed call must match all three.
+func (s *PeerServerClass) uploadKey(ctx context.Context, path string) string {
+	return s.tenant(ctx) + "\x00" + logging.TransferID(ctx) + "\x00" + path
+}
+
+// resumable says if an upload that ended with err may be resumed.
+func resumable(err error) bool {
+	return fault.Is(err, fault.Transport)
+}
+
+// park keeps r, the upload key, for resumeWindow; unless it is
+// resumed by then, it is discarded.
+func (s *PeerServerClass) park(key string, r *receiver) {
+	s.parkMut.Lock()
+	defer s.parkMut.Unlock()
+
+	if old := s.parked[key]; old != nil {
+		old.timer.Stop()
+		old.r.abort()
+	}
+
+	p := &parkedUpload{r: r}
+	p.timer = time.AfterFunc(resumeWindow, func() {
+		s.parkMut.Lock()
+		defer s.parkMut.Unlock()
+
+		if s.parked[key] == p {
+			delete(s.parked, key)
+			p.r.abort()
+		}
+	})
+	s.parked[key] = p
+}
+
+// unpark returns the upload key that was parked, if it still is,
+// for whoever is carrying on with it.
+func (s *PeerServerClass) unpark(key string) *receiver {
+	s.parkMut.Lock()
+	defer s.parkMut.Unlock()
+
+	p := s.parked[key]
+	if p == nil {
+		return nil
+	}
+	p.timer.Stop()
+	delete(s.parked, key)
+
+	return p.r
+}
+
+// discardParked discards every upload waiting to be resumed, as
+// none will be once we are gone.
+func (s *PeerServerClass) discardParked() {
+	s.parkMut.Lock()
+	defer s.parkMut.Unlock()
+
+	for key, p := range s.parked {
+		p.timer.Stop()
+		p.r.abort()
+		delete(s.parked, key)
+	}
+}
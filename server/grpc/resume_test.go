@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/idem"
+)
+
+func TestUploadCutShortResumesWhereItStopped(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ServerConfig{
+		MaxMsgSize: DefaultMaxMsgSize,
+		Halt:       idem.NewHalter(),
+		GrpcServer: grpc.NewServer(),
+	}
+	s := NewPeerServerClass(nil, cfg)
+	pb.RegisterPeerServer(cfg.GrpcServer, s)
+	go cfg.GrpcServer.Serve(lis)
+	defer cfg.GrpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewPeerClient(conn)
+
+	data := []byte("hello")
+	chunk := func(n int64) *pb.BigFileChunk {
+		return &pb.BigFileChunk{
+			Filepath:          "f",
+			ChunkNumber:       n,
+			Data:              data,
+			SizeInBytes:       int64(len(data)),
+			Blake2B:           blake2bOfBytes(data),
+			Blake2BCumulative: blake2bOfBytes([]byte("hellohello")[:5*(n+1)]),
+		}
+	}
+
+	// one chunk in, and then the connection is cut.
+	ctx, cut := context.WithCancel(context.Background())
+	stream, err := client.TransferFile(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Send(chunk(0)); err != nil {
+		t.Fatal(err)
+	}
+	if ack, err := stream.Recv(); err != nil || ack.Status != pb.AckStatus_ACK {
+		t.Fatalf("first chunk got %v, %v", ack, err)
+	}
+	cut()
+
+	parked := func() int {
+		s.parkMut.Lock()
+		defer s.parkMut.Unlock()
+		return len(s.parked)
+	}
+	for deadline := time.Now().Add(5 * time.Second); parked() == 0; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("the upload cut short was not kept")
+		}
+	}
+
+	resume := func(path string) (pb.Peer_TransferFileClient, *pb.ChunkAck) {
+		stream, err := client.TransferFile(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := stream.Send(&pb.BigFileChunk{Filepath: path, Resume: true}); err != nil {
+			t.Fatal(err)
+		}
+		ack, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stream, ack
+	}
+
+	if _, ack := resume("g"); ack.ChunkNumber != -1 || ack.BytesVerified != 0 {
+		t.Errorf("resuming what was never sent got %v; want chunk -1", ack)
+	}
+
+	stream, ack := resume("f")
+	if ack.ChunkNumber != 0 || ack.BytesVerified != 5 {
+		t.Fatalf("resuming got %v; want chunk 0, with 5 bytes", ack)
+	}
+	if err := stream.Send(chunk(1)); err != nil {
+		t.Fatal(err)
+	}
+	if ack, err := stream.Recv(); err != nil || ack.Status != pb.AckStatus_ACK || ack.BytesVerified != 10 {
+		t.Fatalf("the chunk after the cut got %v, %v", ack, err)
+	}
+	if n := parked(); n != 0 {
+		t.Errorf("%v uploads still parked once resumed", n)
+	}
+}
@@ -2,29 +2,57 @@
 package grpc
 
 import (
-	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"flag"
 	"fmt"
-	"hash"
 	"io"
-	"log"
+	"log/slog"
 	"net"
 	"os"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 
 	"github.com/devops-filetransfer/bchan"
 	"github.com/devops-filetransfer/blake2b"
 	"github.com/devops-filetransfer/filetransfer/server/api"
+	"github.com/devops-filetransfer/filetransfer/server/attr"
+	"github.com/devops-filetransfer/filetransfer/server/audit"
+	"github.com/devops-filetransfer/filetransfer/server/authz"
+	"github.com/devops-filetransfer/filetransfer/server/certmgr"
 	"github.com/devops-filetransfer/filetransfer/server/exists"
+	"github.com/devops-filetransfer/filetransfer/server/fault"
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	"github.com/devops-filetransfer/filetransfer/server/jail"
+	"github.com/devops-filetransfer/filetransfer/server/logging"
+	"github.com/devops-filetransfer/filetransfer/server/manifest"
+	"github.com/devops-filetransfer/filetransfer/server/metrics"
+	"github.com/devops-filetransfer/filetransfer/server/pki"
 	"github.com/devops-filetransfer/filetransfer/server/print"
 	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/filetransfer/server/sshkeys"
+	"github.com/devops-filetransfer/filetransfer/server/store"
+	"github.com/devops-filetransfer/filetransfer/server/tracing"
 	"github.com/devops-filetransfer/idem"
 	tun "github.com/devops-filetransfer/sshego"
 )
 
+// DefaultMaxMsgSize is the default limit, in bytes, on gRPC
+// messages in either direction. The stock gRPC receive limit
+// of 4MB is why 4MB chunks used to fail with EOF.
+const DefaultMaxMsgSize = 16 << 20
+
+// minMaxMsgSize is the smallest -max_msg_size we accept; anything
+// smaller leaves no useful room for chunk data.
+const minMaxMsgSize = 256 << 10
+
 type ServerConfig struct {
 	MyID string
 	Host string // ip address
@@ -38,16 +66,99 @@ type ServerConfig struct {
 	CertPath string
 	KeyPath  string
 
+	// ClientCAPath, when set under TLS, turns on mutual TLS:
+	// clients must present a certificate signed by one of
+	// the CAs in this bundle, and are known by it.
+	ClientCAPath string
+
+	// Certs serves the pair in CertPath and KeyPath,
+	// picking up new ones as they are rotated in.
+	Certs *certmgr.Manager
+
 	ExternalLsnPort int
 	InternalLsnPort int
 	CpuProfilePath  string
 
+	// MaxMsgSize bounds gRPC messages we send and receive.
+	// Clients learn it through Negotiate().
+	MaxMsgSize int
+
+	// StoreDir is where received files are kept. When empty,
+	// files are verified and then dropped.
+	StoreDir   string
+	AttrPolicy string
+	Store      *store.Store
+
+	// AttrRootSetID lets files given to root keep the setuid
+	// and setgid bits they came with.
+	AttrRootSetID bool
+
+	// StoreKeyPath, when set, names the master key file that
+	// stored files are encrypted under.
+	StoreKeyPath string
+
+	// AuthzPolicyPath names the file saying who may read, write
+	// and delete what. When empty, every caller may do anything.
+	AuthzPolicyPath string
+	Authz           *authz.Authorizer
+
+	// AuditLogPath, when set, is where we keep the audit log of
+	// every operation on our files, rotated at AuditMaxSize bytes
+	// or AuditMaxAge, whichever comes first.
+	AuditLogPath string
+	AuditMaxSize int64
+	AuditMaxAge  time.Duration
+	Audit        *audit.Log
+
+	// TokenKeyPath, when set, makes callers prove who they are
+	// with a bearer token checked against this key, unless they
+	// present a client certificate. Tokens travel in the clear
+	// under -skip-encryption, so we refuse to run like that
+	// unless TokenInsecure says otherwise.
+	TokenKeyPath  string
+	TokenInsecure bool
+
 	SshegoCfg *tun.SshegoConfig
 
+	// SSHKeysPath is the file of public keys that SSH logins
+	// may use, kept in SSHKeys while we serve SSH.
+	SSHKeysPath string
+	SSHKeys     *sshkeys.Store
+
+	// TrustedSignersPath names the file of Ed25519 keys whose
+	// signed manifests we accept, kept in Signers. With
+	// RequireSigned, files that come unsigned are refused.
+	TrustedSignersPath string
+	RequireSigned      bool
+	Signers            *manifest.Trusted
+
+	// Health says whether we are ready to serve, through the
+	// standard gRPC health service. Reflection also offers the
+	// gRPC reflection service, for generic tools to find out
+	// what we serve.
+	Health     *Health
+	Reflection bool
+
+	// MetricsAddr, when set, is where we serve our metrics, at
+	// /metrics, for Prometheus to scrape.
+	MetricsAddr string
+
+	// LogLevel and LogFormat say what we log, and how.
+	LogLevel  string
+	LogFormat string
+
+	// Trace, when set, is where the spans tracing each call
+	// go, as tracing.Setup takes it.
+	Trace string
+
 	ServerGotGetReply   chan *api.BcastGetReply
 	ServerGotSetRequest chan *api.BcastSetRequest
 
-	Halt *idem.Halter
+	// Halt has its stop requested once Drain wants the calls
+	// still in flight to stop, and is marked done once we have
+	// shut down. DrainTimeout is how long those calls get first.
+	Halt         *idem.Halter
+	DrainTimeout time.Duration
 
 	GrpcServer *grpc.Server
 	Cls        *PeerServerClass
@@ -59,6 +170,11 @@ type PeerServerClass struct {
 	GotFile            *bchan.Bchan
 	mut                sync.Mutex
 	filesReceivedCount int64
+
+	// parked holds the uploads cut short that may yet be
+	// resumed, by uploadKey.
+	parkMut sync.Mutex
+	parked  map[string]*parkedUpload
 }
 
 func NewPeerServerClass(lgs api.LocalGetSet, cfg *ServerConfig) *PeerServerClass {
@@ -66,7 +182,168 @@ func NewPeerServerClass(lgs api.LocalGetSet, cfg *ServerConfig) *PeerServerClass
 		lgs:     lgs,
 		cfg:     cfg,
 		GotFile: bchan.New(1),
+		parked:  make(map[string]*parkedUpload),
+	}
+}
+
+// defaultTenant owns the files of callers we cannot tell apart.
+const defaultTenant = "default"
+
+// tenant names whose files the caller on ctx gets to see.
+// Each identified caller gets their own.
+func (s *PeerServerClass) tenant(ctx context.Context) string {
+	id := identity.FromContext(ctx)
+	if id == "" {
+		return defaultTenant
+	}
+
+	return identity.Tenant(id)
+}
+
+// inventoryKey is what the file at key, a Clean path, is known
+// by in our inventory: the same path is another file for each
+// tenant.
+func (s *PeerServerClass) inventoryKey(ctx context.Context, key string) string {
+	return s.tenant(ctx) + "\x00" + key
+}
+
+// commit makes the file received by r permanent under path,
+// and records it in our inventory. Who is the caller on ctx,
+// if we know who that is, and otherwise ourselves. A signed
+// file is only committed if its signature checks out. Our peers
+// get the trace along with the inventory entry, to replicate it
+// under.
+func (s *PeerServerClass) commit(ctx context.Context, r *receiver, path string) (err error) {
+	ctx, span := tracing.Start(ctx, "commit", attribute.String("path", path))
+	defer func() { tracing.End(span, err) }()
+
+	key, err := jail.Clean(path)
+	if err != nil {
+		return err
+	}
+
+	signed, err := s.checkSignature(r, path)
+	if err != nil {
+		return err
+	}
+
+	if err := r.commit(); err != nil {
+		return err
+	}
+
+	who := identity.FromContext(ctx)
+	if who == "" {
+		who = s.cfg.MyID
+	}
+
+	ki := &api.KeyInv{
+		Key:        []byte(s.inventoryKey(ctx, key)),
+		Who:        who,
+		When:       time.Now(),
+		Size:       r.bytesSeen,
+		Blake2b:    r.sum(),
+		TransferID: logging.TransferID(ctx),
+	}
+	if signed != nil {
+		r.signer = signed.Signer
+		ki.Signer = signed.Signer
+		ki.Signature = r.signed
+		s.logger(ctx).Info("file is signed", "path", path, "signer", signed.Signer, "fingerprint", signed.Fingerprint, "signed_at", signed.Time)
+	}
+
+	rctx, rspan := tracing.Start(ctx, "replicate")
+	ki.TraceParent = tracing.Inject(rctx)
+	err = s.lgs.LocalSet(ki)
+	tracing.End(rspan, err)
+	if err != nil {
+		return err
+	}
+
+	s.IncrementGotFileCount()
+
+	return nil
+}
+
+// checkSignature verifies the signed manifest that r's file came
+// with, if any, against our trusted signers. It must be signed by
+// one of them, and describe just the file we got, as path.
+func (s *PeerServerClass) checkSignature(r *receiver, path string) (*manifest.Signed, error) {
+	if r.signed == nil {
+		if s.cfg.RequireSigned {
+			return nil, fmt.Errorf("'%s' is not signed, and this server only takes signed files", path)
+		}
+		return nil, nil
+	}
+
+	if s.cfg.Signers == nil {
+		return nil, fmt.Errorf("'%s' is signed, but this server has no -trusted_signers to check it against", path)
+	}
+
+	signed, err := s.cfg.Signers.Verify(r.signed)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %v", path, err)
+	}
+	if signed.Path != path {
+		return nil, fmt.Errorf("'%s' came with a manifest signed for '%s'", path, signed.Path)
+	}
+	if err := signed.Check(r.bytesSeen, r.sum()); err != nil {
+		return nil, err
+	}
+
+	return signed, nil
+}
+
+// record puts rec, an operation by the caller on ctx begun at
+// start that ended in err, in the audit log, if we keep one.
+func (s *PeerServerClass) record(ctx context.Context, rec *audit.Record, start time.Time, err error) {
+	if s.cfg.Audit == nil {
+		return
 	}
+
+	rec.Time = start
+	rec.Elapsed = time.Since(start)
+	rec.Result = audit.OK
+	if err != nil {
+		rec.Result = err.Error()
+	}
+
+	if err := s.cfg.Audit.Record(ctx, rec); err != nil {
+		s.logger(ctx).Error("could not write to the audit log", "err", err)
+	}
+}
+
+// recordReceived puts the file r received as path in the audit log.
+func (s *PeerServerClass) recordReceived(ctx context.Context, op, path string, r *receiver, start time.Time, err error) {
+	rec := &audit.Record{Op: op, Path: path, Size: r.bytesSeen}
+	if err == nil {
+		rec.Blake2b = fmt.Sprintf("%x", r.sum())
+	}
+	if r.signer != "" {
+		rec.Detail = "signed by " + r.signer
+	}
+
+	s.record(ctx, rec, start, err)
+}
+
+// logger is the logger for the call on ctx.
+func (s *PeerServerClass) logger(ctx context.Context) *slog.Logger {
+	l := logging.From(ctx)
+	if s.cfg.MyID != "" {
+		l = l.With("peer", s.cfg.MyID)
+	}
+
+	return l
+}
+
+// logReceived logs how receiving path, into r, went, at level if
+// it went well.
+func (s *PeerServerClass) logReceived(ctx context.Context, level slog.Level, path string, r *receiver, err error) {
+	if err != nil {
+		s.logger(ctx).Warn("receive failed", "path", path, "chunks", r.chunkCount, "bytes", r.bytesSeen, "err", err)
+		return
+	}
+
+	s.logger(ctx).Log(ctx, level, "received file", "path", path, "chunks", r.chunkCount, "bytes", r.bytesSeen, "blake2b", fmt.Sprintf("%x", r.sum()))
 }
 
 func (s *PeerServerClass) IncrementGotFileCount() {
@@ -75,37 +352,31 @@ func (s *PeerServerClass) IncrementGotFileCount() {
 	count := s.filesReceivedCount
 	s.mut.Unlock()
 
+	metrics.FileReceived()
 	s.GotFile.Bcast(count)
 }
 
 // Implement pb.PeerServer interface; the server is receiving a file here,
 // because the client called SendFile() on the other end.
 func (s *PeerServerClass) SendFile(stream pb.Peer_SendFileServer) error {
-	var chunkCount int64
 	path := ""
-	var hasher hash.Hash
-
-	log.Printf("%s peer.Server SendFile (for receiving a file) starting!", s.cfg.MyID)
+	start := time.Now()
 
-	hasher, err := blake2b.New(nil)
+	r, err := newReceiver()
 	if err != nil {
 		return err
 	}
 
 	var finalChecksum []byte
-	const writeFileToDisk = false
-	var fd *os.File
-	var bytesSeen int64
 
 	defer func() {
-		if fd != nil {
-			_ = fd.Close()
-		}
+		r.abort()
+		s.recordReceived(stream.Context(), "SendFile", path, r, start, err)
 
-		finalChecksum = []byte(hasher.Sum(nil))
+		finalChecksum = r.sum()
 		endTime := time.Now()
 
-		log.Printf("%s this server.SendFile() call got %v chunks, byteCount=%v. with final checksum '%x'. defer running/is returning with err='%v'", s.cfg.MyID, chunkCount, bytesSeen, finalChecksum, err)
+		s.logReceived(stream.Context(), slog.LevelInfo, path, r, err)
 
 		errStr := ""
 		if err != nil {
@@ -114,13 +385,13 @@ func (s *PeerServerClass) SendFile(stream pb.Peer_SendFileServer) error {
 
 		sacErr := stream.SendAndClose(&pb.BigFileAck{
 			Filepath:         path,
-			SizeInBytes:      bytesSeen,
+			SizeInBytes:      r.bytesSeen,
 			RecvTime:         uint64(endTime.UnixNano()),
 			WholeFileBlake2B: finalChecksum,
 			Err:              errStr,
 		})
 		if sacErr != nil {
-			log.Printf("warning: sacErr='%s' in gserv server.go PeerServerClass.SendFile() attempt to stream.SendAndClose().", sacErr)
+			s.logger(stream.Context()).Warn("could not send the reply", "path", path, "err", sacErr)
 		}
 	}()
 
@@ -130,114 +401,318 @@ func (s *PeerServerClass) SendFile(stream pb.Peer_SendFileServer) error {
 	for {
 		nk, err = stream.Recv()
 		if err == io.EOF {
-			if nk != nil && len(nk.Data) > 0 {
-				panic("we need to save this last chunk too!")
+			// Recv gives no chunk along with io.EOF; the last
+			// one came before, flagged IsLastChunk, if at all.
+			if firstChunkSeen {
+				err = fault.New(fault.Protocol, path, "'%s' stream ended after %v chunks, before the last chunk", path, r.chunkCount)
 			}
-			return nil
+			return err
 		}
 		if err != nil {
 			return err
 		}
+		if err = s.draining(nk.Filepath); err != nil {
+			return err
+		}
 
 		// INVAR: we have a chunk
 		if !firstChunkSeen {
-			if nk.Filepath != "" {
-				if writeFileToDisk {
-					fd, err = os.Create(nk.Filepath + fmt.Sprintf("__%v", time.Now()))
-					if err != nil {
-						return err
-					}
-					defer func(fd *os.File) {
-						_ = fd.Close()
-					}(fd)
-				}
+			err = r.open(s.cfg.Store, s.tenant(stream.Context()), nk.Filepath, nk.Attr)
+			if err != nil {
+				return err
 			}
 			firstChunkSeen = true
 		}
 
-		hasher.Write(nk.Data)
-		cumul := hasher.Sum(nil)
-		if 0 != bytes.Compare(cumul, nk.Blake2BCumulative) {
-			return fmt.Errorf("cumulative checksums failed at chunk %v of '%s'. Observed: '%x', expected: '%x'.", nk.ChunkNumber, nk.Filepath, cumul, nk.Blake2BCumulative)
-		}
-
 		if path == "" {
 			path = nk.Filepath
 		}
 
 		if path != "" && path != nk.Filepath {
-			panic(fmt.Errorf("confusing between two different streams! '%s' vs '%s'", path, nk.Filepath))
+			err = fault.New(fault.Protocol, path, "chunk %v is for '%s' but this stream is for '%s'; "+
+				"use Session to send several files over one stream", nk.ChunkNumber, nk.Filepath, path)
+			return err
 		}
 
-		if nk.SizeInBytes != int64(len(nk.Data)) {
-			return fmt.Errorf("%v == nk.SizeInBytes != int64(len(nk.Data)) == %v", nk.SizeInBytes, int64(len(nk.Data)))
+		err = r.accept(stream.Context(), nk)
+		if err != nil {
+			return err
 		}
 
-		checksum := s.blake2bOfBytes(nk.Data)
-		cmp := bytes.Compare(checksum, nk.Blake2B)
-		if cmp != 0 {
-			return fmt.Errorf("chunk %v bad .Data, checksum mismatch!",
-				nk.ChunkNumber)
+		// INVAR: chunk passes tests, and is stored if we keep files.
+
+		if nk.IsLastChunk {
+			err = s.commit(stream.Context(), r, path)
+			return err
+		}
+	}
+}
+
+// TransferFile implements pb.PeerServer. It receives a file like
+// SendFile does, but acks each chunk as soon as it is verified.
+// A chunk that fails its own checks is naked; we then discard
+// whatever follows it until the sender retransmits it (go-back-N).
+func (s *PeerServerClass) TransferFile(stream pb.Peer_TransferFileServer) error {
+	path := ""
+	committed := false
+	start := time.Now()
+
+	r, err := newReceiver()
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		s.recordReceived(stream.Context(), "TransferFile", path, r, start, err)
+		s.logReceived(stream.Context(), slog.LevelInfo, path, r, err)
+		if path != "" && !committed && resumable(err) {
+			s.park(s.uploadKey(stream.Context(), path), r)
+			return
 		}
+		r.abort()
+	}()
 
-		// INVAR: chunk passes tests, keep it.
-		bytesSeen += int64(len(nk.Data))
-		chunkCount++
+	fatal := func(chunkNumber int64, e error) error {
+		sendErr := stream.Send(&pb.ChunkAck{
+			Filepath:      path,
+			ChunkNumber:   chunkNumber,
+			Status:        pb.AckStatus_FATAL,
+			BytesVerified: r.bytesSeen,
+			Err:           e.Error(),
+		})
+		if sendErr != nil {
+			s.logger(stream.Context()).Warn("could not send FATAL ack", "path", path, "err", sendErr)
+		}
+		return e
+	}
 
-		// TODO: user should store chunk somewhere here... or accumulate
-		// all the chunks in memory
-		// until ready to store it elsewhere; e.g. in boltdb.
+	nakPending := false
+	var nk *pb.BigFileChunk
 
-		if writeFileToDisk {
-			err = s.writeToFd(fd, nk.Data)
-			if err != nil {
+	for {
+		nk, err = stream.Recv()
+		if err == io.EOF {
+			err = fault.New(fault.Protocol, path, "'%s' stream ended after %v chunks, before the last chunk", path, r.chunkCount)
+			return err
+		}
+		if err != nil {
+			err = fault.New(fault.Transport, path, "'%s' stream broke after %v bytes were verified: %w", path, r.bytesSeen, err)
+			return err
+		}
+
+		if nk.Resume {
+			if path != "" {
+				err = fatal(nk.ChunkNumber, fault.New(fault.Protocol, path, "'%s' asked to resume in the middle of the stream", path))
+				return err
+			}
+			ack := &pb.ChunkAck{Filepath: nk.Filepath, ChunkNumber: -1, Status: pb.AckStatus_ACK}
+			if parked := s.unpark(s.uploadKey(stream.Context(), nk.Filepath)); parked != nil {
+				r = parked
+				path = nk.Filepath
+				ack.ChunkNumber = r.nextChunk - 1
+				ack.BytesVerified = r.bytesSeen
+				s.logger(stream.Context()).Info("resuming upload", "path", path, "chunk", r.nextChunk, "bytes", r.bytesSeen)
+			}
+			if err = stream.Send(ack); err != nil {
+				err = fault.New(fault.Transport, path, "'%s' could not ack the resume: %w", nk.Filepath, err)
 				return err
 			}
+			continue
 		}
 
-		if nk.IsLastChunk {
+		if path == "" {
+			path = nk.Filepath
+			// a new start drops what was kept of an earlier try.
+			if old := s.unpark(s.uploadKey(stream.Context(), path)); old != nil {
+				old.abort()
+			}
+			if err = r.open(s.cfg.Store, s.tenant(stream.Context()), path, nk.Attr); err != nil {
+				err = fatal(nk.ChunkNumber, err)
+				return err
+			}
+		}
+
+		if nk.Filepath != path {
+			err = fatal(nk.ChunkNumber, fault.New(fault.Protocol, path, "chunk %v is for '%s' but this stream is for '%s'", nk.ChunkNumber, nk.Filepath, path))
 			return err
 		}
-	}
-}
 
-func (s *PeerServerClass) blake2bOfBytes(by []byte) []byte {
-	h, err := blake2b.New(nil)
-	print.PanicOn(err)
+		if e := s.draining(path); e != nil {
+			err = fatal(nk.ChunkNumber, e)
+			return err
+		}
 
-	h.Write(by)
+		if nk.ChunkNumber != r.nextChunk {
+			if nakPending && nk.ChunkNumber > r.nextChunk {
+				// sent before our NAK got there; the retransmit follows.
+				continue
+			}
+			err = fatal(nk.ChunkNumber, fault.New(fault.Protocol, path, "'%s' got chunk %v, expected chunk %v", path, nk.ChunkNumber, r.nextChunk))
+			return err
+		}
 
-	return h.Sum(nil)
-}
+		err = r.accept(stream.Context(), nk)
+		var bad *badChunkError
+		if errors.As(err, &bad) {
+			s.logger(stream.Context()).Warn("chunk naked", "path", path, "chunk", bad.ChunkNumber, "reason", bad.Reason)
+			nakPending = true
+			err = stream.Send(&pb.ChunkAck{
+				Filepath:      path,
+				ChunkNumber:   nk.ChunkNumber,
+				Status:        pb.AckStatus_NAK,
+				BytesVerified: r.bytesSeen,
+				Err:           bad.Error(),
+			})
+			if err != nil {
+				err = fault.New(fault.Transport, path, "'%s' could not nak chunk %v: %w", path, nk.ChunkNumber, err)
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			err = fatal(nk.ChunkNumber, err)
+			return err
+		}
+		nakPending = false
 
-func (s *PeerServerClass) writeToFd(fd *os.File, data []byte) error {
-	w := 0
-	n := len(data)
+		ack := &pb.ChunkAck{
+			Filepath:      path,
+			ChunkNumber:   nk.ChunkNumber,
+			Status:        pb.AckStatus_ACK,
+			BytesVerified: r.bytesSeen,
+		}
+		if nk.IsLastChunk {
+			if err = s.commit(stream.Context(), r, path); err != nil {
+				err = fatal(nk.ChunkNumber, err)
+				return err
+			}
+			committed = true
+			ack.IsFinal = true
+			ack.WholeFileBlake2B = r.sum()
+			ack.RecvTime = uint64(time.Now().UnixNano())
+		}
 
-	for {
-		nw, err := fd.Write(data[w:])
+		err = stream.Send(ack)
 		if err != nil {
+			err = fault.New(fault.Transport, path, "'%s' could not ack chunk %v: %w", path, nk.ChunkNumber, err)
 			return err
 		}
-		w += nw
-		if nw >= n {
+		if nk.IsLastChunk {
 			return nil
 		}
 	}
 }
 
+// Negotiate implements pb.PeerServer; it tells the client
+// what message sizes this server will accept and produce.
+func (s *PeerServerClass) Negotiate(ctx context.Context, their *pb.Limits) (*pb.Limits, error) {
+	s.logger(ctx).Debug("negotiated message sizes", "client_recv", their.MaxRecvMsgSize, "client_send", their.MaxSendMsgSize, "ours", s.cfg.MaxMsgSize)
+
+	return &pb.Limits{
+		MaxRecvMsgSize: int64(s.cfg.MaxMsgSize),
+		MaxSendMsgSize: int64(s.cfg.MaxMsgSize),
+	}, nil
+}
+
+func blake2bOfBytes(by []byte) []byte {
+	h, err := blake2b.New(nil)
+	print.PanicOn(err)
+
+	h.Write(by)
+
+	return h.Sum(nil)
+}
+
 func (c *ServerConfig) DefineFlags(fs *flag.FlagSet) {
 	fs.BoolVar(&c.UseTLS, "tls", false, "Use TLS instead of the default SSH.")
 	fs.BoolVar(&c.SkipEncryption, "skip-encryption", false, "Skip both TLS and SSH; for running on an already encrypted VPN.")
-	fs.StringVar(&c.CertPath, "cert_file", "testdata/server1.pem", "The TLS cert file")
-	fs.StringVar(&c.KeyPath, "key_file", "testdata/server1.key", "The TLS key file")
+	fs.StringVar(&c.CertPath, "cert_file", filepath.Join(pki.DefaultDir(), pki.ServerCertFile), "The TLS cert file, as made by 'server cert init'")
+	fs.StringVar(&c.KeyPath, "key_file", filepath.Join(pki.DefaultDir(), pki.ServerKeyFile), "The TLS key file")
+	fs.StringVar(&c.ClientCAPath, "client_ca_file", "", "CA bundle to verify client certificates against; requires them (mutual TLS)")
 	fs.StringVar(&c.Host, "host", "127.0.0.1", "host IP address or name to bind")
 	fs.IntVar(&c.ExternalLsnPort, "externalport", 10000, "The exteral server port")
 	fs.IntVar(&c.InternalLsnPort, "iport", 10001, "The internal server port")
 	fs.StringVar(&c.CpuProfilePath, "cpuprofile", "", "write cpu profile to file")
+	fs.IntVar(&c.MaxMsgSize, "max_msg_size", DefaultMaxMsgSize, "max gRPC message size in bytes, for both send and receive")
+	fs.StringVar(&c.StoreDir, "store", "", "directory to keep received files in (default: verify and drop them)")
+	fs.StringVar(&c.StoreKeyPath, "store_key", "", "master key file, as made by 'server store keygen', to encrypt stored files with (default: store them as they are)")
+	fs.StringVar(&c.AttrPolicy, "attr_policy", "mode", "file metadata to apply on commit: none, mode, owner or full")
+	fs.BoolVar(&c.AttrRootSetID, "attr_root_setid", false, "under -attr_policy owner or full, let files owned by root, or its group, keep their setuid or setgid bit")
+	fs.StringVar(&c.TokenKeyPath, "token_key", "", "key file to check bearer tokens with; callers must then present a token or a client certificate")
+	fs.BoolVar(&c.TokenInsecure, "token_insecure", false, "accept bearer tokens even without TLS or SSH, where anyone watching can steal them")
+	fs.StringVar(&c.AuditLogPath, "audit_log", "", "file to keep a hash-chained audit log of every operation in; check it with 'server audit verify'")
+	fs.Int64Var(&c.AuditMaxSize, "audit_max_size", 64<<20, "rotate the audit log once it reaches this many bytes (0: never)")
+	fs.DurationVar(&c.AuditMaxAge, "audit_max_age", 24*time.Hour, "rotate the audit log once its first record is this old (0: never)")
+	fs.StringVar(&c.AuthzPolicyPath, "authz_policy", "", "JSON file of who may read, write and delete which paths; reread on SIGHUP (default: everyone may do anything)")
+	fs.StringVar(&c.SSHKeysPath, "ssh_keys", sshkeys.DefaultPath(), "file of the public keys each SSH login may use, as managed by 'server ssh key'")
+	fs.StringVar(&c.TrustedSignersPath, "trusted_signers", "", "authorized_keys file of the Ed25519 keys whose signed upload manifests we accept; reread on SIGHUP")
+	fs.DurationVar(&c.DrainTimeout, "drain_timeout", DefaultDrainTimeout, "on SIGTERM or SIGINT, how long calls in flight get to finish before they are stopped")
+	fs.BoolVar(&c.Reflection, "reflection", false, "offer the gRPC reflection service, so that tools such as grpcurl can list and call our services")
+	fs.StringVar(&c.MetricsAddr, "metrics_addr", "", "host:port to serve Prometheus metrics on, at /metrics (default: none)")
+	fs.StringVar(&c.LogLevel, "log_level", "info", "least severe level to log: debug, info, warn or error")
+	fs.StringVar(&c.LogFormat, "log_format", logging.Text, "log as "+logging.Text+" or "+logging.JSON)
+	fs.StringVar(&c.Trace, "trace", "", "send OpenTelemetry spans to stdout, file:<path> or otlp[:<host:port>] (default: none)")
+	fs.BoolVar(&c.RequireSigned, "require_signed", false, "refuse uploads that do not come with a manifest signed by a -trusted_signers key")
+}
+
+// ServerOptions returns the grpc.ServerOption(s) that
+// enforce our message size limits.
+func (c *ServerConfig) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.MaxMsgSize),
+		grpc.MaxSendMsgSize(c.MaxMsgSize),
+	}
+}
+
+// TLSCredentials returns the server's TLS transport credentials,
+// serving the certificate held by c.Certs, which it sets up. With
+// a ClientCAPath, clients must present a certificate that
+// verifies against it: mutual TLS.
+func (c *ServerConfig) TLSCredentials() (credentials.TransportCredentials, error) {
+	m, err := certmgr.New(c.CertPath, c.KeyPath)
+	if err != nil {
+		return nil, err
+	}
+	c.Certs = m
+
+	tc := &tls.Config{
+		GetCertificate: m.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+	}
+
+	if c.ClientCAPath != "" {
+		pem, err := os.ReadFile(c.ClientCAPath)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in -client_ca_file '%s'", c.ClientCAPath)
+		}
+		tc.ClientAuth = tls.RequireAndVerifyClientCert
+		tc.ClientCAs = pool
+	}
+
+	return metrics.Handshakes(credentials.NewTLS(tc), metrics.TLS), nil
 }
 
 func (c *ServerConfig) ValidateConfig() error {
+	if c.MaxMsgSize < minMaxMsgSize {
+		return fmt.Errorf("-max_msg_size %v is too small; must be at least %v", c.MaxMsgSize, minMaxMsgSize)
+	}
+
+	if _, err := attr.ParsePolicy(c.AttrPolicy); err != nil {
+		return err
+	}
+
+	if _, err := logging.New(io.Discard, c.LogLevel, c.LogFormat); err != nil {
+		return err
+	}
+
+	if err := tracing.Check(c.Trace); err != nil {
+		return err
+	}
+
 	if c.UseTLS {
 		if c.KeyPath == "" {
 			return fmt.Errorf("must provide -key_file under TLS")
@@ -252,10 +727,60 @@ func (c *ServerConfig) ValidateConfig() error {
 		}
 
 		if !exists.FileExists(c.CertPath) {
-			return fmt.Errorf("-cert_path '%s' does not exist", c.CertPath)
+			return fmt.Errorf("-cert_path '%s' does not exist; 'server cert init' will make one", c.CertPath)
+		}
+
+		if c.ClientCAPath != "" && !exists.FileExists(c.ClientCAPath) {
+			return fmt.Errorf("-client_ca_file '%s' does not exist", c.ClientCAPath)
+		}
+	}
+
+	if c.StoreKeyPath != "" {
+		if c.StoreDir == "" {
+			return fmt.Errorf("-store_key needs -store")
+		}
+		if !exists.FileExists(c.StoreKeyPath) {
+			return fmt.Errorf("-store_key '%s' does not exist; 'server store keygen' will make one", c.StoreKeyPath)
+		}
+	}
+
+	if c.DrainTimeout < 0 {
+		return fmt.Errorf("-drain_timeout cannot be negative")
+	}
+
+	if c.AuditMaxSize < 0 || c.AuditMaxAge < 0 {
+		return fmt.Errorf("-audit_max_size and -audit_max_age cannot be negative")
+	}
+
+	if c.AuthzPolicyPath != "" && !exists.FileExists(c.AuthzPolicyPath) {
+		return fmt.Errorf("-authz_policy '%s' does not exist", c.AuthzPolicyPath)
+	}
+
+	if c.TokenKeyPath != "" {
+		if !exists.FileExists(c.TokenKeyPath) {
+			return fmt.Errorf("-token_key '%s' does not exist", c.TokenKeyPath)
 		}
+		if !c.UseTLS && c.SkipEncryption && !c.TokenInsecure {
+			return fmt.Errorf("refusing to take bearer tokens without encryption; use -tls, or give -token_insecure if you really mean it")
+		}
+	}
+
+	if c.TrustedSignersPath != "" && !exists.FileExists(c.TrustedSignersPath) {
+		return fmt.Errorf("-trusted_signers '%s' does not exist", c.TrustedSignersPath)
 	}
+	if c.RequireSigned && c.TrustedSignersPath == "" {
+		return fmt.Errorf("-require_signed needs -trusted_signers")
+	}
+
+	if c.ClientCAPath != "" && !c.UseTLS {
+		return fmt.Errorf("-client_ca_file needs -tls")
+	}
+
+	return nil
+}
 
+// CheckPorts makes sure the ports we are to listen on are free.
+func (c *ServerConfig) CheckPorts() error {
 	if !c.UseTLS {
 		lsn, err := net.Listen("tcp", fmt.Sprintf(":%v", c.InternalLsnPort))
 		if err != nil {
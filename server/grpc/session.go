@@ -0,0 +1,163 @@
+package grpc
+
+import (
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/devops-filetransfer/filetransfer/server/fault"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+// sessionFile tracks the file currently being received in a Session.
+type sessionFile struct {
+	hdr   *pb.FileHeader
+	r     *receiver
+	start time.Time
+
+	// failed is set once this file has been given an error
+	// ack; we skip its remaining chunks until the next header.
+	failed bool
+}
+
+// Session implements pb.PeerServer. The client sends many files
+// over the one stream, each starting with a FileHeader and followed
+// by its chunks; we stream back one BigFileAck per file. A bad file
+// gets an ack with Err set, and the session carries on with the
+// next header. Only transport errors end the session.
+func (s *PeerServerClass) Session(stream pb.Peer_SessionServer) error {
+	var cur *sessionFile
+	var filesOK, filesFailed int64
+
+	defer func() {
+		if cur != nil {
+			cur.r.abort()
+			if !cur.failed {
+				s.recordReceived(stream.Context(), "Session", cur.hdr.Filepath, cur.r, cur.start, fault.New(fault.Protocol, cur.hdr.Filepath, "session ended in the middle of the file"))
+			}
+		}
+		s.logger(stream.Context()).Info("session finished", "files", filesOK, "failed", filesFailed)
+	}()
+
+	finish := func(f *sessionFile, ferr error) error {
+		ack := &pb.BigFileAck{
+			Filepath:         f.hdr.Filepath,
+			SizeInBytes:      f.r.bytesSeen,
+			RecvTime:         uint64(time.Now().UnixNano()),
+			WholeFileBlake2B: f.r.sum(),
+		}
+		if ferr == nil {
+			ferr = s.commit(stream.Context(), f.r, f.hdr.Filepath)
+		}
+		if ferr != nil {
+			f.r.abort()
+			ack.Err = ferr.Error()
+			f.failed = true
+			filesFailed++
+		} else {
+			filesOK++
+		}
+		s.recordReceived(stream.Context(), "Session", f.hdr.Filepath, f.r, f.start, ferr)
+		s.logReceived(stream.Context(), slog.LevelDebug, f.hdr.Filepath, f.r, ferr)
+		return stream.Send(ack)
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			if cur != nil && !cur.failed {
+				return finish(cur, fault.New(fault.Protocol, cur.hdr.Filepath, "session ended in the middle of '%s'", cur.hdr.Filepath))
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if cur != nil && !cur.failed {
+			if err := s.draining(cur.hdr.Filepath); err != nil {
+				if ferr := finish(cur, err); ferr != nil {
+					return ferr
+				}
+				return err
+			}
+		} else if err := s.draining(""); err != nil {
+			return err
+		}
+
+		if msg.Header != nil {
+			if cur != nil && !cur.failed {
+				err = finish(cur, fault.New(fault.Protocol, cur.hdr.Filepath, "header for '%s' arrived before the last chunk of '%s'", msg.Header.Filepath, cur.hdr.Filepath))
+				if err != nil {
+					return err
+				}
+			}
+
+			r, err := newReceiver()
+			if err != nil {
+				return err
+			}
+			r.metadata = msg.Header.Metadata
+			cur = &sessionFile{hdr: msg.Header, r: r, start: time.Now()}
+			if err := r.open(s.cfg.Store, s.tenant(stream.Context()), msg.Header.Filepath, msg.Header.Attr); err != nil {
+				if err := finish(cur, err); err != nil {
+					return err
+				}
+			}
+
+			if msg.Chunk == nil {
+				continue
+			}
+		}
+
+		nk := msg.Chunk
+		if nk == nil {
+			continue
+		}
+
+		if cur == nil {
+			// nothing sensible to ack against.
+			return fault.New(fault.Protocol, nk.Filepath, "session chunk %v for '%s' arrived before any FileHeader", nk.ChunkNumber, nk.Filepath)
+		}
+		if cur.failed {
+			continue
+		}
+
+		if nk.Filepath != cur.hdr.Filepath {
+			err = finish(cur, fault.New(fault.Protocol, cur.hdr.Filepath, "chunk %v is for '%s' but the current file is '%s'", nk.ChunkNumber, nk.Filepath, cur.hdr.Filepath))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if nk.ChunkNumber != cur.r.nextChunk {
+			err = finish(cur, fault.New(fault.Protocol, cur.hdr.Filepath, "'%s' got chunk %v, expected chunk %v", cur.hdr.Filepath, nk.ChunkNumber, cur.r.nextChunk))
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err = cur.r.accept(stream.Context(), nk); err != nil {
+			if err = finish(cur, err); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if cur.r.bytesSeen > cur.hdr.SizeInBytes {
+			err = finish(cur, fault.New(fault.Size, cur.hdr.Filepath, "'%s' is longer than the %v bytes its header announced", cur.hdr.Filepath, cur.hdr.SizeInBytes))
+		} else if nk.IsLastChunk {
+			var ferr error
+			if cur.r.bytesSeen != cur.hdr.SizeInBytes {
+				ferr = fault.New(fault.Size, cur.hdr.Filepath, "'%s' is %v bytes, but its header announced %v", cur.hdr.Filepath, cur.r.bytesSeen, cur.hdr.SizeInBytes)
+			}
+			err = finish(cur, ferr)
+			cur = nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package grpc
+
+import (
+	"net"
+	"reflect"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/devops-filetransfer/filetransfer/server/api"
+	"github.com/devops-filetransfer/filetransfer/server/attr"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/filetransfer/server/store"
+	"github.com/devops-filetransfer/idem"
+)
+
+// inventory is an api.LocalGetSet that remembers nothing.
+type inventory struct{}
+
+func (inventory) LocalGet(key []byte, includeValue bool) (*api.KeyInv, error) {
+	return nil, nil
+}
+
+func (inventory) LocalSet(ki *api.KeyInv) error {
+	return nil
+}
+
+func TestSessionMetadataComesBackOnGet(t *testing.T) {
+	st, err := store.New(t.TempDir(), attr.None)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &ServerConfig{
+		MaxMsgSize: DefaultMaxMsgSize,
+		Halt:       idem.NewHalter(),
+		GrpcServer: grpc.NewServer(),
+		Store:      st,
+	}
+	pb.RegisterPeerServer(cfg.GrpcServer, NewPeerServerClass(inventory{}, cfg))
+	go cfg.GrpcServer.Serve(lis)
+	defer cfg.GrpcServer.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	client := pb.NewPeerClient(conn)
+
+	data := []byte("hello")
+	put := func(md map[string]string) {
+		sess, err := client.Session(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := sess.Send(&pb.SessionMsg{
+			Header: &pb.FileHeader{Filepath: "f", SizeInBytes: int64(len(data)), Metadata: md},
+			Chunk: &pb.BigFileChunk{
+				Filepath:          "f",
+				Data:              data,
+				SizeInBytes:       int64(len(data)),
+				Blake2B:           blake2bOfBytes(data),
+				Blake2BCumulative: blake2bOfBytes(data),
+				IsLastChunk:       true,
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := sess.CloseSend(); err != nil {
+			t.Fatal(err)
+		}
+		if ack, err := sess.Recv(); err != nil || ack.Err != "" {
+			t.Fatalf("session got %v, %v", ack, err)
+		}
+	}
+	get := func() map[string]string {
+		stream, err := client.GetFile(context.Background(), &pb.GetRequest{Filepath: "f"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		nk, err := stream.Recv()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return nk.Metadata
+	}
+
+	md := map[string]string{"owner": "ops", "build": "1234"}
+	put(md)
+	if got := get(); !reflect.DeepEqual(got, md) {
+		t.Errorf("got metadata %v back; want %v", got, md)
+	}
+
+	// stored again without any, it loses what it had.
+	put(nil)
+	if got := get(); len(got) != 0 {
+		t.Errorf("got metadata %v back from a file stored without any", got)
+	}
+}
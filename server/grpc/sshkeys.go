@@ -0,0 +1,107 @@
+package grpc
+
+import (
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/devops-filetransfer/filetransfer/server/audit"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/filetransfer/server/sshkeys"
+)
+
+// The SSH key calls let an administrator manage who may log in
+// over SSH while we run. The authorization policy must grant
+// them "admin"; without a policy, nobody may.
+
+// keysReady returns an error unless the SSH key calls can go ahead.
+func (s *PeerServerClass) keysReady() error {
+	if s.cfg.SSHKeys == nil {
+		return status.Errorf(codes.FailedPrecondition, "this server does not take SSH logins")
+	}
+	if s.cfg.Authz == nil {
+		return status.Errorf(codes.PermissionDenied, "managing SSH keys needs an -authz_policy that allows 'admin'")
+	}
+
+	return nil
+}
+
+func keyMsg(k *sshkeys.Key) *pb.SSHKey {
+	return &pb.SSHKey{
+		User:          k.User,
+		AuthorizedKey: k.AuthorizedKey,
+		Fingerprint:   k.Fingerprint,
+		Type:          k.Type,
+		Comment:       k.Comment,
+		Added:         k.Added.UnixNano(),
+	}
+}
+
+// AddSSHKey implements pb.PeerServer; it lets req.User log in
+// with the public key req.AuthorizedKey.
+func (s *PeerServerClass) AddSSHKey(ctx context.Context, req *pb.SSHKey) (added *pb.SSHKey, err error) {
+	defer func(start time.Time) {
+		rec := &audit.Record{Op: "AddSSHKey", Path: req.User}
+		if added != nil {
+			rec.Detail = added.Type + " " + added.Fingerprint
+		}
+		s.record(ctx, rec, start, err)
+	}(time.Now())
+
+	if err := s.keysReady(); err != nil {
+		return nil, err
+	}
+
+	k, err := s.cfg.SSHKeys.Add(req.User, []byte(req.AuthorizedKey))
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%v", err)
+	}
+
+	s.logger(ctx).Info("added SSH key", "user", k.User, "type", k.Type, "fingerprint", k.Fingerprint)
+
+	return keyMsg(k), nil
+}
+
+// ListSSHKeys implements pb.PeerServer; it returns the keys of
+// req.User, or of everyone.
+func (s *PeerServerClass) ListSSHKeys(ctx context.Context, req *pb.SSHKeyQuery) (*pb.SSHKeyList, error) {
+	if err := s.keysReady(); err != nil {
+		return nil, err
+	}
+
+	keys, err := s.cfg.SSHKeys.List(req.User)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &pb.SSHKeyList{}
+	for i := range keys {
+		list.Keys = append(list.Keys, keyMsg(&keys[i]))
+	}
+
+	return list, nil
+}
+
+// RevokeSSHKey implements pb.PeerServer; it removes the key of
+// req.User with req.Fingerprint, and cuts off SSH connections
+// that logged in with it.
+func (s *PeerServerClass) RevokeSSHKey(ctx context.Context, req *pb.SSHKey) (revoked *pb.SSHKey, err error) {
+	defer func(start time.Time) {
+		s.record(ctx, &audit.Record{Op: "RevokeSSHKey", Path: req.User, Detail: req.Fingerprint}, start, err)
+	}(time.Now())
+
+	if err := s.keysReady(); err != nil {
+		return nil, err
+	}
+
+	k, err := s.cfg.SSHKeys.Revoke(req.User, req.Fingerprint)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "%v", err)
+	}
+
+	s.logger(ctx).Info("revoked SSH key", "user", k.User, "type", k.Type, "fingerprint", k.Fingerprint)
+
+	return keyMsg(k), nil
+}
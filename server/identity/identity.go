@@ -0,0 +1,82 @@
+// Package identity works out who is on the other end of a gRPC call.
+package identity
+
+import (
+	"crypto/x509"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying id as the caller's
+// identity, for when something other than the transport (an
+// interceptor, say) has established who the caller is.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext returns the identity of the caller on ctx: the one
+// set by NewContext if any, otherwise that of the verified client
+// certificate under mutual TLS. It returns "" if we cannot tell.
+func FromContext(ctx context.Context) string {
+	if id, ok := ctx.Value(ctxKey{}).(string); ok && id != "" {
+		return id
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.AuthInfo == nil {
+		return ""
+	}
+
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return ""
+	}
+
+	// only a chain we verified says anything about who this is.
+	chains := info.State.VerifiedChains
+	if len(chains) == 0 || len(chains[0]) == 0 {
+		return ""
+	}
+
+	return FromCert(chains[0][0])
+}
+
+// FromCert returns the identity named by cert. A subject
+// alternative name wins over the subject, as it is the one meant
+// for machines: first a URI (such as a SPIFFE ID), then an email
+// address, then a DNS name; failing those, the subject's common name.
+func FromCert(cert *x509.Certificate) string {
+	if len(cert.URIs) > 0 {
+		return cert.URIs[0].String()
+	}
+
+	if len(cert.EmailAddresses) > 0 {
+		return cert.EmailAddresses[0]
+	}
+
+	if len(cert.DNSNames) > 0 {
+		return cert.DNSNames[0]
+	}
+
+	return cert.Subject.CommonName
+}
+
+// Tenant turns id into a name that is safe to use as a single
+// directory name. Distinct identities always give distinct names.
+func Tenant(id string) string {
+	t := url.QueryEscape(id)
+
+	// QueryEscape leaves dots alone; keep clear of "." and "..",
+	// and of hidden names.
+	if strings.HasPrefix(t, ".") {
+		t = "%2E" + t[1:]
+	}
+
+	return t
+}
@@ -0,0 +1,50 @@
+package identity
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/url"
+	"testing"
+)
+
+func TestFromCertPrefersSAN(t *testing.T) {
+	spiffe, _ := url.Parse("spiffe://example.org/ci-bot")
+
+	cert := &x509.Certificate{
+		Subject:        pkix.Name{CommonName: "alice"},
+		DNSNames:       []string{"alice.example.org"},
+		EmailAddresses: []string{"alice@example.org"},
+	}
+	if got := FromCert(cert); got != "alice@example.org" {
+		t.Fatalf("expected the email SAN, got '%s'", got)
+	}
+
+	cert.URIs = []*url.URL{spiffe}
+	if got := FromCert(cert); got != "spiffe://example.org/ci-bot" {
+		t.Fatalf("expected the URI SAN, got '%s'", got)
+	}
+
+	cert = &x509.Certificate{Subject: pkix.Name{CommonName: "alice"}}
+	if got := FromCert(cert); got != "alice" {
+		t.Fatalf("expected the common name, got '%s'", got)
+	}
+}
+
+func TestTenantIsOneDirectory(t *testing.T) {
+	seen := map[string]string{}
+	for _, id := range []string{"alice", "a/b", "a_b", "..", ".", ".hidden", "spiffe://example.org/x", "a%2Fb"} {
+		tn := Tenant(id)
+		if tn == "" || tn == "." || tn == ".." || tn[0] == '.' {
+			t.Fatalf("Tenant('%s') == '%s' is not a safe directory name", id, tn)
+		}
+		for _, c := range tn {
+			if c == '/' {
+				t.Fatalf("Tenant('%s') == '%s' has a slash", id, tn)
+			}
+		}
+		if other, dup := seen[tn]; dup {
+			t.Fatalf("'%s' and '%s' both map to tenant '%s'", id, other, tn)
+		}
+		seen[tn] = id
+	}
+}
@@ -0,0 +1,89 @@
+package identity
+
+import (
+	"net"
+	"sync"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+)
+
+// SSHLogins knows which SSH login each connection the embedded
+// sshd forwards to us belongs to. The sshd adds each connection as
+// it dials us on behalf of a login whose key it checked, and
+// removes it once closed; a call is then known by the address it
+// comes from. Nothing the client says is taken into account. Use
+// its interceptors only when serving behind the sshd.
+type SSHLogins struct {
+	mu    sync.Mutex
+	users map[string]string // by the address the sshd dials us from
+}
+
+func NewSSHLogins() *SSHLogins {
+	return &SSHLogins{users: make(map[string]string)}
+}
+
+// Add records conn, which the sshd dialed us on, as forwarded for
+// user, the login it let in.
+func (l *SSHLogins) Add(conn net.Conn, user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.users[conn.LocalAddr().String()] = user
+}
+
+// Remove forgets conn, once closed.
+func (l *SSHLogins) Remove(conn net.Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.users, conn.LocalAddr().String())
+}
+
+// user returns the login the call on ctx came in over, or "" if
+// it did not come through the sshd.
+func (l *SSHLogins) user(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.users[p.Addr.String()]
+}
+
+func (l *SSHLogins) withUser(ctx context.Context) context.Context {
+	if user := l.user(ctx); user != "" {
+		return NewContext(ctx, user)
+	}
+
+	return ctx
+}
+
+// UnaryInterceptor takes the caller's identity from the SSH login
+// their connection was forwarded for.
+func (l *SSHLogins) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(l.withUser(ctx), req)
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streams.
+func (l *SSHLogins) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &ctxStream{ServerStream: ss, ctx: l.withUser(ss.Context())})
+	}
+}
+
+// ctxStream is a grpc.ServerStream with its context replaced.
+type ctxStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ctxStream) Context() context.Context {
+	return s.ctx
+}
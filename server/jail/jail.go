@@ -0,0 +1,186 @@
+// Package jail maps the paths that clients give us into
+// a per-tenant directory tree, and refuses any path that
+// could lead out of it.
+package jail
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// MaxPathLen bounds a whole client path, in bytes.
+	MaxPathLen = 4096
+
+	// MaxNameLen bounds each path segment, in bytes. It is
+	// below the usual 255 to leave room for the suffix of
+	// the temporary name a file is received under.
+	MaxNameLen = 200
+
+	// PartialMark is in the temporary name a file is received
+	// under, and so may be in no name a client gives us.
+	PartialMark = ".partial-"
+)
+
+// Clean checks a client supplied path and returns it in
+// canonical form: relative, slash separated, with no empty
+// or "." segments. It rejects absolute paths, ".." segments,
+// NUL bytes, backslashes, over-long paths or names, and names
+// with PartialMark in them.
+func Clean(p string) (string, error) {
+	if p == "" {
+		return "", fmt.Errorf("jail: empty path")
+	}
+
+	if len(p) > MaxPathLen {
+		return "", fmt.Errorf("jail: path of %v bytes is longer than the limit of %v", len(p), MaxPathLen)
+	}
+
+	if strings.IndexByte(p, 0) >= 0 {
+		return "", fmt.Errorf("jail: path '%q' contains a NUL byte", p)
+	}
+
+	if strings.Contains(p, `\`) {
+		return "", fmt.Errorf("jail: path '%s' contains a backslash", p)
+	}
+
+	if strings.HasPrefix(p, "/") || filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return "", fmt.Errorf("jail: path '%s' is absolute", p)
+	}
+
+	var segs []string
+	for _, seg := range strings.Split(p, "/") {
+		switch seg {
+		case "", ".":
+			continue
+		case "..":
+			return "", fmt.Errorf("jail: path '%s' has a '..' segment", p)
+		}
+
+		if len(seg) > MaxNameLen {
+			return "", fmt.Errorf("jail: name '%.32s...' of %v bytes is longer than the limit of %v", seg, len(seg), MaxNameLen)
+		}
+
+		if strings.Contains(seg, PartialMark) {
+			return "", fmt.Errorf("jail: name '%s' is kept for files being received", seg)
+		}
+
+		segs = append(segs, seg)
+	}
+
+	if len(segs) == 0 {
+		return "", fmt.Errorf("jail: path '%s' names no file", p)
+	}
+
+	return strings.Join(segs, "/"), nil
+}
+
+// Jail keeps each tenant's files under Root/<tenant>.
+type Jail struct {
+	// Root is absolute, with any symlinks in it resolved.
+	Root string
+
+	// sidecars are the suffixes of the files kept beside each
+	// file, as "."+name+suffix.
+	sidecars []string
+}
+
+// New returns a Jail at root, creating root if need be. For each
+// of sidecars, names of the form "."+name+suffix are kept for the
+// file kept beside name, and refused to clients.
+func New(root string, sidecars ...string) (*Jail, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(abs, 0700); err != nil {
+		return nil, fmt.Errorf("jail: could not create root '%s': %v", abs, err)
+	}
+
+	real, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Jail{Root: real, sidecars: sidecars}, nil
+}
+
+// TenantRoot returns the directory holding tenant's files.
+func (j *Jail) TenantRoot(tenant string) (string, error) {
+	t, err := Clean(tenant)
+	if err != nil || strings.Contains(t, "/") || strings.HasPrefix(t, ".") {
+		return "", fmt.Errorf("jail: bad tenant name '%s'", tenant)
+	}
+
+	return filepath.Join(j.Root, t), nil
+}
+
+// Resolve maps clientPath, for tenant, to a path on our disk
+// under the tenant's root. Symlinks already on disk along the
+// way are followed only if they stay within the tenant's root.
+func (j *Jail) Resolve(tenant, clientPath string) (string, error) {
+	root, err := j.TenantRoot(tenant)
+	if err != nil {
+		return "", err
+	}
+
+	clean, err := Clean(clientPath)
+	if err != nil {
+		return "", err
+	}
+	if err := j.checkSidecars(clean); err != nil {
+		return "", err
+	}
+
+	cur := root
+	for _, seg := range strings.Split(clean, "/") {
+		next := filepath.Join(cur, seg)
+
+		fi, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			// nothing further down exists yet, so
+			// there are no more symlinks to follow.
+			cur = next
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if fi.Mode()&os.ModeSymlink != 0 {
+			target, err := filepath.EvalSymlinks(next)
+			if err != nil {
+				return "", fmt.Errorf("jail: cannot resolve symlink in '%s': %v", clientPath, err)
+			}
+			if !within(root, target) {
+				return "", fmt.Errorf("jail: path '%s' escapes through a symlink", clientPath)
+			}
+		}
+
+		cur = next
+	}
+
+	return cur, nil
+}
+
+// checkSidecars refuses clean, a Clean path, if any name in it is
+// that of a sidecar.
+func (j *Jail) checkSidecars(clean string) error {
+	for _, seg := range strings.Split(clean, "/") {
+		for _, suffix := range j.sidecars {
+			if len(seg) > len(suffix)+1 && strings.HasPrefix(seg, ".") && strings.HasSuffix(seg, suffix) {
+				return fmt.Errorf("jail: name '%s' is kept for what is stored beside '%s'", seg, seg[1:len(seg)-len(suffix)])
+			}
+		}
+	}
+
+	return nil
+}
+
+// within reports whether path is root or below it.
+func within(root, path string) bool {
+	return path == root || strings.HasPrefix(path, root+string(filepath.Separator))
+}
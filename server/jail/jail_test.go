@@ -0,0 +1,112 @@
+package jail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCleanRejects(t *testing.T) {
+	bad := []string{
+		"",
+		"/etc/passwd",
+		"../../etc/passwd",
+		"a/../../b",
+		"a/..",
+		"a\x00b",
+		`a\..\b`,
+		".",
+		"//",
+		strings.Repeat("x", MaxNameLen+1),
+		strings.Repeat("a/", MaxPathLen/2+1),
+		".x.partial-123",
+		"a/.x.partial-123/b",
+	}
+
+	for _, p := range bad {
+		if c, err := Clean(p); err == nil {
+			t.Errorf("Clean(%q) = %q, want an error", p, c)
+		}
+	}
+}
+
+func TestCleanCanonical(t *testing.T) {
+	cases := map[string]string{
+		"a":              "a",
+		"a/b":            "a/b",
+		"./a//b/./c":     "a/b/c",
+		"artifacts/x.gz": "artifacts/x.gz",
+	}
+
+	for in, want := range cases {
+		got, err := Clean(in)
+		if err != nil || got != want {
+			t.Errorf("Clean(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestResolveStaysInTenantRoot(t *testing.T) {
+	j, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := j.Resolve("alice", "dir/file")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(j.Root, "alice", "dir", "file"); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	for _, tenant := range []string{"", "..", "a/b", ".hidden"} {
+		if _, err := j.Resolve(tenant, "f"); err == nil {
+			t.Errorf("tenant %q was accepted", tenant)
+		}
+	}
+}
+
+func TestResolveRefusesSidecarNames(t *testing.T) {
+	j, err := New(t.TempDir(), ".sig", ".meta")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{".x.sig", "a/.x.sig", ".x.meta", ".x.meta/f"} {
+		if _, err := j.Resolve("alice", p); err == nil {
+			t.Errorf("sidecar name %q was accepted", p)
+		}
+	}
+	for _, p := range []string{"x.sig", ".sig", "x.meta", ".x"} {
+		if _, err := j.Resolve("alice", p); err != nil {
+			t.Errorf("%q was refused: %v", p, err)
+		}
+	}
+}
+
+func TestResolveRejectsSymlinkEscape(t *testing.T) {
+	j, err := New(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	alice := filepath.Join(j.Root, "alice")
+	if err := os.MkdirAll(filepath.Join(alice, "inside"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(t.TempDir(), filepath.Join(alice, "out")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(alice, "inside"), filepath.Join(alice, "in")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := j.Resolve("alice", "out/passwd"); err == nil {
+		t.Fatal("symlink out of the tenant root was followed")
+	}
+	if _, err := j.Resolve("alice", "in/file"); err != nil {
+		t.Fatalf("symlink within the tenant root was refused: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"log/slog"
+	"path"
+	"regexp"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// validID is what we take for a transfer ID from a caller; anything
+// else, we would rather not copy into our logs.
+var validID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// incoming returns ctx as part of the transfer whose ID the caller
+// sent; or, if they sent none we can use, of a new one, so the
+// call can still be followed through our own logs.
+func incoming(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(TransferIDKey); len(ids) > 0 && validID.MatchString(ids[0]) {
+			return WithTransferID(ctx, ids[0])
+		}
+	}
+
+	return WithTransferID(ctx, NewTransferID())
+}
+
+// finished logs how the call to method, begun at start, went.
+func finished(ctx context.Context, method string, start time.Time, err error) {
+	level := slog.LevelInfo
+	attrs := []interface{}{
+		"method", path.Base(method),
+		"code", status.Code(err).String(),
+		"elapsed", time.Since(start),
+	}
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, "err", err)
+	}
+
+	From(ctx).Log(ctx, level, "call finished", attrs...)
+}
+
+// UnaryInterceptor puts each call in its transfer, as incoming
+// says, and logs how it went.
+func UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		ctx = incoming(ctx)
+		From(ctx).Debug("call started", "method", path.Base(info.FullMethod))
+
+		resp, err := handler(ctx, req)
+		finished(ctx, info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// StreamInterceptor is UnaryInterceptor for streams.
+func StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		ctx := incoming(ss.Context())
+		From(ctx).Debug("call started", "method", path.Base(info.FullMethod))
+
+		err := handler(srv, &ctxStream{ServerStream: ss, ctx: ctx})
+		finished(ctx, info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+// ctxStream is a grpc.ServerStream with its context replaced.
+type ctxStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *ctxStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestInterceptorFollowsTheClientsTransferID(t *testing.T) {
+	defer slog.SetDefault(slog.Default())
+
+	var out bytes.Buffer
+	if err := Setup(&out, "info", JSON); err != nil {
+		t.Fatal(err)
+	}
+
+	call := func(sent string) (got string, logged map[string]interface{}) {
+		out.Reset()
+		ctx := context.Background()
+		if sent != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(TransferIDKey, sent))
+		}
+
+		info := &grpc.UnaryServerInfo{FullMethod: "/streambigfile.Peer/DeleteFile"}
+		_, err := UnaryInterceptor()(ctx, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			got = TransferID(ctx)
+			From(ctx).Info("handled")
+			return nil, nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// the handler's record, then the interceptor's.
+		dec := json.NewDecoder(&out)
+		for dec.More() {
+			logged = nil
+			if err := dec.Decode(&logged); err != nil {
+				t.Fatal(err)
+			}
+			if logged[TransferIDAttr] != got {
+				t.Errorf("logged %v under %v; want %v", logged["msg"], logged[TransferIDAttr], got)
+			}
+		}
+		return got, logged
+	}
+
+	if got, logged := call("0123abcd"); got != "0123abcd" || logged["method"] != "DeleteFile" || logged["code"] != "OK" {
+		t.Errorf("got transfer %v, logged %v", got, logged)
+	}
+
+	// none, or one we will not log, gets a new one.
+	for _, sent := range []string{"", "not\nan id"} {
+		got, _ := call(sent)
+		if got == "" || got == sent {
+			t.Errorf("sent '%s', got transfer '%s'", sent, got)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+// Package logging sets up our structured, leveled logs, and follows
+// each transfer through them by an ID that the client makes up for
+// it and sends along as gRPC metadata, so that one upload can be
+// picked out of the client's, the server's and any replica's logs.
+package logging
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc/metadata"
+)
+
+// TransferIDKey is the gRPC metadata key a transfer's ID goes under.
+const TransferIDKey = "x-transfer-id"
+
+// TransferIDAttr is the attribute a transfer's ID is logged as.
+const TransferIDAttr = "transfer_id"
+
+// TraceIDAttr is the attribute the ID of the trace a record was
+// logged in is logged as, when tracing.
+const TraceIDAttr = "trace_id"
+
+// The formats we can log in.
+const (
+	Text = "text"
+	JSON = "json"
+)
+
+// New returns a logger writing to w, in format, the records at
+// level (debug, info, warn or error) and above.
+func New(w io.Writer, level, format string) (*slog.Logger, error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("-log_level '%s' is not one of debug, info, warn or error", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	switch format {
+	case Text:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case JSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	}
+
+	return nil, fmt.Errorf("-log_format '%s' is neither %s nor %s", format, Text, JSON)
+}
+
+// Setup makes a logger from New the default. What the standard
+// log package is given then goes through it too, at info level.
+func Setup(w io.Writer, level, format string) error {
+	l, err := New(w, level, format)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(l)
+
+	return nil
+}
+
+// NewTransferID makes up an ID for a transfer.
+func NewTransferID() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(b[:])
+}
+
+type transferKey struct{}
+
+// WithTransferID returns ctx, as part of the transfer id.
+func WithTransferID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, transferKey{}, id)
+}
+
+// TransferID returns the ID of the transfer ctx is part of, if any.
+func TransferID(ctx context.Context) string {
+	id, _ := ctx.Value(transferKey{}).(string)
+	return id
+}
+
+// NewTransfer returns ctx as part of a new transfer, whose ID goes
+// along on the calls made with it.
+func NewTransfer(ctx context.Context) context.Context {
+	return Outgoing(WithTransferID(ctx, NewTransferID()))
+}
+
+// Outgoing returns ctx with the ID of the transfer it is part of,
+// if any, set to go along on the calls made with it; such as those
+// a server makes to its peers to replicate what it was sent.
+func Outgoing(ctx context.Context) context.Context {
+	id := TransferID(ctx)
+	if id == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, TransferIDKey, id)
+}
+
+// From returns the default logger, noting the transfer ctx is
+// part of, and the trace, if any.
+func From(ctx context.Context) *slog.Logger {
+	l := slog.Default()
+	if id := TransferID(ctx); id != "" {
+		l = l.With(TransferIDAttr, id)
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		l = l.With(TraceIDAttr, sc.TraceID().String())
+	}
+
+	return l
+}
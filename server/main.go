@@ -7,32 +7,57 @@ import (
 	"log"
 	"net"
 	"os"
+	"os/signal"
 	"runtime/pprof"
+	"strings"
+	"syscall"
 
+	"golang.org/x/net/context"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/reflection"
 
 	"github.com/devops-filetransfer/filetransfer/server/api"
+	"github.com/devops-filetransfer/filetransfer/server/attr"
+	"github.com/devops-filetransfer/filetransfer/server/audit"
+	"github.com/devops-filetransfer/filetransfer/server/authz"
 	_grpc "github.com/devops-filetransfer/filetransfer/server/grpc"
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	"github.com/devops-filetransfer/filetransfer/server/logging"
+	"github.com/devops-filetransfer/filetransfer/server/manifest"
+	"github.com/devops-filetransfer/filetransfer/server/metrics"
 	"github.com/devops-filetransfer/filetransfer/server/print"
 	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/filetransfer/server/seal"
+	"github.com/devops-filetransfer/filetransfer/server/settings"
 	"github.com/devops-filetransfer/filetransfer/server/ssh"
+	"github.com/devops-filetransfer/filetransfer/server/sshkeys"
+	"github.com/devops-filetransfer/filetransfer/server/store"
+	"github.com/devops-filetransfer/filetransfer/server/token"
+	"github.com/devops-filetransfer/filetransfer/server/tracing"
+	"github.com/devops-filetransfer/idem"
+	tun "github.com/devops-filetransfer/sshego"
 )
 
 const ProgramName = "server"
 
 func main() {
-	myflags := flag.NewFlagSet(ProgramName, flag.ExitOnError)
-
-	cfg := &_grpc.ServerConfig{}
-	cfg.DefineFlags(myflags)
-	cfg.SkipEncryption = true
-
-	sshegoCfg := ssh.SetupSshFlags(myflags)
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatalf("%s %s: %s", ProgramName, os.Args[1], err)
+		}
+		return
+	}
 
-	args := os.Args[1:]
-	if err := myflags.Parse(args); err != nil {
-		log.Fatalf("%s parse args error: '%s'", ProgramName, err)
+	cfg, sshegoCfg, _, err := loadConfig(os.Args[1:], flag.ExitOnError)
+	if err != nil {
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
+	}
+	if err := logging.Setup(os.Stderr, cfg.LogLevel, cfg.LogFormat); err != nil {
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
+	}
+	stopTracing, err := tracing.Setup(cfg.Trace, ProgramName)
+	if err != nil {
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
 	}
 
 	if cfg.CpuProfilePath != "" {
@@ -45,7 +70,30 @@ func main() {
 	}
 
 	if err := cfg.ValidateConfig(); err != nil {
-		log.Fatalf("%s command line flag error: '%s'", ProgramName, err)
+		log.Fatalf("%s config error: '%s'", ProgramName, err)
+	}
+	if err := cfg.CheckPorts(); err != nil {
+		log.Fatalf("%s: %s", ProgramName, err)
+	}
+
+	if cfg.StoreDir != "" {
+		policy, _ := attr.ParsePolicy(cfg.AttrPolicy)
+		st, err := store.New(cfg.StoreDir, policy)
+		if err != nil {
+			log.Fatalf("%s could not open store: '%s'", ProgramName, err)
+		}
+		st.RootSetID = cfg.AttrRootSetID
+		cfg.Store = st
+		print.P("keeping received files under '%s', applying %v attributes", st.Root, policy)
+
+		if cfg.StoreKeyPath != "" {
+			k, err := seal.LoadKeyFile(cfg.StoreKeyPath)
+			if err != nil {
+				log.Fatalf("%s could not load -store_key: '%s'", ProgramName, err)
+			}
+			st.Keys = k
+			print.P("encrypting stored files under master key %s from '%s'", k.ID(), cfg.StoreKeyPath)
+		}
 	}
 
 	var gRpcBindPort int
@@ -56,6 +104,9 @@ func main() {
 		gRpcBindPort = cfg.ExternalLsnPort
 		gRpcHost = cfg.Host
 		print.P("gRPC with TLS listening on %v:%v", gRpcHost, gRpcBindPort)
+		if cfg.ClientCAPath != "" {
+			print.P("requiring client certificates signed by a CA in '%s'", cfg.ClientCAPath)
+		}
 	} else if cfg.SkipEncryption {
 		// no encryption at all
 		gRpcBindPort = cfg.ExternalLsnPort
@@ -74,29 +125,249 @@ func main() {
 
 	var opts []grpc.ServerOption
 
+	// reloads are run on SIGHUP.
+	var reloads []func()
+
+	var sshd *ssh.Daemon
+
+	// sshLogins, under SSH, knows who each tunnel is for.
+	var sshLogins *identity.SSHLogins
+
 	if cfg.UseTLS {
 		// use TLS
-		creds, err := credentials.NewServerTLSFromFile(cfg.CertPath, cfg.KeyPath)
+		creds, err := cfg.TLSCredentials()
 		if err != nil {
 			log.Fatalf("Failed to generate credentials %v", err)
 		}
-		opts = []grpc.ServerOption{grpc.Creds(creds)}
+		opts = append(opts, grpc.Creds(creds))
+
+		// new handshakes pick up rotated certificates; those
+		// already connected carry on undisturbed.
+		go cfg.Certs.Watch(nil)
+		metrics.CertExpiry(cfg.Certs.NotAfter)
+		reloads = append(reloads, func() {
+			if err := cfg.Certs.Reload(); err != nil {
+				log.Printf("%s keeping the old certificate: '%s'", ProgramName, err)
+			}
+		})
 	} else if cfg.SkipEncryption {
 		// no encryption
 		print.P("server configured to skip encryption.")
 	} else {
 		// use SSH
-		err = ssh.ServerSshMain(sshegoCfg, cfg.Host, cfg.ExternalLsnPort, cfg.InternalLsnPort)
+		keys, err := sshkeys.Open(cfg.SSHKeysPath)
+		if err != nil {
+			log.Fatalf("%s could not read SSH keys: '%s'", ProgramName, err)
+		}
+		cfg.SSHKeys = keys
+
+		sshLogins = identity.NewSSHLogins()
+		sshd, err = ssh.ServerSshMain(sshegoCfg, keys, sshLogins, cfg.Host, cfg.ExternalLsnPort, cfg.InternalLsnPort)
 		print.PanicOn(err)
 	}
 
+	opts = append(opts, cfg.ServerOptions()...)
+
+	// metrics come first, so they see every call, refused or not;
+	// then tracing, so the logs can note the trace; then logging,
+	// so what follows knows the transfer ID.
+	unary := []grpc.UnaryServerInterceptor{metrics.UnaryInterceptor()}
+	stream := []grpc.StreamServerInterceptor{metrics.StreamInterceptor()}
+	if cfg.Trace != "" {
+		unary = append(unary, tracing.UnaryInterceptor())
+		stream = append(stream, tracing.StreamInterceptor())
+		print.P("sending traces to '%s'", cfg.Trace)
+	}
+	unary = append(unary, logging.UnaryInterceptor())
+	stream = append(stream, logging.StreamInterceptor())
+
+	if cfg.TokenKeyPath != "" {
+		key, err := token.LoadKey(cfg.TokenKeyPath)
+		if err != nil {
+			log.Fatalf("%s could not load token key: '%s'", ProgramName, err)
+		}
+		auth := &token.Authenticator{Key: key}
+		unary = append(unary, auth.UnaryInterceptor())
+		stream = append(stream, auth.StreamInterceptor())
+		print.P("requiring bearer tokens checked against '%s'", cfg.TokenKeyPath)
+	} else if sshLogins != nil {
+		// behind the sshd, callers are known by their SSH login.
+		unary = append(unary, sshLogins.UnaryInterceptor())
+		stream = append(stream, sshLogins.StreamInterceptor())
+	}
+
+	if cfg.AuditLogPath != "" {
+		al, err := audit.Open(cfg.AuditLogPath, cfg.AuditMaxSize, cfg.AuditMaxAge)
+		if err != nil {
+			log.Fatalf("%s could not open the audit log: '%s'", ProgramName, err)
+		}
+		cfg.Audit = al
+		// after we know who is calling, before they may be refused.
+		unary = append(unary, al.UnaryInterceptor())
+		stream = append(stream, al.StreamInterceptor())
+		print.P("keeping the audit log in '%s'", al.Path)
+	}
+
+	if cfg.AuthzPolicyPath != "" {
+		az, err := authz.Load(cfg.AuthzPolicyPath)
+		if err != nil {
+			log.Fatalf("%s could not load authorization policy: '%s'", ProgramName, err)
+		}
+		cfg.Authz = az
+		unary = append(unary, az.UnaryInterceptor())
+		stream = append(stream, az.StreamInterceptor())
+		print.P("enforcing authorization policy '%s'; send SIGHUP to reload it", az.Path)
+
+		reloads = append(reloads, func() {
+			if err := az.Reload(); err != nil {
+				log.Printf("%s keeping the old authorization policy: '%s'", ProgramName, err)
+				return
+			}
+			print.P("reloaded authorization policy '%s'", az.Path)
+		})
+	}
+
+	if cfg.TrustedSignersPath != "" {
+		tr, err := manifest.LoadTrusted(cfg.TrustedSignersPath)
+		if err != nil {
+			log.Fatalf("%s could not load -trusted_signers: '%s'", ProgramName, err)
+		}
+		cfg.Signers = tr
+		print.P("checking signed uploads against %v trusted keys in '%s'; send SIGHUP to reload them", tr.Len(), tr.Path)
+		if cfg.RequireSigned {
+			print.P("refusing unsigned uploads")
+		}
+
+		reloads = append(reloads, func() {
+			if err := tr.Reload(); err != nil {
+				log.Printf("%s keeping the old trusted signers: '%s'", ProgramName, err)
+				return
+			}
+			print.P("reloaded %v trusted signers from '%s'", tr.Len(), tr.Path)
+		})
+	}
+
+	if cfg.MetricsAddr != "" {
+		addr, err := metrics.Serve(cfg.MetricsAddr)
+		if err != nil {
+			log.Fatalf("%s could not serve metrics: '%s'", ProgramName, err)
+		}
+		print.P("serving Prometheus metrics at http://%v/metrics", addr)
+	}
+
+	if len(reloads) > 0 {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				for _, reload := range reloads {
+					reload()
+				}
+			}
+		}()
+	}
+
+	opts = append(opts, grpc.ChainUnaryInterceptor(unary...), grpc.ChainStreamInterceptor(stream...))
+
 	peer := NewPeerMemoryOnly()
 
 	grpcServer := grpc.NewServer(opts...)
-	pb.RegisterPeerServer(grpcServer, _grpc.NewPeerServerClass(peer, cfg))
+	cfg.GrpcServer = grpcServer
+	cfg.Cls = _grpc.NewPeerServerClass(peer, cfg)
+	pb.RegisterPeerServer(grpcServer, cfg.Cls)
+
+	// we are ready while we can store files, and let clients in.
+	cfg.Health = _grpc.NewHealth()
+	if cfg.Store != nil {
+		cfg.Health.Add("store", cfg.Store.Check)
+	}
+	if sshd != nil {
+		cfg.Health.Add("sshd", sshd.Ready)
+	}
+	cfg.Health.Register(grpcServer)
+	go cfg.Health.Watch(cfg.Halt.ReqStop.Chan)
+
+	if cfg.Reflection {
+		reflection.Register(grpcServer)
+		print.P("offering gRPC reflection")
+	}
+
+	go shutdownOnSignal(cfg, sshd, stopTracing)
+
 	if err := grpcServer.Serve(lis); err != nil {
 		log.Fatalf("failed to run grpcserver: %v", err)
 	}
+
+	// Serve returns as soon as draining starts.
+	<-cfg.Halt.Done.Chan
+	print.P("%s shut down cleanly", ProgramName)
+}
+
+// EnvPrefix starts the names of the environment variables that
+// set our flags, as FILETRANSFER_SERVER_STORE sets -store.
+const EnvPrefix = "FILETRANSFER_SERVER_"
+
+// loadConfig parses our flags from args, taking those not given
+// from the environment, and then from our config file.
+func loadConfig(args []string, onError flag.ErrorHandling) (*_grpc.ServerConfig, *tun.SshegoConfig, *settings.Effective, error) {
+	myflags := flag.NewFlagSet(ProgramName, onError)
+
+	cfg := &_grpc.ServerConfig{Halt: idem.NewHalter()}
+	cfg.DefineFlags(myflags)
+	cfg.SkipEncryption = true
+
+	sshegoCfg := ssh.SetupSshFlags(myflags)
+
+	src := &settings.Sources{EnvPrefix: EnvPrefix, Secrets: []string{"mailgun-secretkey"}}
+	src.DefineFlags(myflags, settings.DefaultPath(ProgramName), false)
+
+	if err := myflags.Parse(args); err != nil {
+		return nil, nil, nil, err
+	}
+
+	eff, err := src.Load(myflags)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return cfg, sshegoCfg, eff, nil
+}
+
+// shutdownOnSignal waits for SIGTERM or SIGINT, then drains the
+// calls in flight, stops the sshd, makes sure what we stored is
+// on disk, sends off the last traces, and marks cfg.Halt done. A
+// second signal exits at once.
+func shutdownOnSignal(cfg *_grpc.ServerConfig, sshd *ssh.Daemon, stopTracing func(context.Context) error) {
+	sigs := make(chan os.Signal, 2)
+	signal.Notify(sigs, syscall.SIGTERM, syscall.SIGINT)
+
+	sig := <-sigs
+	print.P("%s got %v; draining calls in flight for up to %v (again to exit at once)", ProgramName, sig, cfg.DrainTimeout)
+	go func() {
+		sig := <-sigs
+		log.Fatalf("%s got %v again; exiting without draining", ProgramName, sig)
+	}()
+
+	cfg.Drain()
+
+	if sshd != nil {
+		sshd.Close()
+	}
+	if cfg.Store != nil {
+		if err := cfg.Store.Flush(); err != nil {
+			log.Printf("%s: %v", ProgramName, err)
+		}
+	}
+	if cfg.Audit != nil {
+		if err := cfg.Audit.Close(); err != nil {
+			log.Printf("%s could not close the audit log: '%s'", ProgramName, err)
+		}
+	}
+	if err := stopTracing(context.Background()); err != nil {
+		log.Printf("%s could not send the last traces: '%s'", ProgramName, err)
+	}
+
+	cfg.Halt.MarkDone()
 }
 
 func NewPeerMemoryOnly() *PeerMemoryOnly {
@@ -0,0 +1,228 @@
+// Package manifest records who produced an uploaded file. The
+// sender signs a manifest of the file's path, size, whole-file
+// Blake2b and the time of signing with an Ed25519 key; the server
+// checks it against the keys it trusts before committing the file,
+// and hands it back with downloads, so anyone holding the file
+// can check where it came from without asking us.
+//
+// A signed manifest is a PEM block: the manifest itself, as JSON,
+// with the signer's public key and the signature in its headers.
+package manifest
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// blockType is the PEM block type of a signed manifest.
+const blockType = "SIGNED MANIFEST"
+
+// label keeps our signatures from being mistaken for ones
+// made by the same key for anything else.
+const label = "filetransfer/manifest/v1\x00"
+
+// SigSuffix is added to a file's name for the name of its
+// signed manifest.
+const SigSuffix = ".sig"
+
+// Manifest describes one uploaded file.
+type Manifest struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	Blake2b string    `json:"blake2b"`
+	Time    time.Time `json:"time"`
+}
+
+// New returns the manifest of a file of size bytes with the
+// whole-file checksum sum, to be stored as path, signed now.
+func New(path string, size int64, sum []byte) *Manifest {
+	return &Manifest{
+		Path:    path,
+		Size:    size,
+		Blake2b: hex.EncodeToString(sum),
+		Time:    time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Check says whether m describes a file of size bytes with the
+// whole-file checksum sum.
+func (m *Manifest) Check(size int64, sum []byte) error {
+	if m.Size != size {
+		return fmt.Errorf("manifest: '%s' was signed at %v bytes, but is %v", m.Path, m.Size, size)
+	}
+	if m.Blake2b != hex.EncodeToString(sum) {
+		return fmt.Errorf("manifest: '%s' was signed with checksum '%s', but has '%x'", m.Path, m.Blake2b, sum)
+	}
+
+	return nil
+}
+
+// Sign returns m signed with key.
+func Sign(m *Manifest, key ed25519.PrivateKey) ([]byte, error) {
+	body, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{
+		Type: blockType,
+		Headers: map[string]string{
+			"Public-Key": strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+			"Signature":  base64.StdEncoding.EncodeToString(ed25519.Sign(key, append([]byte(label), body...))),
+		},
+		Bytes: body,
+	}), nil
+}
+
+// LoadSigner reads the Ed25519 private key at path, in OpenSSH's
+// format, as 'client ssh keygen' and ssh-keygen write them.
+func LoadSigner(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ssh.ParseRawPrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("'%s': %v", path, err)
+	}
+
+	switch k := key.(type) {
+	case ed25519.PrivateKey:
+		return k, nil
+	case *ed25519.PrivateKey:
+		return *k, nil
+	}
+
+	return nil, fmt.Errorf("'%s' is not an Ed25519 key; only those can sign manifests", path)
+}
+
+// Signed is a manifest whose signature has been checked.
+type Signed struct {
+	*Manifest
+
+	// Signer names the trusted key it was signed with.
+	Signer      string
+	Fingerprint string
+}
+
+// Trusted holds the keys, read from Path, whose signatures we
+// accept. It can be reloaded while we are serving.
+type Trusted struct {
+	Path string
+
+	mu   sync.RWMutex
+	keys map[string]string // by fingerprint, the signer's name
+}
+
+// LoadTrusted reads the trusted keys at path, in authorized_keys
+// form, one per line. Each is known by its comment, or by its
+// fingerprint if it has none.
+func LoadTrusted(path string) (*Trusted, error) {
+	t := &Trusted{Path: path}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Reload reads the trusted keys again. If they cannot be read,
+// those already in force stay in force.
+func (t *Trusted) Reload() error {
+	data, err := os.ReadFile(t.Path)
+	if err != nil {
+		return err
+	}
+
+	keys := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(line))
+		if err != nil {
+			return fmt.Errorf("trusted signers '%s' line %v: %v", t.Path, n, err)
+		}
+		if pub.Type() != ssh.KeyAlgoED25519 {
+			return fmt.Errorf("trusted signers '%s' line %v: %s key; only Ed25519 keys sign manifests", t.Path, n, pub.Type())
+		}
+
+		fp := ssh.FingerprintSHA256(pub)
+		if comment == "" {
+			comment = fp
+		}
+		keys[fp] = comment
+	}
+
+	t.mu.Lock()
+	t.keys = keys
+	t.mu.Unlock()
+
+	return nil
+}
+
+// Len is how many keys we trust.
+func (t *Trusted) Len() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return len(t.keys)
+}
+
+// Verify checks that signed is a manifest signed by one of our
+// keys, and returns it.
+func (t *Trusted) Verify(signed []byte) (*Signed, error) {
+	b, rest := pem.Decode(signed)
+	if b == nil || b.Type != blockType || len(bytes.TrimSpace(rest)) > 0 {
+		return nil, fmt.Errorf("manifest: not a signed manifest")
+	}
+
+	pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(b.Headers["Public-Key"]))
+	if err != nil {
+		return nil, fmt.Errorf("manifest: bad Public-Key: %v", err)
+	}
+	fp := ssh.FingerprintSHA256(pub)
+
+	t.mu.RLock()
+	name, ok := t.keys[fp]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("manifest: signed by %s, which is not a trusted key", fp)
+	}
+
+	// a trusted key is an Ed25519 one.
+	raw := ed25519.PublicKey(pub.(ssh.CryptoPublicKey).CryptoPublicKey().(ed25519.PublicKey))
+
+	sig, err := base64.StdEncoding.DecodeString(b.Headers["Signature"])
+	if err != nil || !ed25519.Verify(raw, append([]byte(label), b.Bytes...), sig) {
+		return nil, fmt.Errorf("manifest: signature by %s (%s) does not check out", name, fp)
+	}
+
+	m := &Manifest{}
+	if err := json.Unmarshal(b.Bytes, m); err != nil {
+		return nil, fmt.Errorf("manifest: %v", err)
+	}
+
+	return &Signed{Manifest: m, Signer: name, Fingerprint: fp}, nil
+}
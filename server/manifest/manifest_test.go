@@ -0,0 +1,108 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func newKey(t *testing.T) ed25519.PrivateKey {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return priv
+}
+
+func trust(t *testing.T, lines ...string) *Trusted {
+	path := filepath.Join(t.TempDir(), "trusted_signers")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := LoadTrusted(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return tr
+}
+
+func authorized(t *testing.T, key ed25519.PrivateKey, comment string) string {
+	pub, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))) + " " + comment
+}
+
+func TestSignVerify(t *testing.T) {
+	builder, stranger := newKey(t), newKey(t)
+	tr := trust(t, "# release builders", authorized(t, builder, "ci@build"))
+
+	sum := bytes.Repeat([]byte{7}, 64)
+	signed, err := Sign(New("dist/app.tar", 1234, sum), builder)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := tr.Verify(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Signer != "ci@build" || s.Path != "dist/app.tar" {
+		t.Fatalf("got signer '%s', path '%s'", s.Signer, s.Path)
+	}
+	if err := s.Check(1234, sum); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Check(1234, bytes.Repeat([]byte{8}, 64)); err == nil {
+		t.Fatal("passed a file with another checksum")
+	}
+	if err := s.Check(1235, sum); err == nil {
+		t.Fatal("passed a file of another size")
+	}
+
+	other, _ := Sign(New("dist/app.tar", 1234, sum), stranger)
+	if _, err := tr.Verify(other); err == nil || !strings.Contains(err.Error(), "not a trusted key") {
+		t.Fatalf("accepted a stranger's signature: %v", err)
+	}
+
+	// change the manifest under its signature.
+	b, _ := pem.Decode(signed)
+	b.Bytes = bytes.Replace(b.Bytes, []byte("app.tar"), []byte("evil.sh"), 1)
+	if _, err := tr.Verify(pem.EncodeToMemory(b)); err == nil {
+		t.Fatal("accepted an altered manifest")
+	}
+}
+
+func TestTrustedOnlyEd25519(t *testing.T) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := ssh.NewPublicKey(&k.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "trusted_signers")
+	if err := os.WriteFile(path, ssh.MarshalAuthorizedKey(pub), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadTrusted(path); err == nil || !strings.Contains(err.Error(), "only Ed25519") {
+		t.Fatalf("took a key that is not Ed25519: %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package metrics
+
+import (
+	"net"
+	"path"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+// chunkOf returns the chunk of file data m carries, if any.
+func chunkOf(m interface{}) *pb.BigFileChunk {
+	switch m := m.(type) {
+	case *pb.BigFileChunk:
+		return m
+	case *pb.SessionMsg:
+		return m.Chunk
+	}
+
+	return nil
+}
+
+func observe(method string, start time.Time, err error) {
+	rpcDuration.WithLabelValues(path.Base(method), status.Code(err).String()).Observe(time.Since(start).Seconds())
+}
+
+// UnaryInterceptor times unary calls.
+func UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		observe(info.FullMethod, start, err)
+
+		return resp, err
+	}
+}
+
+// StreamInterceptor times streams, keeps count of those open, and
+// counts the chunks that go each way on them.
+func StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		active := activeStreams.WithLabelValues(path.Base(info.FullMethod))
+		active.Inc()
+		defer active.Dec()
+
+		err := handler(srv, &countedStream{ss})
+		observe(info.FullMethod, start, err)
+
+		return err
+	}
+}
+
+type countedStream struct {
+	grpc.ServerStream
+}
+
+func (s *countedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if nk := chunkOf(m); nk != nil {
+		chunksReceived.Inc()
+		bytesReceived.Add(float64(len(nk.Data)))
+	}
+
+	return nil
+}
+
+func (s *countedStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	if nk := chunkOf(m); nk != nil {
+		chunksSent.Inc()
+		bytesSent.Add(float64(len(nk.Data)))
+	}
+
+	return nil
+}
+
+// Handshakes wraps creds, counting the handshakes that fail as
+// failures of transport.
+func Handshakes(creds credentials.TransportCredentials, transport string) credentials.TransportCredentials {
+	return &countedCreds{TransportCredentials: creds, transport: transport}
+}
+
+type countedCreds struct {
+	credentials.TransportCredentials
+	transport string
+}
+
+func (c *countedCreds) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, info, err := c.TransportCredentials.ServerHandshake(conn)
+	if err != nil {
+		HandshakeFailed(c.transport)
+	}
+
+	return conn, info, err
+}
+
+func (c *countedCreds) Clone() credentials.TransportCredentials {
+	return Handshakes(c.TransportCredentials.Clone(), c.transport)
+}
@@ -0,0 +1,89 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+)
+
+// fakeStream hands out chunks, and takes whatever is sent.
+type fakeStream struct {
+	grpc.ServerStream
+	chunks []*pb.BigFileChunk
+}
+
+func (s *fakeStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *fakeStream) RecvMsg(m interface{}) error {
+	if len(s.chunks) == 0 {
+		return io.EOF
+	}
+	*m.(*pb.BigFileChunk) = *s.chunks[0]
+	s.chunks = s.chunks[1:]
+
+	return nil
+}
+
+func (s *fakeStream) SendMsg(m interface{}) error {
+	return nil
+}
+
+func scrape(t *testing.T) string {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if rec.Code != 200 {
+		t.Fatalf("scrape got %v", rec.Code)
+	}
+
+	return rec.Body.String()
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	ss := &fakeStream{chunks: []*pb.BigFileChunk{
+		{Filepath: "a", Data: make([]byte, 100)},
+		{Filepath: "a", Data: make([]byte, 23), IsLastChunk: true},
+	}}
+	info := &grpc.StreamServerInfo{FullMethod: "/protobuf.Peer/SendFile"}
+
+	err := StreamInterceptor()(nil, ss, info, func(srv interface{}, stream grpc.ServerStream) error {
+		if got := scrape(t); !strings.Contains(got, `filetransfer_server_active_streams{method="SendFile"} 1`) {
+			t.Errorf("stream not counted as active:\n%s", got)
+		}
+		for {
+			nk := &pb.BigFileChunk{}
+			if err := stream.RecvMsg(nk); err == io.EOF {
+				break
+			}
+		}
+		return stream.SendMsg(&pb.BigFileChunk{Data: make([]byte, 7)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ChecksumFailed(Cumulative)
+
+	got := scrape(t)
+	for _, want := range []string{
+		"filetransfer_server_bytes_received_total 123",
+		"filetransfer_server_chunks_received_total 2",
+		"filetransfer_server_bytes_sent_total 7",
+		"filetransfer_server_chunks_sent_total 1",
+		`filetransfer_server_active_streams{method="SendFile"} 0`,
+		`filetransfer_server_rpc_duration_seconds_count{code="OK",method="SendFile"} 1`,
+		`filetransfer_server_checksum_failures_total{type="cumulative"} 1`,
+		`filetransfer_server_checksum_failures_total{type="chunk"} 0`,
+		`filetransfer_server_handshake_failures_total{transport="ssh"} 0`,
+	} {
+		if !strings.Contains(got, want+"\n") {
+			t.Errorf("missing '%s' in:\n%s", want, got)
+		}
+	}
+}
@@ -0,0 +1,229 @@
+// Package pki keeps a small local certificate authority, so
+// that TLS works without borrowing anyone else's certificates.
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// The files we keep in the directory.
+const (
+	CAFile         = "ca.pem"
+	CAKeyFile      = "ca.key"
+	ServerCertFile = "server.pem"
+	ServerKeyFile  = "server.key"
+)
+
+// DefaultDir is where we keep our certificates unless told otherwise.
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	return filepath.Join(home, ".filetransfer", "tls")
+}
+
+// CA is a certificate authority whose key we hold.
+type CA struct {
+	Cert *x509.Certificate
+	Key  crypto.Signer
+}
+
+// InitCA creates a new CA in dir, unless there is one there already,
+// and returns it.
+func InitCA(dir string, validFor time.Duration) (*CA, error) {
+	if _, err := os.Stat(filepath.Join(dir, CAFile)); err == nil {
+		return LoadCA(dir)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	tmpl, err := template(pkix.Name{CommonName: "filetransfer CA " + host}, validFor)
+	if err != nil {
+		return nil, err
+	}
+	tmpl.IsCA = true
+	tmpl.BasicConstraintsValid = true
+	tmpl.MaxPathLenZero = true
+	tmpl.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeKey(filepath.Join(dir, CAKeyFile), key); err != nil {
+		return nil, err
+	}
+	if err := writeCert(filepath.Join(dir, CAFile), der); err != nil {
+		return nil, err
+	}
+
+	return LoadCA(dir)
+}
+
+// LoadCA reads the CA kept in dir.
+func LoadCA(dir string) (*CA, error) {
+	cert, err := ReadCert(filepath.Join(dir, CAFile))
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, CAKeyFile))
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("'%s' is not PEM encoded", filepath.Join(dir, CAKeyFile))
+	}
+	k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := k.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("'%s' cannot sign", filepath.Join(dir, CAKeyFile))
+	}
+
+	return &CA{Cert: cert, Key: key}, nil
+}
+
+// IssueServer writes a new server key and certificate into dir,
+// good for each of hosts, which are names or IP addresses.
+func (ca *CA) IssueServer(dir string, hosts []string, validFor time.Duration) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+
+	tmpl, err := template(pkix.Name{CommonName: hosts[0]}, validFor)
+	if err != nil {
+		return err
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.Cert, key.Public(), ca.Key)
+	if err != nil {
+		return err
+	}
+
+	if err := writeKey(filepath.Join(dir, ServerKeyFile), key); err != nil {
+		return err
+	}
+
+	return writeCert(filepath.Join(dir, ServerCertFile), der)
+}
+
+// SignClient issues a client certificate for the request in csrPEM.
+// The subject and any URI, email or DNS names come from the request,
+// and it is up to whoever runs this to check they are right.
+func (ca *CA) SignClient(csrPEM []byte, validFor time.Duration) (*x509.Certificate, []byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, nil, fmt.Errorf("not a PEM encoded certificate request")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, nil, fmt.Errorf("certificate request signature: %v", err)
+	}
+
+	tmpl, err := template(csr.Subject, validFor)
+	if err != nil {
+		return nil, nil, err
+	}
+	tmpl.URIs = csr.URIs
+	tmpl.EmailAddresses = csr.EmailAddresses
+	tmpl.DNSNames = csr.DNSNames
+	tmpl.KeyUsage = x509.KeyUsageDigitalSignature
+	tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.Cert, csr.PublicKey, ca.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return cert, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// ReadCert reads the first certificate in the PEM file at path.
+func ReadCert(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("no PEM encoded certificate in '%s'", path)
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func template(subject pkix.Name, validFor time.Duration) (*x509.Certificate, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+
+	return &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      subject,
+		// allow for clocks a little behind ours.
+		NotBefore: now.Add(-time.Hour),
+		NotAfter:  now.Add(validFor),
+	}, nil
+}
+
+func writeKey(path string, key *ecdsa.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0600)
+}
+
+func writeCert(path string, der []byte) error {
+	return os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
@@ -0,0 +1,72 @@
+package pki
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestIssueAndSign(t *testing.T) {
+	dir := t.TempDir()
+
+	ca, err := InitCA(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a second init keeps the CA we have.
+	again, err := InitCA(dir, 24*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !again.Cert.Equal(ca.Cert) {
+		t.Fatal("InitCA replaced an existing CA")
+	}
+
+	if err := ca.IssueServer(dir, []string{"127.0.0.1", "files.example.org"}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Cert)
+
+	srv, err := ReadCert(filepath.Join(dir, ServerCertFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range []string{"127.0.0.1", "files.example.org"} {
+		if _, err := srv.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+			t.Fatalf("server certificate not good for '%s': %v", host, err)
+		}
+	}
+	if _, err := srv.Verify(x509.VerifyOptions{DNSName: "elsewhere.example.org", Roots: roots}); err == nil {
+		t.Fatal("server certificate good for a host it should not be")
+	}
+
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:        pkix.Name{CommonName: "ci-bot"},
+		EmailAddresses: []string{"ci@example.org"},
+	}, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cli, _, err := ca.SignClient(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der}), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cli.Subject.CommonName != "ci-bot" || len(cli.EmailAddresses) != 1 {
+		t.Fatalf("client certificate lost its names: %v %v", cli.Subject, cli.EmailAddresses)
+	}
+	opts := x509.VerifyOptions{Roots: roots, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}}
+	if _, err := cli.Verify(opts); err != nil {
+		t.Fatalf("client certificate does not verify: %v", err)
+	}
+}
@@ -11,7 +11,18 @@ It is generated from these files:
 It has these top-level messages:
 
 	BigFileChunk
+	FileAttr
+	GetRequest
+	Limits
 	BigFileAck
+	FileHeader
+	SessionMsg
+	DeleteRequest
+	DeleteReply
+	SSHKey
+	SSHKeyQuery
+	SSHKeyList
+	ChunkAck
 */
 package protobuf
 
@@ -37,6 +48,35 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
+type AckStatus int32
+
+const (
+	// ACK: the chunk, and all before it, verified.
+	AckStatus_ACK AckStatus = 0
+	// NAK: the chunk failed its own checksum or
+	// size check and should be sent again, along
+	// with every chunk after it.
+	AckStatus_NAK AckStatus = 1
+	// FATAL: the transfer cannot continue.
+	AckStatus_FATAL AckStatus = 2
+)
+
+var AckStatus_name = map[int32]string{
+	0: "ACK",
+	1: "NAK",
+	2: "FATAL",
+}
+var AckStatus_value = map[string]int32{
+	"ACK":   0,
+	"NAK":   1,
+	"FATAL": 2,
+}
+
+func (x AckStatus) String() string {
+	return proto.EnumName(AckStatus_name, int32(x))
+}
+func (AckStatus) EnumDescriptor() ([]byte, []int) { return fileDescriptorSbf, []int{0} }
+
 type BigFileChunk struct {
 	// Filepath is just an arbitrary
 	// name for this file.
@@ -55,13 +95,13 @@ type BigFileChunk struct {
 	// Chunks of the file, up to
 	// and including this one.
 	Blake2BCumulative []byte `protobuf:"bytes,5,opt,name=Blake2BCumulative,proto3" json:"Blake2BCumulative,omitempty"`
-	// How big can Data be? I
-	// recommend no more than 1MB.
-	// I suggest 1MB chunking to be
-	// on the safe side. Above
-	// 2MB, I observe that gRPC
-	// starts to return EOF instead
-	// of conveying the messages.
+	// How big can Data be? No more
+	// than the message size limit
+	// agreed upon by Negotiate(),
+	// less some room for the other
+	// fields. The client sizes its
+	// chunks adaptively within
+	// that limit.
 	//
 	// Fields Data and Blake2B are
 	// for just a single chunk.
@@ -77,6 +117,37 @@ type BigFileChunk struct {
 	IsLastChunk bool `protobuf:"varint,8,opt,name=IsLastChunk,proto3" json:"IsLastChunk,omitempty"`
 	// IsBcastSetRequest? (else by default it is a BcastGetReply)
 	IsBcastSet bool `protobuf:"varint,9,opt,name=IsBcastSet,proto3" json:"IsBcastSet,omitempty"`
+	// Attr, when set on the first chunk,
+	// is the metadata of the whole file,
+	// to be applied when it is committed.
+	Attr *FileAttr `protobuf:"bytes,11,opt,name=Attr" json:"Attr,omitempty"`
+	// HoleSize, when positive, makes this
+	// chunk a hole: that many zero bytes of
+	// the file that are not sent. Data is
+	// then empty. Blake2B still covers only
+	// Data, but Blake2BCumulative covers the
+	// zeros, so that the whole-file checksum
+	// is over the logical contents.
+	HoleSize int64 `protobuf:"varint,12,opt,name=HoleSize,proto3" json:"HoleSize,omitempty"`
+	// Signature, when set on the last chunk,
+	// is the sender's signed manifest of the
+	// whole file: its path, size, whole-file
+	// Blake2B and when it was signed. GetFile
+	// returns the one a file was stored with.
+	Signature []byte `protobuf:"bytes,13,opt,name=Signature,proto3" json:"Signature,omitempty"`
+	// Resume, set on the first message of a
+	// TransferFile call, and alone, asks to
+	// carry on with the upload of Filepath
+	// that the last call, in the same
+	// transfer, was cut short in. The server
+	// acks the last chunk it kept, and the
+	// bytes verified up to there; or chunk
+	// -1 and 0 bytes if it kept nothing.
+	Resume bool `protobuf:"varint,14,opt,name=Resume,proto3" json:"Resume,omitempty"`
+	// Metadata, on the first chunk GetFile
+	// sends, is the free-form metadata the
+	// file was stored with, if any.
+	Metadata map[string]string `protobuf:"bytes,15,rep,name=Metadata" json:"Metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
 }
 
 func (m *BigFileChunk) Reset()                    { *m = BigFileChunk{} }
@@ -154,6 +225,201 @@ func (m *BigFileChunk) GetIsBcastSet() bool {
 	return false
 }
 
+func (m *BigFileChunk) GetAttr() *FileAttr {
+	if m != nil {
+		return m.Attr
+	}
+	return nil
+}
+
+func (m *BigFileChunk) GetHoleSize() int64 {
+	if m != nil {
+		return m.HoleSize
+	}
+	return 0
+}
+
+func (m *BigFileChunk) GetSignature() []byte {
+	if m != nil {
+		return m.Signature
+	}
+	return nil
+}
+
+func (m *BigFileChunk) GetResume() bool {
+	if m != nil {
+		return m.Resume
+	}
+	return false
+}
+
+func (m *BigFileChunk) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+// FileAttr is the file metadata that we
+// preserve across a transfer. Which parts
+// the receiver actually applies is up to
+// its policy.
+type FileAttr struct {
+	// Mode holds the POSIX permission bits,
+	// plus setuid, setgid and sticky.
+	Mode uint32 `protobuf:"varint,1,opt,name=Mode,proto3" json:"Mode,omitempty"`
+	// Owner, both numerically and by name;
+	// the receiver prefers the names.
+	Uid   uint32 `protobuf:"varint,2,opt,name=Uid,proto3" json:"Uid,omitempty"`
+	Gid   uint32 `protobuf:"varint,3,opt,name=Gid,proto3" json:"Gid,omitempty"`
+	User  string `protobuf:"bytes,4,opt,name=User,proto3" json:"User,omitempty"`
+	Group string `protobuf:"bytes,5,opt,name=Group,proto3" json:"Group,omitempty"`
+	// Nanoseconds since the Unix epoch.
+	Mtime int64 `protobuf:"fixed64,6,opt,name=Mtime,proto3" json:"Mtime,omitempty"`
+	Atime int64 `protobuf:"fixed64,7,opt,name=Atime,proto3" json:"Atime,omitempty"`
+	// Extended attributes, by name. POSIX
+	// ACLs travel here too, as the
+	// system.posix_acl_access and
+	// system.posix_acl_default attributes.
+	Xattrs map[string][]byte `protobuf:"bytes,8,rep,name=Xattrs" json:"Xattrs,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *FileAttr) Reset()                    { *m = FileAttr{} }
+func (m *FileAttr) String() string            { return proto.CompactTextString(m) }
+func (*FileAttr) ProtoMessage()               {}
+func (*FileAttr) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{1} }
+
+func (m *FileAttr) GetMode() uint32 {
+	if m != nil {
+		return m.Mode
+	}
+	return 0
+}
+
+func (m *FileAttr) GetUid() uint32 {
+	if m != nil {
+		return m.Uid
+	}
+	return 0
+}
+
+func (m *FileAttr) GetGid() uint32 {
+	if m != nil {
+		return m.Gid
+	}
+	return 0
+}
+
+func (m *FileAttr) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
+}
+
+func (m *FileAttr) GetGroup() string {
+	if m != nil {
+		return m.Group
+	}
+	return ""
+}
+
+func (m *FileAttr) GetMtime() int64 {
+	if m != nil {
+		return m.Mtime
+	}
+	return 0
+}
+
+func (m *FileAttr) GetAtime() int64 {
+	if m != nil {
+		return m.Atime
+	}
+	return 0
+}
+
+func (m *FileAttr) GetXattrs() map[string][]byte {
+	if m != nil {
+		return m.Xattrs
+	}
+	return nil
+}
+
+// GetRequest asks for a file to be
+// streamed back by GetFile.
+type GetRequest struct {
+	Filepath string `protobuf:"bytes,1,opt,name=Filepath,proto3" json:"Filepath,omitempty"`
+	// MaxChunkSize is the largest chunk
+	// the client can take, per Negotiate.
+	MaxChunkSize int64 `protobuf:"varint,2,opt,name=MaxChunkSize,proto3" json:"MaxChunkSize,omitempty"`
+	// Offset, to resume a GetFile that was
+	// cut short, is how many bytes of the
+	// file the client already has, and
+	// NextChunk the number of the chunk
+	// that starts there.
+	Offset    int64 `protobuf:"varint,3,opt,name=Offset,proto3" json:"Offset,omitempty"`
+	NextChunk int64 `protobuf:"varint,4,opt,name=NextChunk,proto3" json:"NextChunk,omitempty"`
+}
+
+func (m *GetRequest) Reset()                    { *m = GetRequest{} }
+func (m *GetRequest) String() string            { return proto.CompactTextString(m) }
+func (*GetRequest) ProtoMessage()               {}
+func (*GetRequest) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{2} }
+
+func (m *GetRequest) GetFilepath() string {
+	if m != nil {
+		return m.Filepath
+	}
+	return ""
+}
+
+func (m *GetRequest) GetMaxChunkSize() int64 {
+	if m != nil {
+		return m.MaxChunkSize
+	}
+	return 0
+}
+
+func (m *GetRequest) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *GetRequest) GetNextChunk() int64 {
+	if m != nil {
+		return m.NextChunk
+	}
+	return 0
+}
+
+// Limits describes the gRPC message
+// size limits of one end of a connection.
+type Limits struct {
+	MaxRecvMsgSize int64 `protobuf:"varint,1,opt,name=MaxRecvMsgSize,proto3" json:"MaxRecvMsgSize,omitempty"`
+	MaxSendMsgSize int64 `protobuf:"varint,2,opt,name=MaxSendMsgSize,proto3" json:"MaxSendMsgSize,omitempty"`
+}
+
+func (m *Limits) Reset()                    { *m = Limits{} }
+func (m *Limits) String() string            { return proto.CompactTextString(m) }
+func (*Limits) ProtoMessage()               {}
+func (*Limits) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{3} }
+
+func (m *Limits) GetMaxRecvMsgSize() int64 {
+	if m != nil {
+		return m.MaxRecvMsgSize
+	}
+	return 0
+}
+
+func (m *Limits) GetMaxSendMsgSize() int64 {
+	if m != nil {
+		return m.MaxSendMsgSize
+	}
+	return 0
+}
+
 type BigFileAck struct {
 	Filepath         string `protobuf:"bytes,1,opt,name=Filepath,proto3" json:"Filepath,omitempty"`
 	SizeInBytes      int64  `protobuf:"varint,2,opt,name=SizeInBytes,proto3" json:"SizeInBytes,omitempty"`
@@ -165,7 +431,7 @@ type BigFileAck struct {
 func (m *BigFileAck) Reset()                    { *m = BigFileAck{} }
 func (m *BigFileAck) String() string            { return proto.CompactTextString(m) }
 func (*BigFileAck) ProtoMessage()               {}
-func (*BigFileAck) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{1} }
+func (*BigFileAck) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{4} }
 
 func (m *BigFileAck) GetFilepath() string {
 	if m != nil {
@@ -202,352 +468,3498 @@ func (m *BigFileAck) GetErr() string {
 	return ""
 }
 
-func init() {
-	proto.RegisterType((*BigFileChunk)(nil), "streambigfile.BigFileChunk")
-	proto.RegisterType((*BigFileAck)(nil), "streambigfile.BigFileAck")
+// FileHeader starts each file
+// sent during a Session.
+type FileHeader struct {
+	Filepath string `protobuf:"bytes,1,opt,name=Filepath,proto3" json:"Filepath,omitempty"`
+	// SizeInBytes of the whole file;
+	// the chunks must add up to it.
+	SizeInBytes int64 `protobuf:"varint,2,opt,name=SizeInBytes,proto3" json:"SizeInBytes,omitempty"`
+	// Metadata is free-form, and
+	// travels with the file.
+	Metadata map[string]string `protobuf:"bytes,3,rep,name=Metadata" json:"Metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Attr     *FileAttr         `protobuf:"bytes,4,opt,name=Attr" json:"Attr,omitempty"`
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+func (m *FileHeader) Reset()                    { *m = FileHeader{} }
+func (m *FileHeader) String() string            { return proto.CompactTextString(m) }
+func (*FileHeader) ProtoMessage()               {}
+func (*FileHeader) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{5} }
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *FileHeader) GetFilepath() string {
+	if m != nil {
+		return m.Filepath
+	}
+	return ""
+}
 
-// Client API for Peer service
+func (m *FileHeader) GetSizeInBytes() int64 {
+	if m != nil {
+		return m.SizeInBytes
+	}
+	return 0
+}
 
-type PeerClient interface {
-	// client always sends a big file to the server.
-	SendFile(ctx context.Context, opts ...grpc.CallOption) (Peer_SendFileClient, error)
+func (m *FileHeader) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
 }
 
-type peerClient struct {
-	cc *grpc.ClientConn
+func (m *FileHeader) GetAttr() *FileAttr {
+	if m != nil {
+		return m.Attr
+	}
+	return nil
 }
 
-func NewPeerClient(cc *grpc.ClientConn) PeerClient {
-	return &peerClient{cc}
+// SessionMsg is either a FileHeader, which
+// starts a new file, or the next chunk of
+// the current file.
+type SessionMsg struct {
+	Header *FileHeader   `protobuf:"bytes,1,opt,name=Header" json:"Header,omitempty"`
+	Chunk  *BigFileChunk `protobuf:"bytes,2,opt,name=Chunk" json:"Chunk,omitempty"`
 }
 
-func (c *peerClient) SendFile(ctx context.Context, opts ...grpc.CallOption) (Peer_SendFileClient, error) {
-	stream, err := grpc.NewClientStream(ctx, &_Peer_serviceDesc.Streams[0], c.cc, "/streambigfile.Peer/SendFile", opts...)
-	if err != nil {
-		return nil, err
+func (m *SessionMsg) Reset()                    { *m = SessionMsg{} }
+func (m *SessionMsg) String() string            { return proto.CompactTextString(m) }
+func (*SessionMsg) ProtoMessage()               {}
+func (*SessionMsg) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{6} }
+
+func (m *SessionMsg) GetHeader() *FileHeader {
+	if m != nil {
+		return m.Header
 	}
-	x := &peerSendFileClient{stream}
-	return x, nil
+	return nil
 }
 
-type Peer_SendFileClient interface {
-	Send(*BigFileChunk) error
-	CloseAndRecv() (*BigFileAck, error)
-	grpc.ClientStream
+func (m *SessionMsg) GetChunk() *BigFileChunk {
+	if m != nil {
+		return m.Chunk
+	}
+	return nil
 }
 
-type peerSendFileClient struct {
-	grpc.ClientStream
+type DeleteRequest struct {
+	Filepath string `protobuf:"bytes,1,opt,name=Filepath,proto3" json:"Filepath,omitempty"`
 }
 
-func (x *peerSendFileClient) Send(m *BigFileChunk) error {
-	return x.ClientStream.SendMsg(m)
-}
+func (m *DeleteRequest) Reset()                    { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string            { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()               {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{7} }
 
-func (x *peerSendFileClient) CloseAndRecv() (*BigFileAck, error) {
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
-	}
-	m := new(BigFileAck)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *DeleteRequest) GetFilepath() string {
+	if m != nil {
+		return m.Filepath
 	}
-	return m, nil
+	return ""
 }
 
-// Server API for Peer service
+type DeleteReply struct {
+	Filepath string `protobuf:"bytes,1,opt,name=Filepath,proto3" json:"Filepath,omitempty"`
+}
 
-type PeerServer interface {
-	// client always sends a big file to the server.
-	SendFile(Peer_SendFileServer) error
+func (m *DeleteReply) Reset()                    { *m = DeleteReply{} }
+func (m *DeleteReply) String() string            { return proto.CompactTextString(m) }
+func (*DeleteReply) ProtoMessage()               {}
+func (*DeleteReply) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{8} }
+
+func (m *DeleteReply) GetFilepath() string {
+	if m != nil {
+		return m.Filepath
+	}
+	return ""
 }
 
-func RegisterPeerServer(s *grpc.Server, srv PeerServer) {
-	s.RegisterService(&_Peer_serviceDesc, srv)
+// SSHKey is a public key that User may log in over SSH with.
+type SSHKey struct {
+	User string `protobuf:"bytes,1,opt,name=User,proto3" json:"User,omitempty"`
+	// AuthorizedKey is the key as one line of authorized_keys.
+	AuthorizedKey string `protobuf:"bytes,2,opt,name=AuthorizedKey,proto3" json:"AuthorizedKey,omitempty"`
+	Fingerprint   string `protobuf:"bytes,3,opt,name=Fingerprint,proto3" json:"Fingerprint,omitempty"`
+	Type          string `protobuf:"bytes,4,opt,name=Type,proto3" json:"Type,omitempty"`
+	Comment       string `protobuf:"bytes,5,opt,name=Comment,proto3" json:"Comment,omitempty"`
+	// Added is when the key was registered, in Unix nanoseconds.
+	Added int64 `protobuf:"fixed64,6,opt,name=Added,proto3" json:"Added,omitempty"`
 }
 
-func _Peer_SendFile_Handler(srv interface{}, stream grpc.ServerStream) error {
-	return srv.(PeerServer).SendFile(&peerSendFileServer{stream})
+func (m *SSHKey) Reset()                    { *m = SSHKey{} }
+func (m *SSHKey) String() string            { return proto.CompactTextString(m) }
+func (*SSHKey) ProtoMessage()               {}
+func (*SSHKey) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{9} }
+
+func (m *SSHKey) GetUser() string {
+	if m != nil {
+		return m.User
+	}
+	return ""
 }
 
-type Peer_SendFileServer interface {
-	SendAndClose(*BigFileAck) error
-	Recv() (*BigFileChunk, error)
-	grpc.ServerStream
+func (m *SSHKey) GetAuthorizedKey() string {
+	if m != nil {
+		return m.AuthorizedKey
+	}
+	return ""
 }
 
-type peerSendFileServer struct {
-	grpc.ServerStream
+func (m *SSHKey) GetFingerprint() string {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return ""
 }
 
-func (x *peerSendFileServer) SendAndClose(m *BigFileAck) error {
-	return x.ServerStream.SendMsg(m)
+func (m *SSHKey) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
 }
 
-func (x *peerSendFileServer) Recv() (*BigFileChunk, error) {
-	m := new(BigFileChunk)
-	if err := x.ServerStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *SSHKey) GetComment() string {
+	if m != nil {
+		return m.Comment
 	}
-	return m, nil
+	return ""
 }
 
-var _Peer_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "streambigfile.Peer",
-	HandlerType: (*PeerServer)(nil),
-	Methods:     []grpc.MethodDesc{},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "SendFile",
-			Handler:       _Peer_SendFile_Handler,
-			ClientStreams: true,
-		},
-	},
-	Metadata: "sbf.proto",
+func (m *SSHKey) GetAdded() int64 {
+	if m != nil {
+		return m.Added
+	}
+	return 0
 }
 
-func (m *BigFileChunk) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+type SSHKeyQuery struct {
+	// User, when set, limits the list to that user's keys.
+	User string `protobuf:"bytes,1,opt,name=User,proto3" json:"User,omitempty"`
+}
+
+func (m *SSHKeyQuery) Reset()                    { *m = SSHKeyQuery{} }
+func (m *SSHKeyQuery) String() string            { return proto.CompactTextString(m) }
+func (*SSHKeyQuery) ProtoMessage()               {}
+func (*SSHKeyQuery) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{10} }
+
+func (m *SSHKeyQuery) GetUser() string {
+	if m != nil {
+		return m.User
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *BigFileChunk) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if len(m.Filepath) > 0 {
-		dAtA[i] = 0xa
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
-		i += copy(dAtA[i:], m.Filepath)
-	}
-	if m.SizeInBytes != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(m.SizeInBytes))
+type SSHKeyList struct {
+	Keys []*SSHKey `protobuf:"bytes,1,rep,name=Keys" json:"Keys,omitempty"`
+}
+
+func (m *SSHKeyList) Reset()                    { *m = SSHKeyList{} }
+func (m *SSHKeyList) String() string            { return proto.CompactTextString(m) }
+func (*SSHKeyList) ProtoMessage()               {}
+func (*SSHKeyList) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{11} }
+
+func (m *SSHKeyList) GetKeys() []*SSHKey {
+	if m != nil {
+		return m.Keys
 	}
-	if m.SendTime != 0 {
-		dAtA[i] = 0x19
-		i++
-		i = encodeFixed64Sbf(dAtA, i, uint64(m.SendTime))
+	return nil
+}
+
+// ChunkAck is streamed back by TransferFile
+// as chunks are verified.
+type ChunkAck struct {
+	Filepath    string    `protobuf:"bytes,1,opt,name=Filepath,proto3" json:"Filepath,omitempty"`
+	ChunkNumber int64     `protobuf:"varint,2,opt,name=ChunkNumber,proto3" json:"ChunkNumber,omitempty"`
+	Status      AckStatus `protobuf:"varint,3,opt,name=Status,proto3,enum=streambigfile.AckStatus" json:"Status,omitempty"`
+	// BytesVerified counts the bytes that
+	// have passed verification so far.
+	BytesVerified int64  `protobuf:"varint,4,opt,name=BytesVerified,proto3" json:"BytesVerified,omitempty"`
+	Err           string `protobuf:"bytes,5,opt,name=Err,proto3" json:"Err,omitempty"`
+	// IsFinal is set on the ack of the last
+	// chunk, which also carries the whole
+	// file checksum.
+	IsFinal          bool   `protobuf:"varint,6,opt,name=IsFinal,proto3" json:"IsFinal,omitempty"`
+	WholeFileBlake2B []byte `protobuf:"bytes,7,opt,name=WholeFileBlake2B,proto3" json:"WholeFileBlake2B,omitempty"`
+	RecvTime         uint64 `protobuf:"fixed64,8,opt,name=RecvTime,proto3" json:"RecvTime,omitempty"`
+}
+
+func (m *ChunkAck) Reset()                    { *m = ChunkAck{} }
+func (m *ChunkAck) String() string            { return proto.CompactTextString(m) }
+func (*ChunkAck) ProtoMessage()               {}
+func (*ChunkAck) Descriptor() ([]byte, []int) { return fileDescriptorSbf, []int{12} }
+
+func (m *ChunkAck) GetFilepath() string {
+	if m != nil {
+		return m.Filepath
 	}
-	if len(m.Blake2B) > 0 {
-		dAtA[i] = 0x22
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(len(m.Blake2B)))
-		i += copy(dAtA[i:], m.Blake2B)
+	return ""
+}
+
+func (m *ChunkAck) GetChunkNumber() int64 {
+	if m != nil {
+		return m.ChunkNumber
 	}
-	if len(m.Blake2BCumulative) > 0 {
-		dAtA[i] = 0x2a
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(len(m.Blake2BCumulative)))
-		i += copy(dAtA[i:], m.Blake2BCumulative)
+	return 0
+}
+
+func (m *ChunkAck) GetStatus() AckStatus {
+	if m != nil {
+		return m.Status
 	}
-	if len(m.Data) > 0 {
-		dAtA[i] = 0x32
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(len(m.Data)))
-		i += copy(dAtA[i:], m.Data)
+	return AckStatus_ACK
+}
+
+func (m *ChunkAck) GetBytesVerified() int64 {
+	if m != nil {
+		return m.BytesVerified
 	}
-	if m.ChunkNumber != 0 {
-		dAtA[i] = 0x38
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(m.ChunkNumber))
+	return 0
+}
+
+func (m *ChunkAck) GetErr() string {
+	if m != nil {
+		return m.Err
 	}
-	if m.IsLastChunk {
-		dAtA[i] = 0x40
-		i++
-		if m.IsLastChunk {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return ""
+}
+
+func (m *ChunkAck) GetIsFinal() bool {
+	if m != nil {
+		return m.IsFinal
 	}
-	if m.IsBcastSet {
-		dAtA[i] = 0x48
-		i++
-		if m.IsBcastSet {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return false
+}
+
+func (m *ChunkAck) GetWholeFileBlake2B() []byte {
+	if m != nil {
+		return m.WholeFileBlake2B
 	}
-	if m.OriginalStartSendTime != 0 {
-		dAtA[i] = 0x51
-		i++
-		i = encodeFixed64Sbf(dAtA, i, uint64(m.OriginalStartSendTime))
+	return nil
+}
+
+func (m *ChunkAck) GetRecvTime() uint64 {
+	if m != nil {
+		return m.RecvTime
 	}
-	return i, nil
+	return 0
 }
 
-func (m *BigFileAck) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
+func init() {
+	proto.RegisterType((*BigFileChunk)(nil), "streambigfile.BigFileChunk")
+	proto.RegisterType((*FileAttr)(nil), "streambigfile.FileAttr")
+	proto.RegisterType((*GetRequest)(nil), "streambigfile.GetRequest")
+	proto.RegisterType((*Limits)(nil), "streambigfile.Limits")
+	proto.RegisterType((*BigFileAck)(nil), "streambigfile.BigFileAck")
+	proto.RegisterType((*FileHeader)(nil), "streambigfile.FileHeader")
+	proto.RegisterType((*SessionMsg)(nil), "streambigfile.SessionMsg")
+	proto.RegisterType((*DeleteRequest)(nil), "streambigfile.DeleteRequest")
+	proto.RegisterType((*DeleteReply)(nil), "streambigfile.DeleteReply")
+	proto.RegisterType((*SSHKey)(nil), "streambigfile.SSHKey")
+	proto.RegisterType((*SSHKeyQuery)(nil), "streambigfile.SSHKeyQuery")
+	proto.RegisterType((*SSHKeyList)(nil), "streambigfile.SSHKeyList")
+	proto.RegisterType((*ChunkAck)(nil), "streambigfile.ChunkAck")
+	proto.RegisterEnum("streambigfile.AckStatus", AckStatus_name, AckStatus_value)
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// Client API for Peer service
+
+type PeerClient interface {
+	// client always sends a big file to the server.
+	SendFile(ctx context.Context, opts ...grpc.CallOption) (Peer_SendFileClient, error)
+	// client sends its own limits, and the
+	// server replies with its limits.
+	Negotiate(ctx context.Context, in *Limits, opts ...grpc.CallOption) (*Limits, error)
+	// like SendFile, but each chunk is acked or
+	// naked as soon as the server has verified it.
+	TransferFile(ctx context.Context, opts ...grpc.CallOption) (Peer_TransferFileClient, error)
+	// many files over one stream; each file starts
+	// with a header, and gets its own ack back.
+	Session(ctx context.Context, opts ...grpc.CallOption) (Peer_SessionClient, error)
+	// server sends a stored file back to the client;
+	// the first chunk carries the file's Attr.
+	GetFile(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (Peer_GetFileClient, error)
+	// removes a stored file.
+	DeleteFile(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteReply, error)
+	AddSSHKey(ctx context.Context, in *SSHKey, opts ...grpc.CallOption) (*SSHKey, error)
+	ListSSHKeys(ctx context.Context, in *SSHKeyQuery, opts ...grpc.CallOption) (*SSHKeyList, error)
+	RevokeSSHKey(ctx context.Context, in *SSHKey, opts ...grpc.CallOption) (*SSHKey, error)
+}
+
+type peerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewPeerClient(cc *grpc.ClientConn) PeerClient {
+	return &peerClient{cc}
+}
+
+func (c *peerClient) SendFile(ctx context.Context, opts ...grpc.CallOption) (Peer_SendFileClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Peer_serviceDesc.Streams[0], c.cc, "/streambigfile.Peer/SendFile", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return dAtA[:n], nil
+	x := &peerSendFileClient{stream}
+	return x, nil
 }
 
-func (m *BigFileAck) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if len(m.Filepath) > 0 {
-		dAtA[i] = 0xa
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
-		i += copy(dAtA[i:], m.Filepath)
-	}
-	if m.SizeInBytes != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(m.SizeInBytes))
+type Peer_SendFileClient interface {
+	Send(*BigFileChunk) error
+	CloseAndRecv() (*BigFileAck, error)
+	grpc.ClientStream
+}
+
+type peerSendFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerSendFileClient) Send(m *BigFileChunk) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *peerSendFileClient) CloseAndRecv() (*BigFileAck, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
 	}
-	if m.RecvTime != 0 {
-		dAtA[i] = 0x19
-		i++
-		i = encodeFixed64Sbf(dAtA, i, uint64(m.RecvTime))
+	m := new(BigFileAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	if len(m.WholeFileBlake2B) > 0 {
-		dAtA[i] = 0x22
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(len(m.WholeFileBlake2B)))
-		i += copy(dAtA[i:], m.WholeFileBlake2B)
+	return m, nil
+}
+
+func (c *peerClient) Negotiate(ctx context.Context, in *Limits, opts ...grpc.CallOption) (*Limits, error) {
+	out := new(Limits)
+	err := grpc.Invoke(ctx, "/streambigfile.Peer/Negotiate", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
 	}
-	if len(m.Err) > 0 {
-		dAtA[i] = 0x2a
-		i++
-		i = encodeVarintSbf(dAtA, i, uint64(len(m.Err)))
-		i += copy(dAtA[i:], m.Err)
+	return out, nil
+}
+
+func (c *peerClient) TransferFile(ctx context.Context, opts ...grpc.CallOption) (Peer_TransferFileClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Peer_serviceDesc.Streams[1], c.cc, "/streambigfile.Peer/TransferFile", opts...)
+	if err != nil {
+		return nil, err
 	}
-	return i, nil
+	x := &peerTransferFileClient{stream}
+	return x, nil
 }
 
-func encodeFixed64Sbf(dAtA []byte, offset int, v uint64) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	dAtA[offset+4] = uint8(v >> 32)
-	dAtA[offset+5] = uint8(v >> 40)
-	dAtA[offset+6] = uint8(v >> 48)
-	dAtA[offset+7] = uint8(v >> 56)
-	return offset + 8
+type Peer_TransferFileClient interface {
+	Send(*BigFileChunk) error
+	Recv() (*ChunkAck, error)
+	grpc.ClientStream
 }
-func encodeFixed32Sbf(dAtA []byte, offset int, v uint32) int {
-	dAtA[offset] = uint8(v)
-	dAtA[offset+1] = uint8(v >> 8)
-	dAtA[offset+2] = uint8(v >> 16)
-	dAtA[offset+3] = uint8(v >> 24)
-	return offset + 4
+
+type peerTransferFileClient struct {
+	grpc.ClientStream
 }
-func encodeVarintSbf(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
-	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
+
+func (x *peerTransferFileClient) Send(m *BigFileChunk) error {
+	return x.ClientStream.SendMsg(m)
 }
-func (m *BigFileChunk) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Filepath)
-	if l > 0 {
-		n += 1 + l + sovSbf(uint64(l))
-	}
-	if m.SizeInBytes != 0 {
-		n += 1 + sovSbf(uint64(m.SizeInBytes))
-	}
-	if m.SendTime != 0 {
-		n += 9
-	}
-	l = len(m.Blake2B)
-	if l > 0 {
-		n += 1 + l + sovSbf(uint64(l))
+
+func (x *peerTransferFileClient) Recv() (*ChunkAck, error) {
+	m := new(ChunkAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
 	}
-	l = len(m.Blake2BCumulative)
-	if l > 0 {
-		n += 1 + l + sovSbf(uint64(l))
+	return m, nil
+}
+
+func (c *peerClient) Session(ctx context.Context, opts ...grpc.CallOption) (Peer_SessionClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Peer_serviceDesc.Streams[2], c.cc, "/streambigfile.Peer/Session", opts...)
+	if err != nil {
+		return nil, err
 	}
-	l = len(m.Data)
-	if l > 0 {
+	x := &peerSessionClient{stream}
+	return x, nil
+}
+
+type Peer_SessionClient interface {
+	Send(*SessionMsg) error
+	Recv() (*BigFileAck, error)
+	grpc.ClientStream
+}
+
+type peerSessionClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerSessionClient) Send(m *SessionMsg) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *peerSessionClient) Recv() (*BigFileAck, error) {
+	m := new(BigFileAck)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *peerClient) GetFile(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (Peer_GetFileClient, error) {
+	stream, err := grpc.NewClientStream(ctx, &_Peer_serviceDesc.Streams[3], c.cc, "/streambigfile.Peer/GetFile", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &peerGetFileClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Peer_GetFileClient interface {
+	Recv() (*BigFileChunk, error)
+	grpc.ClientStream
+}
+
+type peerGetFileClient struct {
+	grpc.ClientStream
+}
+
+func (x *peerGetFileClient) Recv() (*BigFileChunk, error) {
+	m := new(BigFileChunk)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *peerClient) DeleteFile(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteReply, error) {
+	out := new(DeleteReply)
+	err := grpc.Invoke(ctx, "/streambigfile.Peer/DeleteFile", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) AddSSHKey(ctx context.Context, in *SSHKey, opts ...grpc.CallOption) (*SSHKey, error) {
+	out := new(SSHKey)
+	err := grpc.Invoke(ctx, "/streambigfile.Peer/AddSSHKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) ListSSHKeys(ctx context.Context, in *SSHKeyQuery, opts ...grpc.CallOption) (*SSHKeyList, error) {
+	out := new(SSHKeyList)
+	err := grpc.Invoke(ctx, "/streambigfile.Peer/ListSSHKeys", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *peerClient) RevokeSSHKey(ctx context.Context, in *SSHKey, opts ...grpc.CallOption) (*SSHKey, error) {
+	out := new(SSHKey)
+	err := grpc.Invoke(ctx, "/streambigfile.Peer/RevokeSSHKey", in, out, c.cc, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Server API for Peer service
+
+type PeerServer interface {
+	// client always sends a big file to the server.
+	SendFile(Peer_SendFileServer) error
+	// client sends its own limits, and the
+	// server replies with its limits.
+	Negotiate(context.Context, *Limits) (*Limits, error)
+	// like SendFile, but each chunk is acked or
+	// naked as soon as the server has verified it.
+	TransferFile(Peer_TransferFileServer) error
+	// many files over one stream; each file starts
+	// with a header, and gets its own ack back.
+	Session(Peer_SessionServer) error
+	// server sends a stored file back to the client;
+	// the first chunk carries the file's Attr.
+	GetFile(*GetRequest, Peer_GetFileServer) error
+	// removes a stored file.
+	DeleteFile(context.Context, *DeleteRequest) (*DeleteReply, error)
+	AddSSHKey(context.Context, *SSHKey) (*SSHKey, error)
+	ListSSHKeys(context.Context, *SSHKeyQuery) (*SSHKeyList, error)
+	RevokeSSHKey(context.Context, *SSHKey) (*SSHKey, error)
+}
+
+func RegisterPeerServer(s *grpc.Server, srv PeerServer) {
+	s.RegisterService(&_Peer_serviceDesc, srv)
+}
+
+func _Peer_SendFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PeerServer).SendFile(&peerSendFileServer{stream})
+}
+
+type Peer_SendFileServer interface {
+	SendAndClose(*BigFileAck) error
+	Recv() (*BigFileChunk, error)
+	grpc.ServerStream
+}
+
+type peerSendFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerSendFileServer) SendAndClose(m *BigFileAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *peerSendFileServer) Recv() (*BigFileChunk, error) {
+	m := new(BigFileChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Peer_Negotiate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Limits)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).Negotiate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/streambigfile.Peer/Negotiate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).Negotiate(ctx, req.(*Limits))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_TransferFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PeerServer).TransferFile(&peerTransferFileServer{stream})
+}
+
+type Peer_TransferFileServer interface {
+	Send(*ChunkAck) error
+	Recv() (*BigFileChunk, error)
+	grpc.ServerStream
+}
+
+type peerTransferFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerTransferFileServer) Send(m *ChunkAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *peerTransferFileServer) Recv() (*BigFileChunk, error) {
+	m := new(BigFileChunk)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Peer_Session_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PeerServer).Session(&peerSessionServer{stream})
+}
+
+type Peer_SessionServer interface {
+	Send(*BigFileAck) error
+	Recv() (*SessionMsg, error)
+	grpc.ServerStream
+}
+
+type peerSessionServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerSessionServer) Send(m *BigFileAck) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *peerSessionServer) Recv() (*SessionMsg, error) {
+	m := new(SessionMsg)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Peer_GetFile_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GetRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PeerServer).GetFile(m, &peerGetFileServer{stream})
+}
+
+type Peer_GetFileServer interface {
+	Send(*BigFileChunk) error
+	grpc.ServerStream
+}
+
+type peerGetFileServer struct {
+	grpc.ServerStream
+}
+
+func (x *peerGetFileServer) Send(m *BigFileChunk) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Peer_DeleteFile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).DeleteFile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/streambigfile.Peer/DeleteFile",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).DeleteFile(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_AddSSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SSHKey)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).AddSSHKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/streambigfile.Peer/AddSSHKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).AddSSHKey(ctx, req.(*SSHKey))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_ListSSHKeys_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SSHKeyQuery)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).ListSSHKeys(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/streambigfile.Peer/ListSSHKeys",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).ListSSHKeys(ctx, req.(*SSHKeyQuery))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Peer_RevokeSSHKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SSHKey)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PeerServer).RevokeSSHKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/streambigfile.Peer/RevokeSSHKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PeerServer).RevokeSSHKey(ctx, req.(*SSHKey))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Peer_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "streambigfile.Peer",
+	HandlerType: (*PeerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Negotiate",
+			Handler:    _Peer_Negotiate_Handler,
+		},
+		{
+			MethodName: "DeleteFile",
+			Handler:    _Peer_DeleteFile_Handler,
+		},
+		{
+			MethodName: "AddSSHKey",
+			Handler:    _Peer_AddSSHKey_Handler,
+		},
+		{
+			MethodName: "ListSSHKeys",
+			Handler:    _Peer_ListSSHKeys_Handler,
+		},
+		{
+			MethodName: "RevokeSSHKey",
+			Handler:    _Peer_RevokeSSHKey_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SendFile",
+			Handler:       _Peer_SendFile_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "TransferFile",
+			Handler:       _Peer_TransferFile_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "Session",
+			Handler:       _Peer_Session_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "GetFile",
+			Handler:       _Peer_GetFile_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "sbf.proto",
+}
+
+func (m *BigFileChunk) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BigFileChunk) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Filepath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
+		i += copy(dAtA[i:], m.Filepath)
+	}
+	if m.SizeInBytes != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.SizeInBytes))
+	}
+	if m.SendTime != 0 {
+		dAtA[i] = 0x19
+		i++
+		i = encodeFixed64Sbf(dAtA, i, uint64(m.SendTime))
+	}
+	if len(m.Blake2B) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Blake2B)))
+		i += copy(dAtA[i:], m.Blake2B)
+	}
+	if len(m.Blake2BCumulative) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Blake2BCumulative)))
+		i += copy(dAtA[i:], m.Blake2BCumulative)
+	}
+	if len(m.Data) > 0 {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Data)))
+		i += copy(dAtA[i:], m.Data)
+	}
+	if m.ChunkNumber != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.ChunkNumber))
+	}
+	if m.IsLastChunk {
+		dAtA[i] = 0x40
+		i++
+		if m.IsLastChunk {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.IsBcastSet {
+		dAtA[i] = 0x48
+		i++
+		if m.IsBcastSet {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.OriginalStartSendTime != 0 {
+		dAtA[i] = 0x51
+		i++
+		i = encodeFixed64Sbf(dAtA, i, uint64(m.OriginalStartSendTime))
+	}
+	if m.Attr != nil {
+		dAtA[i] = 0x5a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Attr.Size()))
+		n1, err := m.Attr.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n1
+	}
+	if m.HoleSize != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.HoleSize))
+	}
+	if len(m.Signature) > 0 {
+		dAtA[i] = 0x6a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Signature)))
+		i += copy(dAtA[i:], m.Signature)
+	}
+	if m.Resume {
+		dAtA[i] = 0x70
+		i++
+		if m.Resume {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.Metadata) > 0 {
+		for k := range m.Metadata {
+			dAtA[i] = 0x7a
+			i++
+			v := m.Metadata[k]
+			mapSize := 1 + len(k) + sovSbf(uint64(len(k))) + 1 + len(v) + sovSbf(uint64(len(v)))
+			i = encodeVarintSbf(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintSbf(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintSbf(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
+		}
+	}
+	return i, nil
+}
+
+func (m *FileAttr) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FileAttr) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Mode != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Mode))
+	}
+	if m.Uid != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Uid))
+	}
+	if m.Gid != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Gid))
+	}
+	if len(m.User) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.User)))
+		i += copy(dAtA[i:], m.User)
+	}
+	if len(m.Group) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Group)))
+		i += copy(dAtA[i:], m.Group)
+	}
+	if m.Mtime != 0 {
+		dAtA[i] = 0x31
+		i++
+		i = encodeFixed64Sbf(dAtA, i, uint64(m.Mtime))
+	}
+	if m.Atime != 0 {
+		dAtA[i] = 0x39
+		i++
+		i = encodeFixed64Sbf(dAtA, i, uint64(m.Atime))
+	}
+	if len(m.Xattrs) > 0 {
+		for k := range m.Xattrs {
+			dAtA[i] = 0x42
+			i++
+			v := m.Xattrs[k]
+			byteSize := 0
+			if len(v) > 0 {
+				byteSize = 1 + len(v) + sovSbf(uint64(len(v)))
+			}
+			mapSize := 1 + len(k) + sovSbf(uint64(len(k))) + byteSize
+			i = encodeVarintSbf(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintSbf(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			if len(v) > 0 {
+				dAtA[i] = 0x12
+				i++
+				i = encodeVarintSbf(dAtA, i, uint64(len(v)))
+				i += copy(dAtA[i:], v)
+			}
+		}
+	}
+	return i, nil
+}
+
+func (m *GetRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Filepath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
+		i += copy(dAtA[i:], m.Filepath)
+	}
+	if m.MaxChunkSize != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.MaxChunkSize))
+	}
+	if m.Offset != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Offset))
+	}
+	if m.NextChunk != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.NextChunk))
+	}
+	return i, nil
+}
+
+func (m *Limits) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Limits) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.MaxRecvMsgSize != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.MaxRecvMsgSize))
+	}
+	if m.MaxSendMsgSize != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.MaxSendMsgSize))
+	}
+	return i, nil
+}
+
+func (m *BigFileAck) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BigFileAck) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Filepath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
+		i += copy(dAtA[i:], m.Filepath)
+	}
+	if m.SizeInBytes != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.SizeInBytes))
+	}
+	if m.RecvTime != 0 {
+		dAtA[i] = 0x19
+		i++
+		i = encodeFixed64Sbf(dAtA, i, uint64(m.RecvTime))
+	}
+	if len(m.WholeFileBlake2B) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.WholeFileBlake2B)))
+		i += copy(dAtA[i:], m.WholeFileBlake2B)
+	}
+	if len(m.Err) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Err)))
+		i += copy(dAtA[i:], m.Err)
+	}
+	return i, nil
+}
+
+func (m *FileHeader) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FileHeader) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Filepath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
+		i += copy(dAtA[i:], m.Filepath)
+	}
+	if m.SizeInBytes != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.SizeInBytes))
+	}
+	if len(m.Metadata) > 0 {
+		for k := range m.Metadata {
+			dAtA[i] = 0x1a
+			i++
+			v := m.Metadata[k]
+			mapSize := 1 + len(k) + sovSbf(uint64(len(k))) + 1 + len(v) + sovSbf(uint64(len(v)))
+			i = encodeVarintSbf(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintSbf(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			dAtA[i] = 0x12
+			i++
+			i = encodeVarintSbf(dAtA, i, uint64(len(v)))
+			i += copy(dAtA[i:], v)
+		}
+	}
+	if m.Attr != nil {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Attr.Size()))
+		n2, err := m.Attr.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n2
+	}
+	return i, nil
+}
+
+func (m *SessionMsg) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SessionMsg) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Header != nil {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Header.Size()))
+		n3, err := m.Header.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n3
+	}
+	if m.Chunk != nil {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Chunk.Size()))
+		n4, err := m.Chunk.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
+	}
+	return i, nil
+}
+
+func (m *DeleteRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DeleteRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Filepath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
+		i += copy(dAtA[i:], m.Filepath)
+	}
+	return i, nil
+}
+
+func (m *DeleteReply) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *DeleteReply) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Filepath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
+		i += copy(dAtA[i:], m.Filepath)
+	}
+	return i, nil
+}
+
+func (m *SSHKey) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SSHKey) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.User) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.User)))
+		i += copy(dAtA[i:], m.User)
+	}
+	if len(m.AuthorizedKey) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.AuthorizedKey)))
+		i += copy(dAtA[i:], m.AuthorizedKey)
+	}
+	if len(m.Fingerprint) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Fingerprint)))
+		i += copy(dAtA[i:], m.Fingerprint)
+	}
+	if len(m.Type) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Type)))
+		i += copy(dAtA[i:], m.Type)
+	}
+	if len(m.Comment) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Comment)))
+		i += copy(dAtA[i:], m.Comment)
+	}
+	if m.Added != 0 {
+		dAtA[i] = 0x31
+		i++
+		i = encodeFixed64Sbf(dAtA, i, uint64(m.Added))
+	}
+	return i, nil
+}
+
+func (m *SSHKeyQuery) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SSHKeyQuery) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.User) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.User)))
+		i += copy(dAtA[i:], m.User)
+	}
+	return i, nil
+}
+
+func (m *SSHKeyList) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SSHKeyList) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Keys) > 0 {
+		for _, msg := range m.Keys {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintSbf(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *ChunkAck) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ChunkAck) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Filepath) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Filepath)))
+		i += copy(dAtA[i:], m.Filepath)
+	}
+	if m.ChunkNumber != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.ChunkNumber))
+	}
+	if m.Status != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.Status))
+	}
+	if m.BytesVerified != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(m.BytesVerified))
+	}
+	if len(m.Err) > 0 {
+		dAtA[i] = 0x2a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.Err)))
+		i += copy(dAtA[i:], m.Err)
+	}
+	if m.IsFinal {
+		dAtA[i] = 0x30
+		i++
+		if m.IsFinal {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.WholeFileBlake2B) > 0 {
+		dAtA[i] = 0x3a
+		i++
+		i = encodeVarintSbf(dAtA, i, uint64(len(m.WholeFileBlake2B)))
+		i += copy(dAtA[i:], m.WholeFileBlake2B)
+	}
+	if m.RecvTime != 0 {
+		dAtA[i] = 0x41
+		i++
+		i = encodeFixed64Sbf(dAtA, i, uint64(m.RecvTime))
+	}
+	return i, nil
+}
+
+func encodeFixed64Sbf(dAtA []byte, offset int, v uint64) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	dAtA[offset+4] = uint8(v >> 32)
+	dAtA[offset+5] = uint8(v >> 40)
+	dAtA[offset+6] = uint8(v >> 48)
+	dAtA[offset+7] = uint8(v >> 56)
+	return offset + 8
+}
+func encodeFixed32Sbf(dAtA []byte, offset int, v uint32) int {
+	dAtA[offset] = uint8(v)
+	dAtA[offset+1] = uint8(v >> 8)
+	dAtA[offset+2] = uint8(v >> 16)
+	dAtA[offset+3] = uint8(v >> 24)
+	return offset + 4
+}
+func encodeVarintSbf(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *BigFileChunk) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Filepath)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.SizeInBytes != 0 {
+		n += 1 + sovSbf(uint64(m.SizeInBytes))
+	}
+	if m.SendTime != 0 {
+		n += 9
+	}
+	l = len(m.Blake2B)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.Blake2BCumulative)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.Data)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.ChunkNumber != 0 {
+		n += 1 + sovSbf(uint64(m.ChunkNumber))
+	}
+	if m.IsLastChunk {
+		n += 2
+	}
+	if m.IsBcastSet {
+		n += 2
+	}
+	if m.OriginalStartSendTime != 0 {
+		n += 9
+	}
+	if m.Attr != nil {
+		l = m.Attr.Size()
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.HoleSize != 0 {
+		n += 1 + sovSbf(uint64(m.HoleSize))
+	}
+	l = len(m.Signature)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.Resume {
+		n += 2
+	}
+	if len(m.Metadata) > 0 {
+		for k, v := range m.Metadata {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSbf(uint64(len(k))) + 1 + len(v) + sovSbf(uint64(len(v)))
+			n += mapEntrySize + 1 + sovSbf(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *FileAttr) Size() (n int) {
+	var l int
+	_ = l
+	if m.Mode != 0 {
+		n += 1 + sovSbf(uint64(m.Mode))
+	}
+	if m.Uid != 0 {
+		n += 1 + sovSbf(uint64(m.Uid))
+	}
+	if m.Gid != 0 {
+		n += 1 + sovSbf(uint64(m.Gid))
+	}
+	l = len(m.User)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.Group)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.Mtime != 0 {
+		n += 9
+	}
+	if m.Atime != 0 {
+		n += 9
+	}
+	if len(m.Xattrs) > 0 {
+		for k, v := range m.Xattrs {
+			_ = k
+			_ = v
+			l = 0
+			if len(v) > 0 {
+				l = 1 + len(v) + sovSbf(uint64(len(v)))
+			}
+			mapEntrySize := 1 + len(k) + sovSbf(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovSbf(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func (m *GetRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Filepath)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.MaxChunkSize != 0 {
+		n += 1 + sovSbf(uint64(m.MaxChunkSize))
+	}
+	if m.Offset != 0 {
+		n += 1 + sovSbf(uint64(m.Offset))
+	}
+	if m.NextChunk != 0 {
+		n += 1 + sovSbf(uint64(m.NextChunk))
+	}
+	return n
+}
+
+func (m *Limits) Size() (n int) {
+	var l int
+	_ = l
+	if m.MaxRecvMsgSize != 0 {
+		n += 1 + sovSbf(uint64(m.MaxRecvMsgSize))
+	}
+	if m.MaxSendMsgSize != 0 {
+		n += 1 + sovSbf(uint64(m.MaxSendMsgSize))
+	}
+	return n
+}
+
+func (m *BigFileAck) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Filepath)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.SizeInBytes != 0 {
+		n += 1 + sovSbf(uint64(m.SizeInBytes))
+	}
+	if m.RecvTime != 0 {
+		n += 9
+	}
+	l = len(m.WholeFileBlake2B)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	return n
+}
+
+func (m *FileHeader) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Filepath)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.SizeInBytes != 0 {
+		n += 1 + sovSbf(uint64(m.SizeInBytes))
+	}
+	if len(m.Metadata) > 0 {
+		for k, v := range m.Metadata {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSbf(uint64(len(k))) + 1 + len(v) + sovSbf(uint64(len(v)))
+			n += mapEntrySize + 1 + sovSbf(uint64(mapEntrySize))
+		}
+	}
+	if m.Attr != nil {
+		l = m.Attr.Size()
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	return n
+}
+
+func (m *SessionMsg) Size() (n int) {
+	var l int
+	_ = l
+	if m.Header != nil {
+		l = m.Header.Size()
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.Chunk != nil {
+		l = m.Chunk.Size()
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	return n
+}
+
+func (m *DeleteRequest) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Filepath)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	return n
+}
+
+func (m *DeleteReply) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Filepath)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	return n
+}
+
+func (m *SSHKey) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.User)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.AuthorizedKey)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.Fingerprint)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.Type)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	l = len(m.Comment)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.Added != 0 {
+		n += 9
+	}
+	return n
+}
+
+func (m *SSHKeyQuery) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.User)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	return n
+}
+
+func (m *SSHKeyList) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Keys) > 0 {
+		for _, e := range m.Keys {
+			l = e.Size()
+			n += 1 + l + sovSbf(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ChunkAck) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Filepath)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.ChunkNumber != 0 {
+		n += 1 + sovSbf(uint64(m.ChunkNumber))
+	}
+	if m.Status != 0 {
+		n += 1 + sovSbf(uint64(m.Status))
+	}
+	if m.BytesVerified != 0 {
+		n += 1 + sovSbf(uint64(m.BytesVerified))
+	}
+	l = len(m.Err)
+	if l > 0 {
+		n += 1 + l + sovSbf(uint64(l))
+	}
+	if m.IsFinal {
+		n += 2
+	}
+	l = len(m.WholeFileBlake2B)
+	if l > 0 {
 		n += 1 + l + sovSbf(uint64(l))
 	}
-	if m.ChunkNumber != 0 {
-		n += 1 + sovSbf(uint64(m.ChunkNumber))
+	if m.RecvTime != 0 {
+		n += 9
+	}
+	return n
+}
+
+func sovSbf(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozSbf(x uint64) (n int) {
+	return sovSbf(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BigFileChunk: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BigFileChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filepath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filepath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SizeInBytes", wireType)
+			}
+			m.SizeInBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SizeInBytes |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SendTime", wireType)
+			}
+			m.SendTime = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			m.SendTime = uint64(dAtA[iNdEx-8])
+			m.SendTime |= uint64(dAtA[iNdEx-7]) << 8
+			m.SendTime |= uint64(dAtA[iNdEx-6]) << 16
+			m.SendTime |= uint64(dAtA[iNdEx-5]) << 24
+			m.SendTime |= uint64(dAtA[iNdEx-4]) << 32
+			m.SendTime |= uint64(dAtA[iNdEx-3]) << 40
+			m.SendTime |= uint64(dAtA[iNdEx-2]) << 48
+			m.SendTime |= uint64(dAtA[iNdEx-1]) << 56
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Blake2B", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Blake2B = append(m.Blake2B[:0], dAtA[iNdEx:postIndex]...)
+			if m.Blake2B == nil {
+				m.Blake2B = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Blake2BCumulative", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Blake2BCumulative = append(m.Blake2BCumulative[:0], dAtA[iNdEx:postIndex]...)
+			if m.Blake2BCumulative == nil {
+				m.Blake2BCumulative = []byte{}
+			}
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
+			if m.Data == nil {
+				m.Data = []byte{}
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkNumber", wireType)
+			}
+			m.ChunkNumber = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ChunkNumber |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsLastChunk", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsLastChunk = bool(v != 0)
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsBcastSet", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsBcastSet = bool(v != 0)
+		case 10:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field OriginalStartSendTime", wireType)
+			}
+			m.OriginalStartSendTime = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			m.OriginalStartSendTime = uint64(dAtA[iNdEx-8])
+			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-7]) << 8
+			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-6]) << 16
+			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-5]) << 24
+			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-4]) << 32
+			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-3]) << 40
+			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-2]) << 48
+			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-1]) << 56
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Attr", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Attr == nil {
+				m.Attr = &FileAttr{}
+			}
+			if err := m.Attr.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HoleSize", wireType)
+			}
+			m.HoleSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HoleSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Signature", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Signature = append(m.Signature[:0], dAtA[iNdEx:postIndex]...)
+			if m.Signature == nil {
+				m.Signature = []byte{}
+			}
+			iNdEx = postIndex
+		case 14:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Resume", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Resume = bool(v != 0)
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var keykey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				keykey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			var stringLenmapkey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLenmapkey := int(stringLenmapkey)
+			if intStringLenmapkey < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postStringIndexmapkey := iNdEx + intStringLenmapkey
+			if postStringIndexmapkey > l {
+				return io.ErrUnexpectedEOF
+			}
+			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
+			iNdEx = postStringIndexmapkey
+			if m.Metadata == nil {
+				m.Metadata = make(map[string]string)
+			}
+			if iNdEx < postIndex {
+				var valuekey uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSbf
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					valuekey |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				var stringLenmapvalue uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSbf
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					stringLenmapvalue |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				intStringLenmapvalue := int(stringLenmapvalue)
+				if intStringLenmapvalue < 0 {
+					return ErrInvalidLengthSbf
+				}
+				postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+				if postStringIndexmapvalue > l {
+					return io.ErrUnexpectedEOF
+				}
+				mapvalue := string(dAtA[iNdEx:postStringIndexmapvalue])
+				iNdEx = postStringIndexmapvalue
+				m.Metadata[mapkey] = mapvalue
+			} else {
+				var mapvalue string
+				m.Metadata[mapkey] = mapvalue
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FileAttr) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FileAttr: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FileAttr: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mode", wireType)
+			}
+			m.Mode = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Mode |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Uid", wireType)
+			}
+			m.Uid = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Uid |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Gid", wireType)
+			}
+			m.Gid = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Gid |= (uint32(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field User", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.User = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Group", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Group = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Mtime", wireType)
+			}
+			m.Mtime = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			m.Mtime = int64(dAtA[iNdEx-8])
+			m.Mtime |= int64(dAtA[iNdEx-7]) << 8
+			m.Mtime |= int64(dAtA[iNdEx-6]) << 16
+			m.Mtime |= int64(dAtA[iNdEx-5]) << 24
+			m.Mtime |= int64(dAtA[iNdEx-4]) << 32
+			m.Mtime |= int64(dAtA[iNdEx-3]) << 40
+			m.Mtime |= int64(dAtA[iNdEx-2]) << 48
+			m.Mtime |= int64(dAtA[iNdEx-1]) << 56
+		case 7:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Atime", wireType)
+			}
+			m.Atime = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			m.Atime = int64(dAtA[iNdEx-8])
+			m.Atime |= int64(dAtA[iNdEx-7]) << 8
+			m.Atime |= int64(dAtA[iNdEx-6]) << 16
+			m.Atime |= int64(dAtA[iNdEx-5]) << 24
+			m.Atime |= int64(dAtA[iNdEx-4]) << 32
+			m.Atime |= int64(dAtA[iNdEx-3]) << 40
+			m.Atime |= int64(dAtA[iNdEx-2]) << 48
+			m.Atime |= int64(dAtA[iNdEx-1]) << 56
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Xattrs", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var keykey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				keykey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			var stringLenmapkey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLenmapkey := int(stringLenmapkey)
+			if intStringLenmapkey < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postStringIndexmapkey := iNdEx + intStringLenmapkey
+			if postStringIndexmapkey > l {
+				return io.ErrUnexpectedEOF
+			}
+			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
+			iNdEx = postStringIndexmapkey
+			if m.Xattrs == nil {
+				m.Xattrs = make(map[string][]byte)
+			}
+			if iNdEx < postIndex {
+				var valuekey uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSbf
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					valuekey |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				var mapbyteLen uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSbf
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					mapbyteLen |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				intMapbyteLen := int(mapbyteLen)
+				if intMapbyteLen < 0 {
+					return ErrInvalidLengthSbf
+				}
+				postbytesIndex := iNdEx + intMapbyteLen
+				if postbytesIndex > l {
+					return io.ErrUnexpectedEOF
+				}
+				mapvalue := make([]byte, mapbyteLen)
+				copy(mapvalue, dAtA[iNdEx:postbytesIndex])
+				iNdEx = postbytesIndex
+				m.Xattrs[mapkey] = mapvalue
+			} else {
+				var mapvalue []byte
+				m.Xattrs[mapkey] = mapvalue
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filepath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filepath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxChunkSize", wireType)
+			}
+			m.MaxChunkSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxChunkSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Offset", wireType)
+			}
+			m.Offset = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Offset |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NextChunk", wireType)
+			}
+			m.NextChunk = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.NextChunk |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Limits) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Limits: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Limits: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxRecvMsgSize", wireType)
+			}
+			m.MaxRecvMsgSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxRecvMsgSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxSendMsgSize", wireType)
+			}
+			m.MaxSendMsgSize = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxSendMsgSize |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BigFileAck) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BigFileAck: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BigFileAck: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filepath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filepath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SizeInBytes", wireType)
+			}
+			m.SizeInBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SizeInBytes |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RecvTime", wireType)
+			}
+			m.RecvTime = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			m.RecvTime = uint64(dAtA[iNdEx-8])
+			m.RecvTime |= uint64(dAtA[iNdEx-7]) << 8
+			m.RecvTime |= uint64(dAtA[iNdEx-6]) << 16
+			m.RecvTime |= uint64(dAtA[iNdEx-5]) << 24
+			m.RecvTime |= uint64(dAtA[iNdEx-4]) << 32
+			m.RecvTime |= uint64(dAtA[iNdEx-3]) << 40
+			m.RecvTime |= uint64(dAtA[iNdEx-2]) << 48
+			m.RecvTime |= uint64(dAtA[iNdEx-1]) << 56
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WholeFileBlake2B", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.WholeFileBlake2B = append(m.WholeFileBlake2B[:0], dAtA[iNdEx:postIndex]...)
+			if m.WholeFileBlake2B == nil {
+				m.WholeFileBlake2B = []byte{}
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Err = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FileHeader) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FileHeader: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FileHeader: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filepath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filepath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SizeInBytes", wireType)
+			}
+			m.SizeInBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SizeInBytes |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Metadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			var keykey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				keykey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			var stringLenmapkey uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLenmapkey |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLenmapkey := int(stringLenmapkey)
+			if intStringLenmapkey < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postStringIndexmapkey := iNdEx + intStringLenmapkey
+			if postStringIndexmapkey > l {
+				return io.ErrUnexpectedEOF
+			}
+			mapkey := string(dAtA[iNdEx:postStringIndexmapkey])
+			iNdEx = postStringIndexmapkey
+			if m.Metadata == nil {
+				m.Metadata = make(map[string]string)
+			}
+			if iNdEx < postIndex {
+				var valuekey uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSbf
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					valuekey |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				var stringLenmapvalue uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSbf
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					stringLenmapvalue |= (uint64(b) & 0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				intStringLenmapvalue := int(stringLenmapvalue)
+				if intStringLenmapvalue < 0 {
+					return ErrInvalidLengthSbf
+				}
+				postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+				if postStringIndexmapvalue > l {
+					return io.ErrUnexpectedEOF
+				}
+				mapvalue := string(dAtA[iNdEx:postStringIndexmapvalue])
+				iNdEx = postStringIndexmapvalue
+				m.Metadata[mapkey] = mapvalue
+			} else {
+				var mapvalue string
+				m.Metadata[mapkey] = mapvalue
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Attr", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Attr == nil {
+				m.Attr = &FileAttr{}
+			}
+			if err := m.Attr.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	if m.IsLastChunk {
-		n += 2
+	return nil
+}
+func (m *SessionMsg) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SessionMsg: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SessionMsg: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Header", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Header == nil {
+				m.Header = &FileHeader{}
+			}
+			if err := m.Header.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunk", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Chunk == nil {
+				m.Chunk = &BigFileChunk{}
+			}
+			if err := m.Chunk.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	if m.IsBcastSet {
-		n += 2
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	if m.OriginalStartSendTime != 0 {
-		n += 9
+	return nil
+}
+func (m *DeleteRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DeleteRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DeleteRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filepath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filepath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
 	}
-	return n
-}
 
-func (m *BigFileAck) Size() (n int) {
-	var l int
-	_ = l
-	l = len(m.Filepath)
-	if l > 0 {
-		n += 1 + l + sovSbf(uint64(l))
-	}
-	if m.SizeInBytes != 0 {
-		n += 1 + sovSbf(uint64(m.SizeInBytes))
-	}
-	if m.RecvTime != 0 {
-		n += 9
-	}
-	l = len(m.WholeFileBlake2B)
-	if l > 0 {
-		n += 1 + l + sovSbf(uint64(l))
-	}
-	l = len(m.Err)
-	if l > 0 {
-		n += 1 + l + sovSbf(uint64(l))
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
+	return nil
 }
-
-func sovSbf(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
+func (m *DeleteReply) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: DeleteReply: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: DeleteReply: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filepath", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filepath = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
-func sozSbf(x uint64) (n int) {
-	return sovSbf(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
 }
-func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
+func (m *SSHKey) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -570,15 +3982,15 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: BigFileChunk: wiretype end group for non-group")
+			return fmt.Errorf("proto: SSHKey: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: BigFileChunk: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: SSHKey: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Filepath", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field User", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -603,13 +4015,13 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Filepath = string(dAtA[iNdEx:postIndex])
+			m.User = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SizeInBytes", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AuthorizedKey", wireType)
 			}
-			m.SizeInBytes = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSbf
@@ -619,33 +4031,55 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.SizeInBytes |= (int64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.AuthorizedKey = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SendTime", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fingerprint", wireType)
 			}
-			m.SendTime = 0
-			if (iNdEx + 8) > l {
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += 8
-			m.SendTime = uint64(dAtA[iNdEx-8])
-			m.SendTime |= uint64(dAtA[iNdEx-7]) << 8
-			m.SendTime |= uint64(dAtA[iNdEx-6]) << 16
-			m.SendTime |= uint64(dAtA[iNdEx-5]) << 24
-			m.SendTime |= uint64(dAtA[iNdEx-4]) << 32
-			m.SendTime |= uint64(dAtA[iNdEx-3]) << 40
-			m.SendTime |= uint64(dAtA[iNdEx-2]) << 48
-			m.SendTime |= uint64(dAtA[iNdEx-1]) << 56
+			m.Fingerprint = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Blake2B", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Type", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSbf
@@ -655,28 +4089,26 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSbf
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Blake2B = append(m.Blake2B[:0], dAtA[iNdEx:postIndex]...)
-			if m.Blake2B == nil {
-				m.Blake2B = []byte{}
-			}
+			m.Type = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Blake2BCumulative", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Comment", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSbf
@@ -686,28 +4118,93 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSbf
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Blake2BCumulative = append(m.Blake2BCumulative[:0], dAtA[iNdEx:postIndex]...)
-			if m.Blake2BCumulative == nil {
-				m.Blake2BCumulative = []byte{}
+			m.Comment = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Added", wireType)
+			}
+			m.Added = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			m.Added = int64(dAtA[iNdEx-8])
+			m.Added |= int64(dAtA[iNdEx-7]) << 8
+			m.Added |= int64(dAtA[iNdEx-6]) << 16
+			m.Added |= int64(dAtA[iNdEx-5]) << 24
+			m.Added |= int64(dAtA[iNdEx-4]) << 32
+			m.Added |= int64(dAtA[iNdEx-3]) << 40
+			m.Added |= int64(dAtA[iNdEx-2]) << 48
+			m.Added |= int64(dAtA[iNdEx-1]) << 56
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SSHKeyQuery) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			iNdEx = postIndex
-		case 6:
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SSHKeyQuery: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SSHKeyQuery: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Data", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field User", wireType)
 			}
-			var byteLen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSbf
@@ -717,67 +4214,76 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSbf
 			}
-			postIndex := iNdEx + byteLen
+			postIndex := iNdEx + intStringLen
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Data = append(m.Data[:0], dAtA[iNdEx:postIndex]...)
-			if m.Data == nil {
-				m.Data = []byte{}
-			}
+			m.User = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 7:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ChunkNumber", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSbf(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			m.ChunkNumber = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSbf
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.ChunkNumber |= (int64(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if skippy < 0 {
+				return ErrInvalidLengthSbf
 			}
-		case 8:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsLastChunk", wireType)
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
 			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSbf
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SSHKeyList) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSbf
 			}
-			m.IsLastChunk = bool(v != 0)
-		case 9:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IsBcastSet", wireType)
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			var v int
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SSHKeyList: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SSHKeyList: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Keys", wireType)
+			}
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSbf
@@ -787,29 +4293,23 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.IsBcastSet = bool(v != 0)
-		case 10:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field OriginalStartSendTime", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthSbf
 			}
-			m.OriginalStartSendTime = 0
-			if (iNdEx + 8) > l {
+			postIndex := iNdEx + msglen
+			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			iNdEx += 8
-			m.OriginalStartSendTime = uint64(dAtA[iNdEx-8])
-			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-7]) << 8
-			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-6]) << 16
-			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-5]) << 24
-			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-4]) << 32
-			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-3]) << 40
-			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-2]) << 48
-			m.OriginalStartSendTime |= uint64(dAtA[iNdEx-1]) << 56
+			m.Keys = append(m.Keys, &SSHKey{})
+			if err := m.Keys[len(m.Keys)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSbf(dAtA[iNdEx:])
@@ -831,7 +4331,7 @@ func (m *BigFileChunk) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *BigFileAck) Unmarshal(dAtA []byte) error {
+func (m *ChunkAck) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -854,10 +4354,10 @@ func (m *BigFileAck) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: BigFileAck: wiretype end group for non-group")
+			return fmt.Errorf("proto: ChunkAck: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: BigFileAck: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ChunkAck: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -891,9 +4391,9 @@ func (m *BigFileAck) Unmarshal(dAtA []byte) error {
 			iNdEx = postIndex
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SizeInBytes", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ChunkNumber", wireType)
 			}
-			m.SizeInBytes = 0
+			m.ChunkNumber = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSbf
@@ -903,33 +4403,35 @@ func (m *BigFileAck) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.SizeInBytes |= (int64(b) & 0x7F) << shift
+				m.ChunkNumber |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 3:
-			if wireType != 1 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RecvTime", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Status", wireType)
 			}
-			m.RecvTime = 0
-			if (iNdEx + 8) > l {
-				return io.ErrUnexpectedEOF
+			m.Status = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Status |= (AckStatus(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
 			}
-			iNdEx += 8
-			m.RecvTime = uint64(dAtA[iNdEx-8])
-			m.RecvTime |= uint64(dAtA[iNdEx-7]) << 8
-			m.RecvTime |= uint64(dAtA[iNdEx-6]) << 16
-			m.RecvTime |= uint64(dAtA[iNdEx-5]) << 24
-			m.RecvTime |= uint64(dAtA[iNdEx-4]) << 32
-			m.RecvTime |= uint64(dAtA[iNdEx-3]) << 40
-			m.RecvTime |= uint64(dAtA[iNdEx-2]) << 48
-			m.RecvTime |= uint64(dAtA[iNdEx-1]) << 56
 		case 4:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WholeFileBlake2B", wireType)
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BytesVerified", wireType)
 			}
-			var byteLen int
+			m.BytesVerified = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSbf
@@ -939,23 +4441,11 @@ func (m *BigFileAck) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				byteLen |= (int(b) & 0x7F) << shift
+				m.BytesVerified |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if byteLen < 0 {
-				return ErrInvalidLengthSbf
-			}
-			postIndex := iNdEx + byteLen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.WholeFileBlake2B = append(m.WholeFileBlake2B[:0], dAtA[iNdEx:postIndex]...)
-			if m.WholeFileBlake2B == nil {
-				m.WholeFileBlake2B = []byte{}
-			}
-			iNdEx = postIndex
 		case 5:
 			if wireType != 2 {
 				return fmt.Errorf("proto: wrong wireType = %d for field Err", wireType)
@@ -985,6 +4475,74 @@ func (m *BigFileAck) Unmarshal(dAtA []byte) error {
 			}
 			m.Err = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IsFinal", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.IsFinal = bool(v != 0)
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WholeFileBlake2B", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSbf
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSbf
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.WholeFileBlake2B = append(m.WholeFileBlake2B[:0], dAtA[iNdEx:postIndex]...)
+			if m.WholeFileBlake2B == nil {
+				m.WholeFileBlake2B = []byte{}
+			}
+			iNdEx = postIndex
+		case 8:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RecvTime", wireType)
+			}
+			m.RecvTime = 0
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += 8
+			m.RecvTime = uint64(dAtA[iNdEx-8])
+			m.RecvTime |= uint64(dAtA[iNdEx-7]) << 8
+			m.RecvTime |= uint64(dAtA[iNdEx-6]) << 16
+			m.RecvTime |= uint64(dAtA[iNdEx-5]) << 24
+			m.RecvTime |= uint64(dAtA[iNdEx-4]) << 32
+			m.RecvTime |= uint64(dAtA[iNdEx-3]) << 40
+			m.RecvTime |= uint64(dAtA[iNdEx-2]) << 48
+			m.RecvTime |= uint64(dAtA[iNdEx-1]) << 56
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSbf(dAtA[iNdEx:])
@@ -1114,28 +4672,76 @@ var (
 func init() { proto.RegisterFile("sbf.proto", fileDescriptorSbf) }
 
 var fileDescriptorSbf = []byte{
-	// 359 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x92, 0x5f, 0x4a, 0xc3, 0x40,
-	0x10, 0xc6, 0xbb, 0x6d, 0x6d, 0x9b, 0xb1, 0x42, 0x5d, 0x10, 0xd6, 0x0a, 0x21, 0xf4, 0x29, 0x88,
-	0xf4, 0xa1, 0x7a, 0x81, 0xa6, 0x5a, 0x28, 0x48, 0x95, 0x44, 0xf0, 0x79, 0x13, 0xa7, 0xed, 0xd2,
-	0x24, 0x2d, 0x9b, 0x4d, 0x41, 0x4f, 0xe2, 0x01, 0x3c, 0x8c, 0x8f, 0x9e, 0x40, 0xa4, 0x5e, 0x44,
-	0xb2, 0xfd, 0x43, 0x4a, 0xfb, 0xe8, 0xdb, 0x7c, 0xbf, 0xf9, 0x12, 0x66, 0xbe, 0x1d, 0x30, 0x12,
-	0x7f, 0xd4, 0x9e, 0xcb, 0x99, 0x9a, 0xd1, 0x93, 0x44, 0x49, 0xe4, 0x91, 0x2f, 0xc6, 0x23, 0x11,
-	0x62, 0xeb, 0xbb, 0x08, 0x75, 0x47, 0x8c, 0xfb, 0x22, 0xc4, 0xde, 0x24, 0x8d, 0xa7, 0xb4, 0x09,
-	0xb5, 0x4c, 0xcc, 0xb9, 0x9a, 0x30, 0x62, 0x11, 0xdb, 0x70, 0xb7, 0x9a, 0x5a, 0x70, 0xec, 0x89,
-	0x37, 0x1c, 0xc4, 0xce, 0xab, 0xc2, 0x84, 0x15, 0x2d, 0x62, 0x97, 0xdc, 0x3c, 0xca, 0xbe, 0xf6,
-	0x30, 0x7e, 0x79, 0x12, 0x11, 0xb2, 0x92, 0x45, 0xec, 0x8a, 0xbb, 0xd5, 0x94, 0x41, 0xd5, 0x09,
-	0xf9, 0x14, 0x3b, 0x0e, 0x2b, 0x5b, 0xc4, 0xae, 0xbb, 0x1b, 0x49, 0xaf, 0xe0, 0x74, 0x5d, 0xf6,
-	0xd2, 0x28, 0x0d, 0xb9, 0x12, 0x0b, 0x64, 0x47, 0xda, 0xb3, 0xdf, 0xa0, 0x14, 0xca, 0xb7, 0x5c,
-	0x71, 0x56, 0xd1, 0x06, 0x5d, 0x67, 0x93, 0xe9, 0xf1, 0x87, 0x69, 0xe4, 0xa3, 0x64, 0xd5, 0xd5,
-	0x64, 0x39, 0x94, 0x39, 0x06, 0xc9, 0x3d, 0x4f, 0x94, 0x86, 0xac, 0x66, 0x11, 0xbb, 0xe6, 0xe6,
-	0x11, 0x35, 0x01, 0x06, 0x89, 0x13, 0xf0, 0x44, 0x79, 0xa8, 0x98, 0xa1, 0x0d, 0x39, 0x42, 0x6f,
-	0xe0, 0xec, 0x41, 0x8a, 0xb1, 0x88, 0x79, 0xe8, 0x29, 0x2e, 0xd5, 0x76, 0x51, 0xd0, 0x8b, 0x1e,
-	0x6e, 0xb6, 0x3e, 0x08, 0xc0, 0x3a, 0xe0, 0x6e, 0xf0, 0x0f, 0xf1, 0xba, 0x18, 0x2c, 0xf2, 0xf1,
-	0x6e, 0x34, 0xbd, 0x84, 0xc6, 0xf3, 0x64, 0x16, 0x62, 0xf6, 0xbb, 0xdd, 0x9c, 0xf7, 0x38, 0x6d,
-	0x40, 0xe9, 0x4e, 0x4a, 0x1d, 0xb1, 0xe1, 0x66, 0x65, 0x67, 0x08, 0xe5, 0x47, 0x44, 0x49, 0xfb,
-	0xab, 0x07, 0xcc, 0xcc, 0xf4, 0xa2, 0xbd, 0x73, 0x2b, 0xed, 0xfc, 0x9d, 0x34, 0xcf, 0x0f, 0x37,
-	0xbb, 0xc1, 0xb4, 0x55, 0xb0, 0x89, 0xd3, 0xf8, 0x5c, 0x9a, 0xe4, 0x6b, 0x69, 0x92, 0x9f, 0xa5,
-	0x49, 0xde, 0x7f, 0xcd, 0x82, 0x5f, 0xd1, 0xf7, 0x77, 0xfd, 0x17, 0x00, 0x00, 0xff, 0xff, 0xf5,
-	0x37, 0x27, 0xd1, 0x8c, 0x02, 0x00, 0x00,
+	// 1136 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0xcb, 0x6e, 0x23, 0x45,
+	0x17, 0x76, 0xdb, 0x8e, 0x2f, 0xc7, 0xf6, 0xfc, 0xfe, 0x4b, 0x0c, 0x74, 0xcc, 0x28, 0x32, 0x0d,
+	0x02, 0x67, 0x06, 0x45, 0x19, 0x83, 0xc4, 0x25, 0x12, 0x92, 0x9d, 0x89, 0x93, 0x90, 0x38, 0x03,
+	0xe5, 0x0c, 0xcc, 0xb6, 0x93, 0x3e, 0x76, 0x5a, 0xbe, 0xb4, 0xa9, 0xaa, 0x8e, 0xe2, 0x59, 0xf2,
+	0x04, 0x2c, 0x58, 0xf0, 0x00, 0x2c, 0x78, 0x14, 0x96, 0x3c, 0x02, 0x84, 0x57, 0x60, 0xc3, 0x0e,
+	0xd5, 0xa9, 0xb6, 0xd3, 0x76, 0x9c, 0x8b, 0x18, 0x76, 0xe7, 0x7c, 0xf5, 0x55, 0xf7, 0xa9, 0x53,
+	0xdf, 0xf9, 0xba, 0x21, 0x2f, 0x4f, 0xba, 0x1b, 0x63, 0x11, 0xa8, 0x80, 0x95, 0xa4, 0x12, 0xe8,
+	0x0e, 0x4f, 0xfc, 0x5e, 0xd7, 0x1f, 0xa0, 0xf3, 0x47, 0x1a, 0x8a, 0x4d, 0xbf, 0xd7, 0xf2, 0x07,
+	0xb8, 0x7d, 0x16, 0x8e, 0xfa, 0xac, 0x02, 0x39, 0x9d, 0x8c, 0x5d, 0x75, 0x66, 0x5b, 0x55, 0xab,
+	0x96, 0xe7, 0xb3, 0x9c, 0x55, 0xa1, 0xd0, 0xf1, 0x5f, 0xe1, 0xfe, 0xa8, 0x39, 0x51, 0x28, 0xed,
+	0x64, 0xd5, 0xaa, 0xa5, 0x78, 0x1c, 0xd2, 0xbb, 0x3b, 0x38, 0xf2, 0x8e, 0xfd, 0x21, 0xda, 0xa9,
+	0xaa, 0x55, 0xcb, 0xf0, 0x59, 0xce, 0x6c, 0xc8, 0x36, 0x07, 0x6e, 0x1f, 0xeb, 0x4d, 0x3b, 0x5d,
+	0xb5, 0x6a, 0x45, 0x3e, 0x4d, 0xd9, 0x87, 0xf0, 0xff, 0x28, 0xdc, 0x0e, 0x87, 0xe1, 0xc0, 0x55,
+	0xfe, 0x39, 0xda, 0x2b, 0xc4, 0xb9, 0xbe, 0xc0, 0x18, 0xa4, 0x9f, 0xb9, 0xca, 0xb5, 0x33, 0x44,
+	0xa0, 0x58, 0x57, 0x46, 0xe5, 0x1f, 0x85, 0xc3, 0x13, 0x14, 0x76, 0xd6, 0x54, 0x16, 0x83, 0x34,
+	0x63, 0x5f, 0x1e, 0xba, 0x52, 0x11, 0x68, 0xe7, 0xaa, 0x56, 0x2d, 0xc7, 0xe3, 0x10, 0x5b, 0x03,
+	0xd8, 0x97, 0xcd, 0x53, 0x57, 0xaa, 0x0e, 0x2a, 0x3b, 0x4f, 0x84, 0x18, 0xc2, 0x3e, 0x86, 0x87,
+	0xcf, 0x85, 0xdf, 0xf3, 0x47, 0xee, 0xa0, 0xa3, 0x5c, 0xa1, 0x66, 0x07, 0x05, 0x3a, 0xe8, 0xf2,
+	0x45, 0xf6, 0x04, 0xd2, 0x0d, 0xa5, 0x84, 0x5d, 0xa8, 0x5a, 0xb5, 0x42, 0xfd, 0xad, 0x8d, 0xb9,
+	0xf6, 0x6f, 0xe8, 0xd6, 0xea, 0x65, 0x4e, 0x24, 0xdd, 0xbe, 0xbd, 0x60, 0x80, 0xba, 0xa3, 0x76,
+	0x91, 0xce, 0x30, 0xcb, 0xd9, 0x23, 0xc8, 0x77, 0xfc, 0xde, 0xc8, 0x55, 0xa1, 0x40, 0xbb, 0x44,
+	0x67, 0xbf, 0x02, 0xd8, 0x9b, 0x90, 0xe1, 0x28, 0xc3, 0x21, 0xda, 0x0f, 0xa8, 0xf0, 0x28, 0x63,
+	0x3b, 0x90, 0x6b, 0xa3, 0x72, 0x3d, 0xdd, 0xb0, 0xff, 0x55, 0x53, 0xb5, 0x42, 0x7d, 0x7d, 0xa1,
+	0x84, 0xf8, 0xed, 0x6f, 0x4c, 0xb9, 0x3b, 0x23, 0x25, 0x26, 0x7c, 0xb6, 0xb5, 0xb2, 0x05, 0xa5,
+	0xb9, 0x25, 0x56, 0x86, 0x54, 0x1f, 0x27, 0x91, 0x42, 0x74, 0xc8, 0xde, 0x80, 0x95, 0x73, 0x77,
+	0x10, 0x22, 0xc9, 0x22, 0xcf, 0x4d, 0xf2, 0x79, 0xf2, 0x53, 0xcb, 0xf9, 0x21, 0x69, 0x34, 0x45,
+	0x47, 0x64, 0x90, 0x6e, 0x07, 0x1e, 0xd2, 0xce, 0x12, 0xa7, 0x58, 0x3f, 0xec, 0x85, 0xef, 0xd1,
+	0xc6, 0x12, 0xd7, 0xa1, 0x46, 0x76, 0x7d, 0x8f, 0x24, 0x54, 0xe2, 0x3a, 0xd4, 0xfb, 0x5e, 0x48,
+	0x14, 0x24, 0x9d, 0x3c, 0xa7, 0x58, 0xbf, 0x72, 0x57, 0x04, 0xe1, 0x98, 0xb4, 0x92, 0xe7, 0x26,
+	0xd1, 0x68, 0x5b, 0xe9, 0x7b, 0xd1, 0x02, 0x29, 0x73, 0x93, 0x68, 0xb4, 0x41, 0x68, 0xd6, 0xa0,
+	0x94, 0xb0, 0x2d, 0xc8, 0xbc, 0x74, 0x95, 0x12, 0xd2, 0xce, 0x51, 0x73, 0xde, 0xbd, 0xe1, 0x7e,
+	0x36, 0x0c, 0xcb, 0xb4, 0x25, 0xda, 0x52, 0xf9, 0x0c, 0x0a, 0x31, 0xf8, 0xae, 0x96, 0x14, 0xe3,
+	0x2d, 0xf9, 0xde, 0x02, 0xd8, 0x45, 0xc5, 0xf1, 0xbb, 0x10, 0xa5, 0xba, 0x75, 0xe8, 0x1c, 0x28,
+	0xb6, 0xdd, 0x0b, 0xba, 0x1e, 0xd2, 0x85, 0x99, 0xba, 0x39, 0x4c, 0xdf, 0xfe, 0xf3, 0x6e, 0x57,
+	0xa2, 0xa2, 0x8e, 0xa5, 0x78, 0x94, 0x69, 0xcd, 0x1c, 0xe1, 0x45, 0x24, 0xf9, 0x34, 0x2d, 0x5d,
+	0x01, 0xce, 0x4b, 0xc8, 0x1c, 0xfa, 0x43, 0x5f, 0x49, 0xf6, 0x3e, 0x3c, 0x68, 0xbb, 0x17, 0x1c,
+	0x4f, 0xcf, 0xdb, 0xb2, 0x47, 0x6f, 0xb1, 0x88, 0xbc, 0x80, 0x46, 0x3c, 0xad, 0xed, 0x29, 0x2f,
+	0x39, 0xe3, 0xc5, 0x50, 0xe7, 0x67, 0x0b, 0x20, 0xd2, 0x55, 0xe3, 0xf4, 0x3f, 0xf0, 0x14, 0x5d,
+	0x43, 0xdc, 0x53, 0xa6, 0x39, 0x7b, 0x0c, 0xe5, 0x6f, 0xcf, 0x82, 0x01, 0xea, 0xc7, 0xcd, 0x9b,
+	0xcb, 0x35, 0x5c, 0xdf, 0xcf, 0x8e, 0x10, 0x91, 0x56, 0x74, 0xe8, 0xfc, 0x6d, 0x01, 0x68, 0xc6,
+	0x1e, 0xba, 0x1e, 0x8a, 0xd7, 0x2c, 0x73, 0x3b, 0x36, 0x69, 0x29, 0x12, 0xd3, 0x07, 0x4b, 0xc4,
+	0x64, 0x5e, 0x75, 0xd3, 0x9c, 0xcd, 0xdc, 0x22, 0x7d, 0x0f, 0xb7, 0x78, 0xbd, 0xa1, 0x14, 0x00,
+	0x1d, 0x94, 0xd2, 0x0f, 0x46, 0x6d, 0xd9, 0x63, 0x4f, 0x21, 0x63, 0x2a, 0xa3, 0xcd, 0x85, 0xfa,
+	0xea, 0x8d, 0xa5, 0xf3, 0x88, 0xc8, 0x9e, 0xc2, 0x8a, 0xd1, 0x55, 0x92, 0x76, 0xbc, 0x7d, 0x8b,
+	0xad, 0x70, 0xc3, 0x74, 0x9e, 0x40, 0xe9, 0x19, 0x0e, 0x50, 0xe1, 0x3d, 0x74, 0xef, 0xac, 0x43,
+	0x61, 0x4a, 0x1e, 0x0f, 0x26, 0xb7, 0x52, 0x7f, 0xb1, 0x20, 0xd3, 0xe9, 0xec, 0x1d, 0xe0, 0x64,
+	0x66, 0x13, 0x56, 0xcc, 0x26, 0xde, 0x83, 0x52, 0x23, 0x54, 0x67, 0x81, 0xf0, 0x5f, 0xa1, 0x77,
+	0x80, 0x93, 0xa8, 0x19, 0xf3, 0xa0, 0xbe, 0xe1, 0x96, 0x3f, 0xea, 0xa1, 0x18, 0x0b, 0x7f, 0x64,
+	0x06, 0x29, 0xcf, 0xe3, 0x90, 0x7e, 0xf6, 0xf1, 0x64, 0x8c, 0x53, 0x0b, 0xd2, 0xb1, 0xfe, 0xa8,
+	0x6d, 0x07, 0xc3, 0x21, 0x8e, 0x54, 0x24, 0xac, 0x69, 0x4a, 0x86, 0xe3, 0x79, 0xe8, 0x4d, 0x6d,
+	0x88, 0x12, 0xe7, 0x1d, 0x28, 0x98, 0x4a, 0xbf, 0x0e, 0x51, 0x2c, 0x2d, 0xd7, 0xf9, 0x04, 0xc0,
+	0x50, 0x0e, 0x7d, 0xa9, 0xd8, 0x3a, 0xa4, 0x0f, 0x70, 0x22, 0x6d, 0x8b, 0x24, 0xf5, 0x70, 0xa1,
+	0xcb, 0x86, 0xc8, 0x89, 0xe2, 0xfc, 0x98, 0x84, 0x1c, 0x35, 0xfa, 0x1e, 0x33, 0x17, 0xff, 0x5a,
+	0x26, 0xaf, 0x7f, 0x2d, 0x37, 0x21, 0xd3, 0x51, 0xae, 0x0a, 0x25, 0xf5, 0xe1, 0x41, 0xdd, 0x5e,
+	0x78, 0x6f, 0xe3, 0xb4, 0x6f, 0xd6, 0x79, 0xc4, 0xd3, 0x4d, 0xa6, 0x39, 0xf8, 0x06, 0x85, 0xdf,
+	0xf5, 0xd1, 0x8b, 0xec, 0x66, 0x1e, 0xbc, 0x3e, 0x83, 0xba, 0x81, 0xfb, 0xb2, 0xa5, 0xbf, 0x9b,
+	0xd4, 0xa8, 0x1c, 0x9f, 0xa6, 0x4b, 0x67, 0x3b, 0x7b, 0xc3, 0x6c, 0xc7, 0x3d, 0x22, 0x37, 0xef,
+	0x11, 0x8f, 0x6b, 0x90, 0x9f, 0x95, 0xcb, 0xb2, 0x90, 0x6a, 0x6c, 0x1f, 0x94, 0x13, 0x3a, 0x38,
+	0x6a, 0x1c, 0x94, 0x2d, 0x96, 0x87, 0x95, 0x56, 0xe3, 0xb8, 0x71, 0x58, 0x4e, 0xd6, 0xff, 0x4a,
+	0x43, 0xfa, 0x2b, 0x44, 0xc1, 0x5a, 0xe6, 0x37, 0x46, 0xbf, 0x81, 0xdd, 0x26, 0xec, 0xca, 0xea,
+	0xf2, 0xc5, 0xc6, 0x69, 0xdf, 0x49, 0xd4, 0x2c, 0xb6, 0xa5, 0xfd, 0xb7, 0x17, 0x28, 0xdf, 0x55,
+	0xc8, 0x16, 0xef, 0xce, 0x78, 0x6f, 0x65, 0x39, 0xec, 0x24, 0xd8, 0x97, 0x50, 0x3c, 0x16, 0xee,
+	0x48, 0x76, 0x51, 0xdc, 0x5d, 0xc8, 0xa2, 0x55, 0x4c, 0x75, 0xa0, 0xcb, 0xd8, 0xb4, 0xd8, 0x0e,
+	0x64, 0xa3, 0x69, 0x67, 0x8b, 0x25, 0x5f, 0xb9, 0xc0, 0x1d, 0xa7, 0x31, 0x8f, 0xd9, 0x45, 0x45,
+	0xd5, 0x2c, 0x72, 0xaf, 0xbe, 0x66, 0x95, 0xdb, 0x0a, 0x75, 0x12, 0x9b, 0x16, 0xdb, 0x03, 0x30,
+	0xa3, 0x4d, 0x4f, 0x7a, 0xb4, 0x40, 0x9f, 0xb3, 0x88, 0x4a, 0xe5, 0x86, 0xd5, 0xf1, 0x60, 0xe2,
+	0x24, 0x74, 0x83, 0x1b, 0x9e, 0x17, 0xcd, 0xfe, 0xf2, 0xe1, 0xa8, 0x2c, 0x87, 0x9d, 0x04, 0x6b,
+	0x41, 0x41, 0x8f, 0x98, 0xc9, 0x25, 0xab, 0x2c, 0xe5, 0xd1, 0x9c, 0x56, 0x56, 0x97, 0xae, 0xe9,
+	0xdd, 0x4e, 0x82, 0x7d, 0x01, 0x45, 0x8e, 0xe7, 0x41, 0x1f, 0xff, 0x5d, 0x1d, 0xcd, 0xf2, 0xaf,
+	0x97, 0x6b, 0xd6, 0x6f, 0x97, 0x6b, 0xd6, 0xef, 0x97, 0x6b, 0xd6, 0x4f, 0x7f, 0xae, 0x25, 0x4e,
+	0x32, 0xf4, 0xaf, 0xfe, 0xd1, 0x3f, 0x03, 0x00, 0x70, 0x6e, 0x87, 0x33, 0xb8, 0x0b, 0x00, 0x00,
 }
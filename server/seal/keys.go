@@ -0,0 +1,133 @@
+package seal
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// Wrapper seals and unseals the data keys of stored files with a
+// master key it holds. KeyFile is one; a KMS client can be another,
+// so long as the master key never has to leave the KMS.
+type Wrapper interface {
+	// Wrap seals dataKey, returning the ID of the master key it
+	// used along with the wrapped key.
+	Wrap(dataKey []byte) (keyID string, wrapped []byte, err error)
+
+	// Unwrap opens a data key wrapped by the master key keyID.
+	Unwrap(keyID string, wrapped []byte) ([]byte, error)
+}
+
+// masterBlock is the PEM block type of the keys in a key file.
+const masterBlock = "STORE MASTER KEY"
+
+// KeyFile is a Wrapper holding its master keys in a local file,
+// as PEM blocks. The first key wraps new data keys; the rest are
+// kept to unwrap those of files stored before a key rotation.
+type KeyFile struct {
+	keys []masterKey
+}
+
+type masterKey struct {
+	id  string
+	key []byte
+}
+
+// GenerateKey returns a new master key, PEM encoded, to be the
+// first in a key file.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: masterBlock, Bytes: key}), nil
+}
+
+// LoadKeyFile reads the master keys in path.
+func LoadKeyFile(path string) (*KeyFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &KeyFile{}
+	for {
+		var b *pem.Block
+		b, data = pem.Decode(data)
+		if b == nil {
+			break
+		}
+		if b.Type != masterBlock {
+			return nil, fmt.Errorf("'%s' holds a %s, not a %s", path, b.Type, masterBlock)
+		}
+		if len(b.Bytes) != chacha20poly1305.KeySize {
+			return nil, fmt.Errorf("'%s' holds a master key of %v bytes; want %v", path, len(b.Bytes), chacha20poly1305.KeySize)
+		}
+		k.keys = append(k.keys, masterKey{id: keyID(b.Bytes), key: b.Bytes})
+	}
+
+	if len(k.keys) == 0 {
+		return nil, fmt.Errorf("no %s found in '%s'", masterBlock, path)
+	}
+
+	return k, nil
+}
+
+// keyID names a master key without giving it away.
+func keyID(key []byte) string {
+	sum := sha256.Sum256(key)
+
+	return hex.EncodeToString(sum[:8])
+}
+
+// ID is the ID of the master key that wraps new data keys.
+func (k *KeyFile) ID() string {
+	return k.keys[0].id
+}
+
+// Wrap implements Wrapper.
+func (k *KeyFile) Wrap(dataKey []byte) (string, []byte, error) {
+	mk := k.keys[0]
+	aead, err := chacha20poly1305.NewX(mk.key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(dataKey)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", nil, err
+	}
+
+	return mk.id, aead.Seal(nonce, nonce, dataKey, []byte(mk.id)), nil
+}
+
+// Unwrap implements Wrapper.
+func (k *KeyFile) Unwrap(id string, wrapped []byte) ([]byte, error) {
+	for _, mk := range k.keys {
+		if mk.id != id {
+			continue
+		}
+
+		aead, err := chacha20poly1305.NewX(mk.key)
+		if err != nil {
+			return nil, err
+		}
+		if len(wrapped) < aead.NonceSize() {
+			return nil, fmt.Errorf("wrapped data key is too short")
+		}
+
+		key, err := aead.Open(nil, wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():], []byte(id))
+		if err != nil {
+			return nil, fmt.Errorf("could not unwrap data key with master key %s: %v", id, err)
+		}
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("data key was wrapped by master key %s, which is not in our key file", id)
+}
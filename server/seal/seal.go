@@ -0,0 +1,388 @@
+// Package seal encrypts stored files at rest. Each file gets its
+// own random data key, wrapped by a master key held by a Wrapper,
+// and its contents are sealed with XChaCha20-Poly1305 in records
+// of at most RecordSize bytes.
+//
+// A sealed file is a header followed by records:
+//
+//	header: Magic | uint16 len | master key ID | uint16 len | wrapped data key
+//	record: kind | uint32 len | nonce | sealed body
+//
+// A data record's body is the file's bytes; a hole record's is the
+// length of a run of zeros, so holes stay cheap; the end record's is
+// the file's size, and its absence means the file was cut short.
+// Each record is sealed with its kind and index as additional data,
+// so records can be neither altered, reordered nor dropped.
+package seal
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"golang.org/x/crypto/chacha20poly1305"
+
+	"github.com/devops-filetransfer/filetransfer/server/sparse"
+)
+
+// Magic starts every sealed file.
+const Magic = "\x00ftseal1"
+
+// RecordSize is the most file data one record holds.
+const RecordSize = 64 << 10
+
+// The kinds of record.
+const (
+	dataRecord = 'd'
+	holeRecord = 'h'
+	endRecord  = 'e'
+)
+
+// recordHeader is the kind, length and nonce before each
+// record's sealed body.
+const recordHeader = 1 + 4 + chacha20poly1305.NonceSizeX
+
+// IsSealed says whether the file r starts like a sealed one.
+func IsSealed(r io.ReaderAt) bool {
+	b := make([]byte, len(Magic))
+	_, err := r.ReadAt(b, 0)
+
+	return err == nil && string(b) == Magic
+}
+
+// Writer seals a file as it is written, in order.
+type Writer struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	buf   []byte
+	index uint64
+	size  int64
+}
+
+// NewWriter makes a new data key, wrapped with keys, and starts a
+// sealed file on w.
+func NewWriter(w io.Writer, keys Wrapper) (*Writer, error) {
+	dataKey := make([]byte, chacha20poly1305.KeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+
+	id, wrapped, err := keys.Wrap(dataKey)
+	if err != nil {
+		return nil, fmt.Errorf("seal: could not wrap data key: %v", err)
+	}
+
+	aead, err := chacha20poly1305.NewX(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	var hdr bytes.Buffer
+	hdr.WriteString(Magic)
+	writeShort(&hdr, []byte(id))
+	writeShort(&hdr, wrapped)
+	if _, err := w.Write(hdr.Bytes()); err != nil {
+		return nil, err
+	}
+
+	return &Writer{w: w, aead: aead, buf: make([]byte, 0, RecordSize)}, nil
+}
+
+func writeShort(b *bytes.Buffer, p []byte) {
+	var n [2]byte
+	binary.BigEndian.PutUint16(n[:], uint16(len(p)))
+	b.Write(n[:])
+	b.Write(p)
+}
+
+// Write appends p to the file.
+func (w *Writer) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		k := RecordSize - len(w.buf)
+		if k > len(p) {
+			k = len(p)
+		}
+		w.buf = append(w.buf, p[:k]...)
+		p = p[k:]
+		n += k
+
+		if len(w.buf) == RecordSize {
+			if err := w.flush(); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	return n, nil
+}
+
+// Hole appends n zero bytes to the file, taking next to no room.
+func (w *Writer) Hole(n int64) error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+
+	return w.record(holeRecord, binary.BigEndian.AppendUint64(nil, uint64(n)), n)
+}
+
+// Size is the number of bytes written so far, holes included.
+func (w *Writer) Size() int64 {
+	return w.size + int64(len(w.buf))
+}
+
+// Close ends the file. It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	return w.record(endRecord, binary.BigEndian.AppendUint64(nil, uint64(w.size)), 0)
+}
+
+func (w *Writer) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	err := w.record(dataRecord, w.buf, int64(len(w.buf)))
+	w.buf = w.buf[:0]
+
+	return err
+}
+
+// record seals body as the next record, of kind, standing for
+// n bytes of the file.
+func (w *Writer) record(kind byte, body []byte, n int64) error {
+	rec := make([]byte, recordHeader, recordHeader+len(body)+w.aead.Overhead())
+	rec[0] = kind
+	binary.BigEndian.PutUint32(rec[1:5], uint32(len(body)+w.aead.Overhead()))
+	nonce := rec[5:recordHeader]
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+
+	rec = w.aead.Seal(rec, nonce, body, additional(kind, w.index))
+	if _, err := w.w.Write(rec); err != nil {
+		return err
+	}
+
+	w.index++
+	w.size += n
+
+	return nil
+}
+
+// additional is the data each record is bound to besides its body.
+func additional(kind byte, index uint64) []byte {
+	return binary.BigEndian.AppendUint64([]byte{kind}, index)
+}
+
+// Reader reads a sealed file. It is safe for concurrent use.
+type Reader struct {
+	r       io.ReaderAt
+	aead    cipher.AEAD
+	records []record
+	size    int64
+
+	mu     sync.Mutex
+	cached int // index into records of plain, or -1
+	plain  []byte
+}
+
+// record is where one data or hole record sits, in the sealed
+// file and in the plain one.
+type record struct {
+	kind   byte
+	index  uint64
+	pos    int64 // of the record, in the sealed file
+	sealed int   // length of the sealed body
+	off    int64 // of its bytes, in the plain file
+	n      int64
+}
+
+// Open unwraps the data key of the sealed file r with keys, and
+// walks its records. Data records are only checked as they are
+// read; holes and the end record are checked here.
+func Open(r io.ReaderAt, keys Wrapper) (*Reader, error) {
+	pos := int64(len(Magic))
+	if !IsSealed(r) {
+		return nil, fmt.Errorf("seal: not a sealed file")
+	}
+
+	id, err := readShort(r, &pos)
+	if err != nil {
+		return nil, err
+	}
+	wrapped, err := readShort(r, &pos)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := keys.Unwrap(string(id), wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("seal: %v", err)
+	}
+	aead, err := chacha20poly1305.NewX(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sr := &Reader{r: r, aead: aead, cached: -1}
+	for index := uint64(0); ; index++ {
+		var hdr [recordHeader]byte
+		if _, err := r.ReadAt(hdr[:], pos); err != nil {
+			if err == io.EOF {
+				return nil, fmt.Errorf("seal: file ends after %v bytes without its end record; it was cut short", sr.size)
+			}
+			return nil, err
+		}
+
+		rec := record{kind: hdr[0], index: index, pos: pos, sealed: int(binary.BigEndian.Uint32(hdr[1:5])), off: sr.size}
+		if rec.sealed < aead.Overhead() || rec.sealed > RecordSize+aead.Overhead() {
+			return nil, fmt.Errorf("seal: record %v has a bad length, %v", index, rec.sealed)
+		}
+		pos += recordHeader + int64(rec.sealed)
+
+		switch rec.kind {
+		case dataRecord:
+			rec.n = int64(rec.sealed - aead.Overhead())
+			sr.records = append(sr.records, rec)
+			sr.size += rec.n
+
+		case holeRecord, endRecord:
+			body, err := sr.open(&rec)
+			if err != nil {
+				return nil, err
+			}
+			if len(body) != 8 {
+				return nil, fmt.Errorf("seal: record %v has a bad body", index)
+			}
+			n := int64(binary.BigEndian.Uint64(body))
+
+			if rec.kind == endRecord {
+				if n != sr.size {
+					return nil, fmt.Errorf("seal: file should be %v bytes, but its records hold %v", n, sr.size)
+				}
+				return sr, nil
+			}
+
+			rec.n = n
+			sr.records = append(sr.records, rec)
+			sr.size += rec.n
+
+		default:
+			return nil, fmt.Errorf("seal: record %v is of unknown kind %q", index, rec.kind)
+		}
+	}
+}
+
+func readShort(r io.ReaderAt, pos *int64) ([]byte, error) {
+	var n [2]byte
+	if _, err := r.ReadAt(n[:], *pos); err != nil {
+		return nil, fmt.Errorf("seal: short header: %v", err)
+	}
+	p := make([]byte, binary.BigEndian.Uint16(n[:]))
+	if _, err := r.ReadAt(p, *pos+2); err != nil {
+		return nil, fmt.Errorf("seal: short header: %v", err)
+	}
+	*pos += 2 + int64(len(p))
+
+	return p, nil
+}
+
+// open reads and unseals the body of rec.
+func (sr *Reader) open(rec *record) ([]byte, error) {
+	buf := make([]byte, recordHeader+rec.sealed)
+	if _, err := sr.r.ReadAt(buf, rec.pos); err != nil {
+		return nil, fmt.Errorf("seal: reading record %v: %v", rec.index, err)
+	}
+
+	body, err := sr.aead.Open(buf[recordHeader:recordHeader], buf[5:recordHeader], buf[recordHeader:], additional(rec.kind, rec.index))
+	if err != nil {
+		return nil, fmt.Errorf("seal: record %v does not check out; the file has been altered or damaged", rec.index)
+	}
+
+	return body, nil
+}
+
+// Size is the size of the plain file.
+func (sr *Reader) Size() int64 {
+	return sr.size
+}
+
+// Segments returns the data and hole segments of the plain file,
+// in the form sparse.Map gives them for plain ones.
+func (sr *Reader) Segments() []sparse.Segment {
+	var segs []sparse.Segment
+	for _, rec := range sr.records {
+		s := sparse.Segment{Offset: rec.off, Length: rec.n, Hole: rec.kind == holeRecord}
+		if n := len(segs); n > 0 && segs[n-1].Hole == s.Hole {
+			segs[n-1].Length += s.Length
+			continue
+		}
+		segs = append(segs, s)
+	}
+
+	if len(segs) == 0 {
+		segs = append(segs, sparse.Segment{})
+	}
+
+	return segs
+}
+
+// ReadAt implements io.ReaderAt over the plain file.
+func (sr *Reader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("seal: negative offset %v", off)
+	}
+
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	n := 0
+	for n < len(p) && off < sr.size {
+		i := sort.Search(len(sr.records), func(i int) bool {
+			return sr.records[i].off+sr.records[i].n > off
+		})
+		rec := &sr.records[i]
+		within := off - rec.off
+
+		var k int
+		if rec.kind == holeRecord {
+			k = len(p) - n
+			if rest := rec.n - within; int64(k) > rest {
+				k = int(rest)
+			}
+			for j := range p[n : n+k] {
+				p[n+j] = 0
+			}
+		} else {
+			if sr.cached != i {
+				body, err := sr.open(rec)
+				if err != nil {
+					return n, err
+				}
+				sr.cached, sr.plain = i, body
+			}
+			k = copy(p[n:], sr.plain[within:])
+		}
+
+		n += k
+		off += int64(k)
+	}
+
+	if n < len(p) {
+		return n, io.EOF
+	}
+
+	return n, nil
+}
@@ -0,0 +1,128 @@
+package seal
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/devops-filetransfer/filetransfer/server/sparse"
+)
+
+func keyFile(t *testing.T, name string) *KeyFile {
+	path := filepath.Join(t.TempDir(), name)
+	data, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := LoadKeyFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k
+}
+
+// sealed writes data, a hole of hole bytes, then data again.
+func sealed(t *testing.T, keys Wrapper, data []byte, hole int64) []byte {
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range [][]byte{data[:1000], data[1000:]} {
+		if _, err := w.Write(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Hole(hole); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	keys := keyFile(t, "store.key")
+	data := make([]byte, 3*RecordSize+123)
+	rand.New(rand.NewSource(1)).Read(data)
+	const hole = 1 << 30
+
+	file := sealed(t, keys, data, hole)
+	if !IsSealed(bytes.NewReader(file)) {
+		t.Fatal("not recognised as sealed")
+	}
+	if bytes.Contains(file, data[:64]) {
+		t.Fatal("plain text in the sealed file")
+	}
+
+	r, err := Open(bytes.NewReader(file), keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2*int64(len(data)) + hole; r.Size() != want {
+		t.Fatalf("size %v, want %v", r.Size(), want)
+	}
+
+	want := []sparse.Segment{
+		{Offset: 0, Length: int64(len(data))},
+		{Offset: int64(len(data)), Length: hole, Hole: true},
+		{Offset: int64(len(data)) + hole, Length: int64(len(data))},
+	}
+	if got := r.Segments(); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Fatalf("segments %v, want %v", got, want)
+	}
+
+	// reads that straddle records, and the hole.
+	got := make([]byte, len(data))
+	if _, err := r.ReadAt(got, 0); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("first copy differs: %v", err)
+	}
+	if _, err := r.ReadAt(got, int64(len(data))+hole); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("second copy differs: %v", err)
+	}
+	edge := make([]byte, 20)
+	if _, err := r.ReadAt(edge, int64(len(data))-10); err != nil || !bytes.Equal(edge[:10], data[len(data)-10:]) || !bytes.Equal(edge[10:], make([]byte, 10)) {
+		t.Fatalf("read across into the hole: %v", err)
+	}
+	if n, err := r.ReadAt(edge, r.Size()-5); n != 5 || err != io.EOF {
+		t.Fatalf("read past the end gave %v, %v", n, err)
+	}
+}
+
+func TestTampering(t *testing.T) {
+	keys := keyFile(t, "store.key")
+	data := make([]byte, 2*RecordSize)
+	file := sealed(t, keys, data, 1<<20)
+
+	if _, err := Open(bytes.NewReader(file), keyFile(t, "other.key")); err == nil {
+		t.Fatal("opened with the wrong master key")
+	}
+
+	// cut off the end record.
+	if _, err := Open(bytes.NewReader(file[:len(file)-recordHeader-24]), keys); err == nil {
+		t.Fatal("opened a truncated file")
+	}
+
+	flipped := append([]byte(nil), file...)
+	flipped[len(file)/3] ^= 1
+	r, err := Open(bytes.NewReader(flipped), keys)
+	if err == nil {
+		_, err = r.ReadAt(make([]byte, len(data)), 0)
+	}
+	if err == nil {
+		t.Fatal("read an altered file")
+	}
+}
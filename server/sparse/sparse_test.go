@@ -0,0 +1,25 @@
+package sparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCoalesceFoldsSmallHoles(t *testing.T) {
+	segs := []Segment{
+		{Offset: 0, Length: 100},
+		{Offset: 100, Length: 4096, Hole: true},
+		{Offset: 4196, Length: 100},
+		{Offset: 4296, Length: MinHole, Hole: true},
+	}
+
+	got := coalesce(segs)
+	want := []Segment{
+		{Offset: 0, Length: 4296},
+		{Offset: 4296, Length: MinHole, Hole: true},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net"
+
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	tun "github.com/devops-filetransfer/sshego"
+	xssh "github.com/glycerine/sshego/xendor/github.com/glycerine/xcryptossh"
+)
+
+// userExtension is the Permissions extension keyCallback puts the
+// login it let in under.
+const userExtension = "user"
+
+// directMsg is the payload of a direct-tcpip channel open, per
+// RFC 4254 7.2.
+type directMsg struct {
+	Rhost string
+	Rport uint32
+	Lhost string
+	Lport uint32
+}
+
+// forward shakes hands with the client on c, and forwards its
+// tunnels to target, our gRPC service, and nowhere else, in place
+// of sshego's handlers, which would forward them anywhere, and
+// without saying for whom. Each connection it dials to target goes
+// in logins, as that of the login the key check let in.
+func forward(ctx context.Context, c net.Conn, config *xssh.ServerConfig, target string, logins *identity.SSHLogins) error {
+	conn, chans, reqs, err := xssh.NewServerConn(ctx, c, config)
+	if err != nil {
+		return err
+	}
+
+	user := conn.Permissions.Extensions[userExtension]
+	if user == "" {
+		conn.Close()
+		return fmt.Errorf("login from %v has no user", conn.RemoteAddr())
+	}
+
+	go tun.DiscardRequestsExceptKeepalives(ctx, reqs, make(chan struct{}))
+	go func() {
+		for nc := range chans {
+			go forwardChannel(nc, target, user, logins)
+		}
+	}()
+
+	return nil
+}
+
+// forwardChannel carries nc, a tunnel opened by user, to target.
+func forwardChannel(nc xssh.NewChannel, target, user string, logins *identity.SSHLogins) {
+	if nc.ChannelType() != "direct-tcpip" {
+		nc.Reject(xssh.UnknownChannelType, fmt.Sprintf("unknown channel type: %s", nc.ChannelType()))
+		return
+	}
+
+	var msg directMsg
+	if err := xssh.Unmarshal(nc.ExtraData(), &msg); err != nil {
+		nc.Reject(xssh.ConnectionFailed, "could not parse direct-tcpip payload: "+err.Error())
+		return
+	}
+	_, port, _ := net.SplitHostPort(target)
+	if fmt.Sprint(msg.Rport) != port {
+		log.Printf("ssh: refusing '%s' a tunnel to %s:%d; we only forward to port %s", user, msg.Rhost, msg.Rport, port)
+		nc.Reject(xssh.Prohibited, "only port "+port+" is forwarded")
+		return
+	}
+
+	t, err := net.Dial("tcp", target)
+	if err != nil {
+		nc.Reject(xssh.ConnectionFailed, err.Error())
+		return
+	}
+	defer t.Close()
+
+	// known before the first byte gets to gRPC, and so before any call.
+	logins.Add(t, user)
+	defer logins.Remove(t)
+
+	ch, reqs, err := nc.Accept()
+	if err != nil {
+		log.Printf("ssh: could not accept a tunnel for '%s': %v", user, err)
+		return
+	}
+	defer ch.Close()
+
+	go func() {
+		for req := range reqs {
+			if req.WantReply {
+				req.Reply(false, nil)
+			}
+		}
+	}()
+
+	// once either side is done, so is the other.
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(t, ch)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(ch, t)
+		done <- struct{}{}
+	}()
+	<-done
+}
@@ -0,0 +1,132 @@
+package ssh
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	"github.com/devops-filetransfer/filetransfer/server/sshkeys"
+	xssh "github.com/glycerine/sshego/xendor/github.com/glycerine/xcryptossh"
+)
+
+func TestCallerIsTheLoginNotWhoTheClientSays(t *testing.T) {
+	keys, err := sshkeys.Open(filepath.Join(t.TempDir(), "authorized_keys.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	signer, err := xssh.NewSignerFromKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keys.Add("alice", xssh.MarshalAuthorizedKey(signer.PublicKey())); err != nil {
+		t.Fatal(err)
+	}
+
+	// the gRPC service behind the sshd, noting who called.
+	logins := identity.NewSSHLogins()
+	callers := make(chan string, 1)
+	note := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		callers <- identity.FromContext(ctx)
+		return handler(ctx, req)
+	}
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(logins.UnaryInterceptor(), note))
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	glis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(glis)
+	defer srv.Stop()
+	target := glis.Addr().String()
+
+	// the sshd.
+	slis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slis.Close()
+	live := &liveConns{conns: make(map[string]map[*trackedConn]bool)}
+	go func() {
+		for {
+			nConn, err := slis.Accept()
+			if err != nil {
+				return
+			}
+			c := &trackedConn{Conn: nConn, live: live}
+			config := &xssh.ServerConfig{
+				Config:            xssh.Config{Halt: xssh.NewHalter()},
+				PublicKeyCallback: keyCallback(keys, live, c),
+			}
+			config.AddHostKey(signer)
+			if err := forward(context.Background(), c, config, target, logins); err != nil {
+				c.Close()
+			}
+		}
+	}()
+
+	// alice logs in, and says she is bob.
+	nConn, err := net.Dial("tcp", slis.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	halt := xssh.NewHalter()
+	defer halt.RequestStop()
+	sshConn, chans, reqs, err := xssh.NewClientConn(context.Background(), nConn, slis.Addr().String(), &xssh.ClientConfig{
+		User:            "alice",
+		HostPort:        slis.Addr().String(),
+		Auth:            []xssh.AuthMethod{xssh.PublicKeys(signer)},
+		HostKeyCallback: xssh.InsecureIgnoreHostKey(),
+		Config:          xssh.Config{Halt: halt},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := xssh.NewClient(context.Background(), sshConn, chans, reqs, halt)
+	defer cli.Close()
+
+	if _, err := cli.Dial("tcp", "127.0.0.1:1"); err == nil {
+		t.Errorf("the sshd forwarded a tunnel to a port other than ours")
+	}
+
+	conn, err := grpc.Dial(target,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return cli.Dial("tcp", addr)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-ssh-user", "bob")
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if who := <-callers; who != "alice" {
+		t.Errorf("called as '%s'; want 'alice', who logged in", who)
+	}
+
+	// straight to the gRPC port, not through the sshd, a caller is no one.
+	direct, err := grpc.Dial(target, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer direct.Close()
+	if _, err := healthpb.NewHealthClient(direct).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if who := <-callers; who != "" {
+		t.Errorf("called as '%s' without going through the sshd", who)
+	}
+}
@@ -0,0 +1,203 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+	"github.com/devops-filetransfer/filetransfer/server/metrics"
+	"github.com/devops-filetransfer/filetransfer/server/sshkeys"
+	tun "github.com/devops-filetransfer/sshego"
+	xssh "github.com/glycerine/sshego/xendor/github.com/glycerine/xcryptossh"
+)
+
+// serve accepts SSH logins on cfg.EmbeddedSSHd.Addr, in place of
+// Esshd.Start, whose key check knows a single RSA key per user,
+// made on the server. We let a login in with any of the keys
+// registered for it in keys, and cut off its connections when the
+// key it used is revoked. Tunnels go to target alone, and logins
+// learns the login behind each.
+func serve(ctx context.Context, cfg *tun.SshegoConfig, keys *sshkeys.Store, target string, logins *identity.SSHLogins) (*Daemon, error) {
+	lis, err := net.Listen("tcp", cfg.EmbeddedSSHd.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	state := tun.NewAuthState(nil)
+	state.HostKey = cfg.HostDb.HostSshSigner
+
+	live := &liveConns{conns: make(map[string]map[*trackedConn]bool)}
+	keys.OnRevoke = live.cut
+
+	ctx, cancel := context.WithCancel(ctx)
+	d := &Daemon{Addr: lis.Addr(), cancel: cancel, live: live}
+
+	go func() {
+		<-ctx.Done()
+		lis.Close()
+	}()
+
+	go func() {
+		for {
+			nConn, err := lis.Accept()
+			if err != nil {
+				log.Printf("ssh: no longer accepting on %v: %v", cfg.EmbeddedSSHd.Addr, err)
+				d.stopped(err)
+				return
+			}
+
+			go func() {
+				c := &trackedConn{Conn: nConn, live: live}
+
+				attempt := tun.NewPerAttempt(state, cfg)
+				attempt.SetupAuthRequirements()
+				attempt.Config.PublicKeyCallback = keyCallback(keys, live, c)
+
+				if err := forward(ctx, c, attempt.Config, target, logins); err != nil {
+					metrics.HandshakeFailed(metrics.SSH)
+					log.Printf("ssh: %v", err)
+					c.Close()
+				}
+			}()
+		}
+	}()
+
+	return d, nil
+}
+
+// Daemon is the embedded sshd, once it is taking logins.
+type Daemon struct {
+	Addr net.Addr
+
+	cancel context.CancelFunc
+	live   *liveConns
+
+	mu  sync.Mutex
+	err error // why it stopped accepting
+}
+
+// Close stops taking logins, and closes the connections of those
+// logged in, along with their tunnels.
+func (d *Daemon) Close() {
+	d.cancel()
+
+	n := d.live.closeAll()
+	log.Printf("ssh: stopped on %v; closed %v connection(s)", d.Addr, n)
+}
+
+func (d *Daemon) stopped(err error) {
+	d.mu.Lock()
+	d.err = err
+	d.mu.Unlock()
+}
+
+// Ready says whether the daemon is still taking logins.
+func (d *Daemon) Ready() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.err != nil {
+		return fmt.Errorf("sshd on %v is no longer accepting: %v", d.Addr, d.err)
+	}
+
+	return nil
+}
+
+// keyCallback lets in a login presenting one of its registered keys.
+func keyCallback(keys *sshkeys.Store, live *liveConns, c *trackedConn) func(xssh.ConnMetadata, xssh.PublicKey) (*xssh.Permissions, error) {
+	return func(meta xssh.ConnMetadata, pub xssh.PublicKey) (*xssh.Permissions, error) {
+		k, err := keys.Authorized(meta.User(), pub.Marshal())
+		if err != nil {
+			log.Printf("ssh: refusing '%s' from %v: %v", meta.User(), meta.RemoteAddr(), err)
+			return nil, err
+		}
+		if k == nil {
+			log.Printf("ssh: refusing '%s' from %v: key %s is not registered for them", meta.User(), meta.RemoteAddr(), xssh.FingerprintSHA256(pub))
+			return nil, fmt.Errorf("unknown public key for '%s'", meta.User())
+		}
+
+		// the client may ask about a key before signing with it,
+		// so we can be called twice for the one connection.
+		live.add(k, c)
+
+		return &xssh.Permissions{Extensions: map[string]string{"fingerprint": k.Fingerprint, userExtension: meta.User()}}, nil
+	}
+}
+
+// liveConns are the connections logged in with each key.
+type liveConns struct {
+	mu    sync.Mutex
+	conns map[string]map[*trackedConn]bool // by user and fingerprint
+}
+
+func liveKey(k *sshkeys.Key) string {
+	return k.User + " " + k.Fingerprint
+}
+
+func (l *liveConns) add(k *sshkeys.Key, c *trackedConn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	key := liveKey(k)
+	if l.conns[key] == nil {
+		l.conns[key] = make(map[*trackedConn]bool)
+	}
+	l.conns[key][c] = true
+	c.key = key
+}
+
+func (l *liveConns) drop(c *trackedConn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.conns[c.key], c)
+	if len(l.conns[c.key]) == 0 {
+		delete(l.conns, c.key)
+	}
+}
+
+// cut closes the connections logged in with k, which has been revoked.
+func (l *liveConns) cut(k sshkeys.Key) {
+	l.mu.Lock()
+	conns := l.conns[liveKey(&k)]
+	delete(l.conns, liveKey(&k))
+	l.mu.Unlock()
+
+	for c := range conns {
+		c.Conn.Close()
+	}
+	log.Printf("ssh: revoked %s key %s for '%s'; closed %v connection(s) using it", k.Type, k.Fingerprint, k.User, len(conns))
+}
+
+// closeAll closes every connection, returning how many there were.
+func (l *liveConns) closeAll() int {
+	l.mu.Lock()
+	all := l.conns
+	l.conns = make(map[string]map[*trackedConn]bool)
+	l.mu.Unlock()
+
+	n := 0
+	for _, conns := range all {
+		for c := range conns {
+			c.Conn.Close()
+			n++
+		}
+	}
+
+	return n
+}
+
+// trackedConn leaves liveConns when closed.
+type trackedConn struct {
+	net.Conn
+	live *liveConns
+	key  string
+}
+
+func (c *trackedConn) Close() error {
+	c.live.drop(c)
+	return c.Conn.Close()
+}
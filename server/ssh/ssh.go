@@ -33,9 +33,13 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 
+	"github.com/devops-filetransfer/filetransfer/server/identity"
 	"github.com/devops-filetransfer/filetransfer/server/print"
+	"github.com/devops-filetransfer/filetransfer/server/sshkeys"
 	tun "github.com/devops-filetransfer/sshego"
+	xssh "github.com/glycerine/sshego/xendor/github.com/glycerine/xcryptossh"
 )
 
 func SetupSshFlags(myflags *flag.FlagSet) *tun.SshegoConfig {
@@ -45,7 +49,11 @@ func SetupSshFlags(myflags *flag.FlagSet) *tun.SshegoConfig {
 	return cfg
 }
 
-func ServerSshMain(cfg *tun.SshegoConfig, host string, securedPort, targetPort int) error {
+// ServerSshMain starts the embedded sshd on host:securedPort,
+// taking logins with the keys registered in keys, and forwarding
+// their tunnels to our gRPC service on targetPort; logins learns
+// which login each forwarded connection belongs to.
+func ServerSshMain(cfg *tun.SshegoConfig, keys *sshkeys.Store, logins *identity.SSHLogins, host string, securedPort, targetPort int) (*Daemon, error) {
 	if cfg.ShowVersion {
 		fmt.Printf("\n%v\n", tun.SourceVersion())
 		os.Exit(0)
@@ -70,12 +78,10 @@ func ServerSshMain(cfg *tun.SshegoConfig, host string, securedPort, targetPort i
 
 	cfg.KnownHosts = h
 
-	if cfg.AddUser != "" {
-		tun.AddUserAndExit(cfg)
-	}
-
-	if cfg.DelUser != "" {
-		tun.DelUserAndExit(cfg)
+	// these made a key pair on the server, for the user
+	// to carry off; users now bring their own public keys.
+	if cfg.AddUser != "" || cfg.DelUser != "" {
+		return nil, fmt.Errorf("-adduser and -deluser are gone; register a user's public key with 'server ssh key add', and remove it with 'server ssh key revoke'")
 	}
 
 	log.Printf("grpc-demo/server/ssh.go is starting -esshd with addr: %s", cfg.EmbeddedSSHd.Addr)
@@ -83,13 +89,39 @@ func ServerSshMain(cfg *tun.SshegoConfig, host string, securedPort, targetPort i
 	err = cfg.EmbeddedSSHd.ParseAddr()
 	if err != nil {
 		print.P("grpc-demo/server/ssh.go cfg.EmbeddedSSHd.ParseAddr() error = '%s'", err)
-		return err
+		return nil, err
+	}
+
+	if err := seedHostDb(cfg.EmbeddedSSHdHostDbPath); err != nil {
+		return nil, err
 	}
 
 	cfg.NewEsshd()
-	print.P("grpc-demo/server/ssh.go about to call cfg.Esshd.Start()")
+	print.P("grpc-demo/server/ssh.go taking SSH logins with the keys in '%s'", keys.Path)
+
+	// clients check this, out of band, before they first trust us.
+	fp := xssh.FingerprintSHA256(cfg.HostDb.HostSshSigner.PublicKey())
+	log.Printf("SSH host key %s; clients can pin it with -host_key %s", fp, fp)
+
+	return serve(context.Background(), cfg, keys, fmt.Sprintf("127.0.0.1:%v", targetPort), logins)
+}
 
-	go cfg.Esshd.Start(context.Background())
+// seedHostDb gives sshego an empty host database to start from,
+// where it keeps its host key; on a first run it makes an empty
+// file, which it then cannot read.
+func seedHostDb(dir string) error {
+	path := filepath.Join(dir, "msgp.db")
+	if fi, err := os.Stat(path); err == nil && fi.Size() > 0 {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	by, err := (&tun.Filedb{}).MarshalMsg(nil)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return os.WriteFile(path, by, 0600)
 }
@@ -0,0 +1,313 @@
+// Package sshkeys keeps the public keys each SSH login may use.
+// Users make their key pairs on their own machines and hand us
+// only the public half, so no private key ever has to travel.
+package sshkeys
+
+import (
+	"bytes"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// MinRSABits is the smallest RSA key we accept.
+const MinRSABits = 2048
+
+// File is where, under DefaultDir, we keep the keys.
+const File = "authorized_keys.json"
+
+// DefaultDir is where we keep our SSH state unless told otherwise.
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+
+	return filepath.Join(home, ".filetransfer", "ssh")
+}
+
+// DefaultPath is the key file we use unless told otherwise.
+func DefaultPath() string {
+	return filepath.Join(DefaultDir(), File)
+}
+
+// loginRE is what we accept as a login; the same as sshego does.
+var loginRE = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// Key is one public key that User may log in with.
+type Key struct {
+	User        string    `json:"user"`
+	Type        string    `json:"type"`
+	Fingerprint string    `json:"fingerprint"`
+	Comment     string    `json:"comment,omitempty"`
+	Added       time.Time `json:"added"`
+
+	// AuthorizedKey is the key in authorized_keys form.
+	AuthorizedKey string `json:"key"`
+}
+
+type file struct {
+	Keys []Key `json:"keys"`
+}
+
+// ParseKey parses one line in authorized_keys form, such as a
+// .pub file written by ssh-keygen, and returns the Key for user.
+// Only Ed25519 keys, and RSA keys of at least MinRSABits, will do.
+func ParseKey(user string, line []byte) (*Key, error) {
+	if !loginRE.MatchString(user) {
+		return nil, fmt.Errorf("bad login '%s': must match '%s'", user, loginRE)
+	}
+
+	pub, comment, _, _, err := ssh.ParseAuthorizedKey(line)
+	if err != nil {
+		return nil, fmt.Errorf("not a public key in authorized_keys form: %v", err)
+	}
+
+	switch pub.Type() {
+	case ssh.KeyAlgoED25519:
+	case ssh.KeyAlgoRSA:
+		rsaKey, ok := pub.(ssh.CryptoPublicKey).CryptoPublicKey().(*rsa.PublicKey)
+		if !ok || rsaKey.N.BitLen() < MinRSABits {
+			return nil, fmt.Errorf("RSA keys must have at least %v bits", MinRSABits)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported key type '%s'; use ssh-ed25519 or ssh-rsa", pub.Type())
+	}
+
+	return &Key{
+		User:          user,
+		Type:          pub.Type(),
+		Fingerprint:   ssh.FingerprintSHA256(pub),
+		Comment:       comment,
+		Added:         time.Now().UTC(),
+		AuthorizedKey: strings.TrimSpace(string(ssh.MarshalAuthorizedKey(pub))),
+	}, nil
+}
+
+// Store holds the keys kept in the file at Path. Changes made
+// to the file by another process, such as 'server ssh key', are
+// picked up the next time we look.
+type Store struct {
+	Path string
+
+	// OnRevoke, if set, is called for each key that goes away,
+	// whether through Revoke or from the file.
+	OnRevoke func(Key)
+
+	mu    sync.Mutex
+	keys  []Key
+	wire  map[string]*Key // by user and marshalled public key
+	stamp string
+}
+
+// Open returns the Store kept at path, which need not exist yet.
+func Open(path string) (*Store, error) {
+	s := &Store{Path: path}
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// fileStamp tells us whether the file has changed; it is ""
+// while there is no file.
+func (s *Store) fileStamp() (string, error) {
+	fi, err := os.Stat(s.Path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%v:%v", fi.Size(), fi.ModTime().UnixNano()), nil
+}
+
+// refresh reads the file again if it has changed. The caller
+// holds s.mu, or is Open.
+func (s *Store) refresh() error {
+	stamp, err := s.fileStamp()
+	if err != nil {
+		return err
+	}
+	if stamp == s.stamp && s.wire != nil {
+		return nil
+	}
+
+	var f file
+	if stamp != "" {
+		data, err := os.ReadFile(s.Path)
+		if err != nil {
+			return err
+		}
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&f); err != nil {
+			return fmt.Errorf("ssh keys '%s': %v", s.Path, err)
+		}
+	}
+
+	wire := make(map[string]*Key, len(f.Keys))
+	for i := range f.Keys {
+		k := &f.Keys[i]
+		pub, _, _, _, err := ssh.ParseAuthorizedKey([]byte(k.AuthorizedKey))
+		if err != nil {
+			return fmt.Errorf("ssh keys '%s': key %v for '%s': %v", s.Path, i, k.User, err)
+		}
+		wire[k.User+"\x00"+string(pub.Marshal())] = k
+	}
+
+	old := s.keys
+	s.keys, s.wire, s.stamp = f.Keys, wire, stamp
+
+	if s.OnRevoke != nil {
+		for _, k := range old {
+			if s.find(k.User, k.Fingerprint) < 0 {
+				s.OnRevoke(k)
+			}
+		}
+	}
+
+	return nil
+}
+
+// commit writes keys out, replacing the file in one go so
+// readers never see half of it, and then reads it back.
+func (s *Store) commit(keys []Key) error {
+	data, err := json.MarshalIndent(file{Keys: keys}, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0700); err != nil {
+		return err
+	}
+
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+
+	// the file may change twice within the clock's resolution.
+	s.stamp = ""
+
+	return s.refresh()
+}
+
+func (s *Store) find(user, fingerprint string) int {
+	for i, k := range s.keys {
+		if k.User == user && k.Fingerprint == fingerprint {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// Add registers the public key in line, in authorized_keys form,
+// for user. Adding a key the user already has is an error.
+func (s *Store) Add(user string, line []byte) (*Key, error) {
+	k, err := ParseKey(user, line)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	if s.find(user, k.Fingerprint) >= 0 {
+		return nil, fmt.Errorf("'%s' already has key %s", user, k.Fingerprint)
+	}
+
+	keys := append(append([]Key(nil), s.keys...), *k)
+	if err := s.commit(keys); err != nil {
+		return nil, err
+	}
+
+	return k, nil
+}
+
+// List returns the keys of user, or of everyone if user is "",
+// sorted by user and then by when they were added.
+func (s *Store) List(user string) ([]Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	var keys []Key
+	for _, k := range s.keys {
+		if user == "" || k.User == user {
+			keys = append(keys, k)
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		if keys[i].User != keys[j].User {
+			return keys[i].User < keys[j].User
+		}
+		return keys[i].Added.Before(keys[j].Added)
+	})
+
+	return keys, nil
+}
+
+// Revoke removes the key of user with the given SHA256
+// fingerprint, as shown by List and by 'ssh-keygen -l'.
+func (s *Store) Revoke(user, fingerprint string) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+	i := s.find(user, fingerprint)
+	if i < 0 {
+		return nil, fmt.Errorf("'%s' has no key %s", user, fingerprint)
+	}
+
+	k := s.keys[i]
+	keys := append(append([]Key(nil), s.keys[:i]...), s.keys[i+1:]...)
+	if err := s.commit(keys); err != nil {
+		return nil, err
+	}
+
+	return &k, nil
+}
+
+// Authorized returns the key of user that pub, in SSH wire
+// form, matches; or nil if user may not log in with it.
+func (s *Store) Authorized(user string, pub []byte) (*Key, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.refresh(); err != nil {
+		return nil, err
+	}
+
+	k, ok := s.wire[user+"\x00"+string(pub)]
+	if !ok {
+		return nil, nil
+	}
+	kk := *k
+
+	return &kk, nil
+}
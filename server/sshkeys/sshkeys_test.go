@@ -0,0 +1,89 @@
+package sshkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/crypto/ssh"
+)
+
+func authorizedKey(t *testing.T, pub interface{}) (ssh.PublicKey, []byte) {
+	k, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k, ssh.MarshalAuthorizedKey(k)
+}
+
+func TestParseKey(t *testing.T) {
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	_, line := authorizedKey(t, edPub)
+	if _, err := ParseKey("alice", line); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseKey("../alice", line); err == nil {
+		t.Fatal("took a bad login")
+	}
+
+	small, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, line = authorizedKey(t, &small.PublicKey)
+	if _, err := ParseKey("alice", line); err == nil {
+		t.Fatal("took a 1024 bit RSA key")
+	}
+}
+
+func TestAddAndRevoke(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ssh", File)
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var revoked []Key
+	s.OnRevoke = func(k Key) { revoked = append(revoked, k) }
+
+	edPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	pub, line := authorizedKey(t, edPub)
+
+	k, err := s.Add("alice", line)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add("alice", line); err == nil {
+		t.Fatal("added the same key twice")
+	}
+
+	if got, _ := s.Authorized("alice", pub.Marshal()); got == nil || got.Fingerprint != k.Fingerprint {
+		t.Fatal("alice's key not authorized")
+	}
+	if got, _ := s.Authorized("bob", pub.Marshal()); got != nil {
+		t.Fatal("alice's key let bob in")
+	}
+
+	// another process, such as 'server ssh key revoke', edits
+	// the file; we notice at the next login.
+	other, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Revoke("alice", k.Fingerprint); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := s.Authorized("alice", pub.Marshal()); got != nil {
+		t.Fatal("revoked key still authorized")
+	}
+	if len(revoked) != 1 || revoked[0].Fingerprint != k.Fingerprint {
+		t.Fatalf("OnRevoke saw %v", revoked)
+	}
+
+	if _, err := s.Revoke("alice", k.Fingerprint); err == nil {
+		t.Fatal("revoked a key twice")
+	}
+}
@@ -0,0 +1,528 @@
+// Package store keeps received files on the local disk.
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/devops-filetransfer/filetransfer/server/attr"
+	"github.com/devops-filetransfer/filetransfer/server/jail"
+	"github.com/devops-filetransfer/filetransfer/server/manifest"
+	pb "github.com/devops-filetransfer/filetransfer/server/protobuf"
+	"github.com/devops-filetransfer/filetransfer/server/seal"
+	"github.com/devops-filetransfer/filetransfer/server/sparse"
+)
+
+// MetaSuffix is added to a file's name for the name of the file
+// its metadata is kept in.
+const MetaSuffix = ".meta"
+
+// Store writes files under Root, each tenant in its own
+// subdirectory. Each file is written to a temporary name next
+// to its final one, and only appears under its real name once
+// committed, so readers never see a partial file.
+//
+// A file uploaded with a signed manifest has it kept beside it,
+// in the clear, as "."+name+manifest.SigSuffix; one sent with
+// free-form metadata has that kept, as JSON, as "."+name+MetaSuffix.
+// Our jail refuses clients these names, and those of partial files.
+//
+// With Keys set, files are sealed at rest, each with its own data
+// key wrapped by Keys, and so is their metadata; files stored
+// before that are still read as they are.
+//
+// RootSetID lets files applied to root, under an Owner Policy or
+// more, keep their setuid and setgid bits.
+type Store struct {
+	Root      string
+	Policy    attr.Policy
+	Keys      seal.Wrapper
+	RootSetID bool
+
+	jail *jail.Jail
+
+	// committed are the directories files have been renamed into
+	// since the last Flush.
+	mu        sync.Mutex
+	committed map[string]bool
+}
+
+// New returns a Store rooted at root, creating it if need be.
+func New(root string, policy attr.Policy) (*Store, error) {
+	j, err := jail.New(root, manifest.SigSuffix, MetaSuffix)
+	if err != nil {
+		return nil, fmt.Errorf("store: %v", err)
+	}
+
+	return &Store{Root: j.Root, Policy: policy, jail: j, committed: make(map[string]bool)}, nil
+}
+
+// Writer receives the data of one file.
+type Writer struct {
+	f      *os.File
+	sw     *seal.Writer
+	tmp    string
+	final  string
+	size   int64
+	sig    []byte
+	meta   map[string]string
+	policy attr.Policy
+	store  *Store
+}
+
+// Create starts a new file that will be committed as path,
+// for tenant.
+func (s *Store) Create(tenant, path string) (*Writer, error) {
+	final, err := s.jail.Resolve(tenant, path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(final)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("store: could not create directory for '%s': %v", path, err)
+	}
+
+	f, err := os.CreateTemp(dir, "."+filepath.Base(final)+jail.PartialMark+"*")
+	if err != nil {
+		return nil, fmt.Errorf("store: could not create '%s': %v", path, err)
+	}
+
+	w := &Writer{f: f, tmp: f.Name(), final: final, policy: s.Policy, store: s}
+	if s.Keys != nil {
+		w.sw, err = seal.NewWriter(f, s.Keys)
+		if err != nil {
+			_ = w.Abort()
+			return nil, fmt.Errorf("store: could not create '%s': %v", path, err)
+		}
+	}
+
+	return w, nil
+}
+
+// Write appends p to the file.
+func (w *Writer) Write(p []byte) (int, error) {
+	var n int
+	var err error
+	if w.sw != nil {
+		n, err = w.sw.Write(p)
+	} else {
+		n, err = w.f.Write(p)
+	}
+	w.size += int64(n)
+
+	return n, err
+}
+
+// Hole skips over n zero bytes, leaving a hole in the file
+// where the filesystem supports that.
+func (w *Writer) Hole(n int64) error {
+	if w.sw != nil {
+		if err := w.sw.Hole(n); err != nil {
+			return err
+		}
+	} else if _, err := w.f.Seek(n, io.SeekCurrent); err != nil {
+		return err
+	}
+	w.size += n
+
+	return nil
+}
+
+// Size is the number of bytes written (or skipped) so far.
+func (w *Writer) Size() int64 {
+	return w.size
+}
+
+// Sign has the file committed along with its signed manifest.
+func (w *Writer) Sign(signed []byte) {
+	w.sig = signed
+}
+
+// SetMetadata has the file committed along with md.
+func (w *Writer) SetMetadata(md map[string]string) {
+	w.meta = md
+}
+
+// Commit flushes the file, applies a as far as the store's
+// policy allows, and moves the file to its final name. Its signed
+// manifest and metadata, if any, follow it in; a file committed
+// without them loses any its predecessor had.
+func (w *Writer) Commit(a *pb.FileAttr) error {
+	if w.sw != nil {
+		if err := w.sw.Close(); err != nil {
+			_ = w.Abort()
+			return err
+		}
+	} else if err := w.f.Truncate(w.size); err != nil {
+		// a trailing hole only counts once the size says so.
+		_ = w.Abort()
+		return err
+	}
+
+	if err := w.f.Sync(); err != nil {
+		_ = w.Abort()
+		return err
+	}
+
+	if err := w.f.Close(); err != nil {
+		_ = os.Remove(w.tmp)
+		return err
+	}
+
+	if err := attr.Apply(w.tmp, a, w.policy, w.store.RootSetID); err != nil {
+		_ = os.Remove(w.tmp)
+		return err
+	}
+
+	var meta []byte
+	if len(w.meta) > 0 {
+		var err error
+		if meta, err = json.Marshal(w.meta); err != nil {
+			_ = os.Remove(w.tmp)
+			return err
+		}
+		if w.store.Keys != nil {
+			if meta, err = sealBytes(meta, w.store.Keys); err != nil {
+				_ = os.Remove(w.tmp)
+				return fmt.Errorf("store: could not seal the metadata of '%s': %v", w.final, err)
+			}
+		}
+	}
+
+	// the sidecars are staged first, and put in place only once the
+	// file is, so a commit that fails leaves the last file as it was.
+	sigTmp, err := stageSidecar(sigPath(w.final), w.sig)
+	if err != nil {
+		_ = os.Remove(w.tmp)
+		return fmt.Errorf("store: could not keep the signature of '%s': %v", w.final, err)
+	}
+	metaTmp, err := stageSidecar(metaPath(w.final), meta)
+	if err != nil {
+		_ = os.Remove(w.tmp)
+		unstage(sigTmp)
+		return fmt.Errorf("store: could not keep the metadata of '%s': %v", w.final, err)
+	}
+
+	if err := os.Rename(w.tmp, w.final); err != nil {
+		_ = os.Remove(w.tmp)
+		unstage(sigTmp)
+		unstage(metaTmp)
+		return err
+	}
+
+	// the file is in; sidecars that cannot follow it must not stay
+	// behind as those of the last one.
+	if err := placeSidecar(sigTmp, sigPath(w.final)); err != nil {
+		unstage(metaTmp)
+		_ = os.Remove(sigPath(w.final))
+		_ = os.Remove(metaPath(w.final))
+		return fmt.Errorf("store: could not keep the signature of '%s': %v", w.final, err)
+	}
+	if err := placeSidecar(metaTmp, metaPath(w.final)); err != nil {
+		_ = os.Remove(metaPath(w.final))
+		return fmt.Errorf("store: could not keep the metadata of '%s': %v", w.final, err)
+	}
+
+	w.store.mu.Lock()
+	w.store.committed[filepath.Dir(w.final)] = true
+	w.store.mu.Unlock()
+
+	return nil
+}
+
+// sigPath is where the signed manifest of the file at full is kept.
+func sigPath(full string) string {
+	return filepath.Join(filepath.Dir(full), "."+filepath.Base(full)+manifest.SigSuffix)
+}
+
+// metaPath is where the metadata of the file at full is kept.
+func metaPath(full string) string {
+	return filepath.Join(filepath.Dir(full), "."+filepath.Base(full)+MetaSuffix)
+}
+
+// sealBytes seals data whole, as a file of its own.
+func sealBytes(data []byte, keys seal.Wrapper) ([]byte, error) {
+	var b bytes.Buffer
+	sw, err := seal.NewWriter(&b, keys)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := sw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := sw.Close(); err != nil {
+		return nil, err
+	}
+
+	return b.Bytes(), nil
+}
+
+// stageSidecar writes data to a temporary name beside path, and
+// returns that name, for placeSidecar to put in place; with nil
+// data it stages nothing, and returns "".
+func stageSidecar(path string, data []byte) (string, error) {
+	if data == nil {
+		return "", nil
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+jail.PartialMark+"*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(f.Name())
+		return "", err
+	}
+
+	return f.Name(), nil
+}
+
+// placeSidecar moves the sidecar staged as tmp to path, or removes
+// what is at path if nothing was staged.
+func placeSidecar(tmp, path string) error {
+	if tmp == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		unstage(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// unstage throws away a sidecar staged as tmp.
+func unstage(tmp string) {
+	if tmp != "" {
+		_ = os.Remove(tmp)
+	}
+}
+
+// Abort throws away the partial file.
+func (w *Writer) Abort() error {
+	_ = w.f.Close()
+
+	return os.Remove(w.tmp)
+}
+
+// File is a committed file, opened for reading.
+type File interface {
+	io.ReaderAt
+	io.Closer
+
+	// Size is the size of the file's contents.
+	Size() int64
+
+	// Segments returns its data and holes, as sparse.Map does.
+	Segments() ([]sparse.Segment, error)
+}
+
+// Open returns tenant's committed file stored as path, along
+// with its metadata; extended attributes are included if the
+// policy is Full.
+func (s *Store) Open(tenant, path string) (File, *pb.FileAttr, error) {
+	full, err := s.jail.Resolve(tenant, path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	a, err := attr.FromFile(full, s.Policy >= attr.Full)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !seal.IsSealed(f) {
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, err
+		}
+		return &plainFile{File: f, size: fi.Size()}, a, nil
+	}
+
+	if s.Keys == nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("store: '%s' is encrypted, and this server has no -store_key to open it with", path)
+	}
+
+	r, err := seal.Open(f, s.Keys)
+	if err != nil {
+		_ = f.Close()
+		return nil, nil, fmt.Errorf("store: '%s': %v", path, err)
+	}
+
+	return &sealedFile{Reader: r, f: f}, a, nil
+}
+
+type plainFile struct {
+	*os.File
+	size int64
+}
+
+func (f *plainFile) Size() int64 {
+	return f.size
+}
+
+func (f *plainFile) Segments() ([]sparse.Segment, error) {
+	return sparse.Map(f.File, f.size)
+}
+
+type sealedFile struct {
+	*seal.Reader
+	f *os.File
+}
+
+func (f *sealedFile) Segments() ([]sparse.Segment, error) {
+	return f.Reader.Segments(), nil
+}
+
+func (f *sealedFile) Close() error {
+	return f.f.Close()
+}
+
+// Signature returns the signed manifest tenant's file path was
+// stored with, or nil if it came without one.
+func (s *Store) Signature(tenant, path string) ([]byte, error) {
+	full, err := s.jail.Resolve(tenant, path)
+	if err != nil {
+		return nil, err
+	}
+
+	signed, err := os.ReadFile(sigPath(full))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	return signed, err
+}
+
+// Metadata returns the metadata tenant's file path was stored
+// with, or nil if it came without any.
+func (s *Store) Metadata(tenant, path string) (map[string]string, error) {
+	full, err := s.jail.Resolve(tenant, path)
+	if err != nil {
+		return nil, err
+	}
+
+	by, err := os.ReadFile(metaPath(full))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if r := bytes.NewReader(by); seal.IsSealed(r) {
+		if s.Keys == nil {
+			return nil, fmt.Errorf("store: the metadata of '%s' is encrypted, and this server has no -store_key to open it with", path)
+		}
+		sr, err := seal.Open(r, s.Keys)
+		if err != nil {
+			return nil, fmt.Errorf("store: metadata of '%s': %v", path, err)
+		}
+		by = make([]byte, sr.Size())
+		if _, err := sr.ReadAt(by, 0); err != nil && err != io.EOF {
+			return nil, fmt.Errorf("store: metadata of '%s': %v", path, err)
+		}
+	}
+
+	var md map[string]string
+	if err := json.Unmarshal(by, &md); err != nil {
+		return nil, fmt.Errorf("store: metadata of '%s': %v", path, err)
+	}
+
+	return md, nil
+}
+
+// Remove deletes tenant's committed file stored as path, and its
+// signed manifest and metadata. Directories are left alone.
+func (s *Store) Remove(tenant, path string) error {
+	full, err := s.jail.Resolve(tenant, path)
+	if err != nil {
+		return err
+	}
+
+	fi, err := os.Lstat(full)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("store: '%s' is a directory", path)
+	}
+
+	if err := os.Remove(full); err != nil {
+		return err
+	}
+
+	if err := placeSidecar("", sigPath(full)); err != nil {
+		return err
+	}
+
+	return placeSidecar("", metaPath(full))
+}
+
+// Check says whether we can still write under Root, by writing
+// a small file there and removing it again.
+func (s *Store) Check() error {
+	f, err := os.CreateTemp(s.Root, ".check-*")
+	if err != nil {
+		return fmt.Errorf("store: cannot write under '%s': %v", s.Root, err)
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write([]byte("ok\n")); err != nil {
+		f.Close()
+		return fmt.Errorf("store: cannot write under '%s': %v", s.Root, err)
+	}
+
+	return f.Close()
+}
+
+// Flush syncs the directories files have been committed into
+// since the last Flush, so that their new names survive a crash;
+// the files themselves are synced as they are committed.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	dirs := s.committed
+	s.committed = make(map[string]bool)
+	s.mu.Unlock()
+
+	var first error
+	for dir := range dirs {
+		if err := syncDir(dir); err != nil && first == nil {
+			first = fmt.Errorf("store: could not sync '%s': %v", dir, err)
+		}
+	}
+
+	return first
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/devops-filetransfer/filetransfer/server/attr"
+	"github.com/devops-filetransfer/filetransfer/server/jail"
+	"github.com/devops-filetransfer/filetransfer/server/seal"
+)
+
+func TestMetadataIsSealedWithTheFile(t *testing.T) {
+	dir := t.TempDir()
+	pemKey, err := seal.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(dir, "store.key")
+	if err := os.WriteFile(keyPath, pemKey, 0600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := seal.LoadKeyFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := New(filepath.Join(dir, "root"), attr.None)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Keys = keys
+
+	w, err := s.Create("alice", "f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	md := map[string]string{"owner": "secret-team"}
+	w.SetMetadata(md)
+	if err := w.Commit(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(s.Root, "alice", ".f"+MetaSuffix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("secret-team")) {
+		t.Errorf("metadata kept in the clear: %q", raw)
+	}
+
+	got, err := s.Metadata("alice", "f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, md) {
+		t.Errorf("got metadata %v back; want %v", got, md)
+	}
+}
+
+func TestFailedCommitLeavesTheLastSidecars(t *testing.T) {
+	s, err := New(t.TempDir(), attr.None)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	commit := func(sig string) error {
+		w, err := s.Create("alice", "f")
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Sign([]byte(sig))
+		w.SetMetadata(map[string]string{"sig": sig})
+		return w.Commit(nil)
+	}
+	if err := commit("first"); err != nil {
+		t.Fatal(err)
+	}
+
+	// a directory in its place makes the next commit fail.
+	full := filepath.Join(s.Root, "alice", "f")
+	if err := os.Remove(full); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(full, "d"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := commit("second"); err == nil {
+		t.Fatal("commit over a directory succeeded")
+	}
+
+	if sig, err := s.Signature("alice", "f"); err != nil || string(sig) != "first" {
+		t.Errorf("signature is %q, %v after a failed commit; want the first", sig, err)
+	}
+	if md, err := s.Metadata("alice", "f"); err != nil || md["sig"] != "first" {
+		t.Errorf("metadata is %v, %v after a failed commit; want the first", md, err)
+	}
+	if left, _ := filepath.Glob(filepath.Join(s.Root, "alice", "*"+jail.PartialMark+"*")); len(left) > 0 {
+		t.Errorf("partial files left behind: %v", left)
+	}
+}
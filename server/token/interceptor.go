@@ -0,0 +1,125 @@
+package token
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/devops-filetransfer/filetransfer/server/authz"
+	"github.com/devops-filetransfer/filetransfer/server/identity"
+)
+
+// Authenticator requires each call to carry a valid token, in
+// the "authorization" metadata as "Bearer <token>", unless the
+// caller has already proven who they are with a client
+// certificate, or is only asking after our health. The token's
+// subject becomes the caller's identity, and its operations cap
+// what the caller may do, whatever the authorization policy says.
+type Authenticator struct {
+	Key *Key
+}
+
+// healthService is the standard gRPC health service, which load
+// balancers must be able to ask without a token.
+const healthService = "/grpc.health.v1.Health/"
+
+// authenticate returns ctx with the caller's identity, and the
+// claims of their token; nil claims mean no token was needed.
+func (a *Authenticator) authenticate(ctx context.Context) (context.Context, *Claims, error) {
+	var tok string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get("authorization") {
+			if strings.HasPrefix(v, "Bearer ") {
+				tok = strings.TrimPrefix(v, "Bearer ")
+			}
+		}
+	}
+
+	if tok == "" {
+		if identity.FromContext(ctx) != "" {
+			return ctx, nil, nil
+		}
+		return nil, nil, status.Errorf(codes.Unauthenticated, "this server needs a bearer token")
+	}
+
+	c, err := a.Key.Verify(tok, time.Now())
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Unauthenticated, "%v", err)
+	}
+
+	return identity.NewContext(ctx, c.Subject), c, nil
+}
+
+// check refuses m unless c covers it.
+func check(c *Claims, m interface{}) error {
+	if c == nil {
+		return nil
+	}
+
+	op, paths := authz.OpsOf(m)
+	if op == "" || c.Allows(op) {
+		return nil
+	}
+
+	return status.Errorf(codes.PermissionDenied, "the token for '%s' does not allow %s of '%s'", c.Subject, op, strings.Join(paths, "', '"))
+}
+
+// UnaryInterceptor authenticates unary calls.
+func (a *Authenticator) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if strings.HasPrefix(info.FullMethod, healthService) {
+			return handler(ctx, req)
+		}
+
+		ctx, c, err := a.authenticate(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := check(c, req); err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor authenticates streams, and checks each
+// message received against the token.
+func (a *Authenticator) StreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if strings.HasPrefix(info.FullMethod, healthService) {
+			return handler(srv, ss)
+		}
+
+		ctx, c, err := a.authenticate(ss.Context())
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &tokenStream{ServerStream: ss, ctx: ctx, claims: c})
+	}
+}
+
+type tokenStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	claims *Claims
+}
+
+func (s *tokenStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *tokenStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	return check(s.claims, m)
+}
@@ -0,0 +1,250 @@
+// Package token issues and checks signed bearer tokens, in the
+// style of a JWT, signed with either an HMAC-SHA256 secret or an
+// Ed25519 key.
+package token
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/devops-filetransfer/filetransfer/server/authz"
+)
+
+// The algorithms we sign with, as named in the token header.
+const (
+	HS256 = "HS256"
+	EdDSA = "EdDSA"
+)
+
+// The PEM block types of our key files. Ed25519 keys are in
+// the standard PKCS #8 and PKIX forms.
+const (
+	hmacBlock    = "HMAC KEY"
+	privateBlock = "PRIVATE KEY"
+	publicBlock  = "PUBLIC KEY"
+)
+
+// minHMACKeyLen is the shortest HMAC secret we accept, in bytes.
+const minHMACKeyLen = 32
+
+// Claims are what a token says about its bearer.
+type Claims struct {
+	Subject  string     `json:"sub"`
+	Ops      []authz.Op `json:"ops"`
+	IssuedAt int64      `json:"iat"`
+	Expiry   int64      `json:"exp"`
+}
+
+// Allows says whether the claims cover op.
+func (c *Claims) Allows(op authz.Op) bool {
+	for _, o := range c.Ops {
+		if o == op {
+			return true
+		}
+	}
+
+	return false
+}
+
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+}
+
+// Key signs and checks tokens. A Key loaded from an Ed25519
+// public key can only check them.
+type Key struct {
+	alg    string
+	secret []byte
+	priv   ed25519.PrivateKey
+	pub    ed25519.PublicKey
+}
+
+// GenerateKey returns a new key of the given algorithm, HS256
+// or EdDSA, PEM encoded for writing to a key file.
+func GenerateKey(alg string) ([]byte, error) {
+	switch alg {
+	case HS256:
+		secret := make([]byte, minHMACKeyLen)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: hmacBlock, Bytes: secret}), nil
+
+	case EdDSA:
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: privateBlock, Bytes: der}), nil
+	}
+
+	return nil, fmt.Errorf("unknown token algorithm '%s'; expected %s or %s", alg, HS256, EdDSA)
+}
+
+// PublicKey returns the PEM encoded public half of an Ed25519
+// key, for servers that only need to check tokens.
+func (k *Key) PublicKey() ([]byte, error) {
+	if k.pub == nil {
+		return nil, fmt.Errorf("only Ed25519 keys have a public half")
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(k.pub)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: publicBlock, Bytes: der}), nil
+}
+
+// LoadKey reads a key file written by GenerateKey, or the
+// public half of an Ed25519 key.
+func LoadKey(path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("token key '%s' is not PEM encoded", path)
+	}
+
+	switch block.Type {
+	case hmacBlock:
+		if len(block.Bytes) < minHMACKeyLen {
+			return nil, fmt.Errorf("token key '%s' is too short; need at least %v bytes", path, minHMACKeyLen)
+		}
+		return &Key{alg: HS256, secret: block.Bytes}, nil
+
+	case privateBlock:
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("token key '%s': %v", path, err)
+		}
+		priv, ok := k.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("token key '%s' is not an Ed25519 key", path)
+		}
+		return &Key{alg: EdDSA, priv: priv, pub: priv.Public().(ed25519.PublicKey)}, nil
+
+	case publicBlock:
+		k, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("token key '%s': %v", path, err)
+		}
+		pub, ok := k.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("token key '%s' is not an Ed25519 key", path)
+		}
+		return &Key{alg: EdDSA, pub: pub}, nil
+	}
+
+	return nil, fmt.Errorf("token key '%s' has unknown PEM type '%s'", path, block.Type)
+}
+
+var b64 = base64.RawURLEncoding
+
+// Issue returns a token carrying c.
+func (k *Key) Issue(c *Claims) (string, error) {
+	if k.alg == EdDSA && k.priv == nil {
+		return "", fmt.Errorf("cannot issue tokens with only a public key")
+	}
+
+	hdr, err := json.Marshal(&header{Alg: k.alg, Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+
+	signed := b64.EncodeToString(hdr) + "." + b64.EncodeToString(body)
+
+	return signed + "." + b64.EncodeToString(k.sign([]byte(signed))), nil
+}
+
+func (k *Key) sign(msg []byte) []byte {
+	if k.alg == HS256 {
+		mac := hmac.New(sha256.New, k.secret)
+		mac.Write(msg)
+		return mac.Sum(nil)
+	}
+
+	return ed25519.Sign(k.priv, msg)
+}
+
+func (k *Key) verify(msg, sig []byte) bool {
+	if k.alg == HS256 {
+		return hmac.Equal(k.sign(msg), sig)
+	}
+
+	return ed25519.Verify(k.pub, msg, sig)
+}
+
+// Verify checks tok's signature and expiry as of now, and
+// returns its claims.
+func (k *Key) Verify(tok string, now time.Time) (*Claims, error) {
+	parts := strings.Split(tok, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed token")
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("malformed token signature")
+	}
+	if !k.verify([]byte(parts[0]+"."+parts[1]), sig) {
+		return nil, fmt.Errorf("bad token signature")
+	}
+
+	// INVAR: we signed this; but check we signed it as we would now.
+	var hdr header
+	if err := decode(parts[0], &hdr); err != nil {
+		return nil, err
+	}
+	if hdr.Alg != k.alg {
+		return nil, fmt.Errorf("token signed with %s, expected %s", hdr.Alg, k.alg)
+	}
+
+	c := &Claims{}
+	if err := decode(parts[1], c); err != nil {
+		return nil, err
+	}
+	if c.Subject == "" {
+		return nil, fmt.Errorf("token has no subject")
+	}
+	if now.Unix() >= c.Expiry {
+		return nil, fmt.Errorf("token for '%s' expired at %v", c.Subject, time.Unix(c.Expiry, 0).UTC())
+	}
+
+	return c, nil
+}
+
+func decode(part string, v interface{}) error {
+	data, err := b64.DecodeString(part)
+	if err != nil {
+		return fmt.Errorf("malformed token")
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("malformed token: %v", err)
+	}
+
+	return nil
+}
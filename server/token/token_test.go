@@ -0,0 +1,116 @@
+package token
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/devops-filetransfer/filetransfer/server/authz"
+)
+
+func newKey(t *testing.T, alg string) (*Key, string) {
+	data, err := GenerateKey(alg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(p, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := LoadKey(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return k, p
+}
+
+func TestIssueAndVerify(t *testing.T) {
+	now := time.Now()
+
+	for _, alg := range []string{HS256, EdDSA} {
+		k, _ := newKey(t, alg)
+
+		tok, err := k.Issue(&Claims{Subject: "ci-bot", Ops: []authz.Op{authz.Write}, IssuedAt: now.Unix(), Expiry: now.Add(time.Hour).Unix()})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := k.Verify(tok, now)
+		if err != nil {
+			t.Fatalf("%s: %v", alg, err)
+		}
+		if c.Subject != "ci-bot" || !c.Allows(authz.Write) || c.Allows(authz.Read) {
+			t.Fatalf("%s: wrong claims back: %#v", alg, c)
+		}
+
+		if _, err := k.Verify(tok, now.Add(2*time.Hour)); err == nil {
+			t.Fatalf("%s: expired token accepted", alg)
+		}
+
+		parts := strings.Split(tok, ".")
+		forged, _ := json.Marshal(&Claims{Subject: "root", Ops: []authz.Op{authz.Delete}, Expiry: now.Add(time.Hour).Unix()})
+		if _, err := k.Verify(parts[0]+"."+b64.EncodeToString(forged)+"."+parts[2], now); err == nil {
+			t.Fatalf("%s: token with altered claims accepted", alg)
+		}
+	}
+}
+
+func TestVerifyRejectsOtherKeys(t *testing.T) {
+	now := time.Now()
+	c := &Claims{Subject: "ci-bot", Expiry: now.Add(time.Hour).Unix()}
+
+	hk, _ := newKey(t, HS256)
+	ek, _ := newKey(t, EdDSA)
+	ek2, _ := newKey(t, EdDSA)
+
+	tok, err := hk.Issue(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ek.Verify(tok, now); err == nil {
+		t.Fatal("HS256 token accepted by an Ed25519 key")
+	}
+
+	tok, err = ek.Issue(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ek2.Verify(tok, now); err == nil {
+		t.Fatal("token accepted by the wrong Ed25519 key")
+	}
+}
+
+func TestPublicKeyOnlyVerifies(t *testing.T) {
+	now := time.Now()
+	k, _ := newKey(t, EdDSA)
+
+	pubPEM, err := k.PublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(t.TempDir(), "pub.pem")
+	if err := os.WriteFile(p, pubPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	pub, err := LoadKey(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tok, err := k.Issue(&Claims{Subject: "ci-bot", Expiry: now.Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pub.Verify(tok, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := pub.Issue(&Claims{Subject: "x"}); err == nil {
+		t.Fatal("issued a token with only a public key")
+	}
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTraceFollowsTheCallAndTheReplica(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	old := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	defer otel.SetTracerProvider(old)
+
+	if _, err := Setup("", "test"); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, span := Start(context.Background(), "transfer")
+	want := span.SpanContext().TraceID()
+
+	// what the client sends along with its call.
+	incoming := metadata.NewIncomingContext(context.Background(), metadata.Pairs("traceparent", Inject(ctx)))
+
+	var replica trace.SpanContext
+	info := &grpc.UnaryServerInfo{FullMethod: "/streambigfile.Peer/DeleteFile"}
+	_, err := UnaryInterceptor()(incoming, nil, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+		if got := trace.SpanContextFromContext(ctx).TraceID(); got != want {
+			t.Errorf("server traced under %v; want %v", got, want)
+		}
+
+		// a peer, given the TraceParent of the inventory entry.
+		_, s := Start(Extract(context.Background(), Inject(ctx)), "replica")
+		replica = s.SpanContext()
+		s.End()
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	span.End()
+
+	if replica.TraceID() != want {
+		t.Errorf("replica traced under %v; want %v", replica.TraceID(), want)
+	}
+	if n := len(rec.Ended()); n != 3 {
+		t.Errorf("recorded %v spans; want the client's, the server's and the replica's", n)
+	}
+
+	if err := Check("file:"); err == nil {
+		t.Errorf("-trace file: with no path was taken")
+	}
+}